@@ -30,6 +30,7 @@ import (
 	"runtime"
 	"text/template"
 
+	"golang.org/x/tools/go/buildutil"
 	"golang.org/x/tools/go/callgraph"
 	"golang.org/x/tools/go/callgraph/cha"
 	"golang.org/x/tools/go/callgraph/rta"
@@ -53,7 +54,7 @@ const Usage = `callgraph: display the the call graph of a Go program.
 
 Usage:
 
-  callgraph [-algo=static|cha|rta|pta] [-test] [-format=...] <args>...
+  callgraph [-algo=static|cha|rta|pta] [-test] [-format=...] [-tags=...] <args>...
 
 Flags:
 
@@ -71,6 +72,9 @@ Flags:
 
 -test      Include the package's tests in the analysis.
 
+-tags      Specifies a space-separated list of build tags to consider
+           satisfied when locating packages, as for 'go build -tags'.
+
 -format    Specifies the format in which each call graph edge is displayed.
            One of:
 
@@ -131,6 +135,8 @@ Examples:
 `
 
 func init() {
+	flag.Var((*buildutil.TagsFlag)(&build.Default.BuildTags), "tags", buildutil.TagsFlagDoc)
+
 	// If $GOMAXPROCS isn't set, use the full capacity of the machine.
 	// For small machines, use at least 4 threads.
 	if os.Getenv("GOMAXPROCS") == "" {
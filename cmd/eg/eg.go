@@ -6,6 +6,7 @@ package main // import "golang.org/x/tools/cmd/eg"
 import (
 	"flag"
 	"fmt"
+	"go/build"
 	"go/parser"
 	"go/printer"
 	"go/token"
@@ -13,6 +14,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"golang.org/x/tools/go/buildutil"
 	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/refactor/eg"
 )
@@ -26,12 +28,17 @@ var (
 	verboseFlag    = flag.Bool("v", false, "show verbose matcher diagnostics")
 )
 
+func init() {
+	flag.Var((*buildutil.TagsFlag)(&build.Default.BuildTags), "tags", buildutil.TagsFlagDoc)
+}
+
 const usage = `eg: an example-based refactoring tool.
 
-Usage: eg -t template.go [-w] [-transitive] <args>...
+Usage: eg -t template.go [-w] [-transitive] [-tags <tags>] <args>...
 -t template.go	specifies the template file (use -help to see explanation)
 -w          	causes files to be re-written in place.
 -transitive 	causes all dependencies to be refactored too.
+-tags       	specifies the build tags to consider satisfied, as for 'go build'.
 ` + loader.FromArgsUsage
 
 func main() {
@@ -61,9 +68,7 @@ func doMain() error {
 	}
 
 	// The first Created package is the template.
-	if err := conf.CreateFromFilenames("template", *templateFlag); err != nil {
-		return err //  e.g. "foo.go:1: syntax error"
-	}
+	conf.CreateFromFilenames("template", *templateFlag)
 
 	if len(args) == 0 {
 		fmt.Fprint(os.Stderr, usage)
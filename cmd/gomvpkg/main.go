@@ -12,6 +12,7 @@ import (
 	"go/build"
 	"os"
 
+	"golang.org/x/tools/go/buildutil"
 	"golang.org/x/tools/refactor/rename"
 )
 
@@ -22,11 +23,15 @@ var (
 	helpFlag     = flag.Bool("help", false, "show usage message")
 )
 
+func init() {
+	flag.Var((*buildutil.TagsFlag)(&build.Default.BuildTags), "tags", buildutil.TagsFlagDoc)
+}
+
 const Usage = `gomvpkg: moves a package, updating import declarations
 
 Usage:
 
- gomvpkg -from <path> -to <path> [-vcs_mv_cmd <template>]
+ gomvpkg -from <path> -to <path> [-vcs_mv_cmd <template>] [-tags <tags>]
 
 Flags:
 
@@ -41,6 +46,9 @@ Flags:
 
              For example: "git mv {{.Src}} {{.Dst}}"
 
+-tags        a space-separated list of build tags to consider satisfied
+             when locating packages, as for 'go build -tags'
+
 gomvpkg determines the set of packages that might be affected, including all
 packages importing the 'from' package and any of its subpackages. It will move
 the 'from' package and all its subpackages to the destination path and update all
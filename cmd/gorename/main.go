@@ -14,6 +14,7 @@ import (
 	"os"
 	"runtime"
 
+	"golang.org/x/tools/go/buildutil"
 	"golang.org/x/tools/refactor/rename"
 )
 
@@ -28,6 +29,7 @@ func init() {
 	flag.BoolVar(&rename.Force, "force", false, "proceed, even if conflicts were reported")
 	flag.BoolVar(&rename.DryRun, "dryrun", false, "show the change, but do not apply it")
 	flag.BoolVar(&rename.Verbose, "v", false, "print verbose information")
+	flag.Var((*buildutil.TagsFlag)(&build.Default.BuildTags), "tags", buildutil.TagsFlagDoc)
 
 	// If $GOMAXPROCS isn't set, use the full capacity of the machine.
 	// For small machines, use at least 4 threads.
@@ -23,6 +23,7 @@ import (
 	"runtime"
 	"runtime/pprof"
 
+	"golang.org/x/tools/go/buildutil"
 	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/oracle"
 )
@@ -52,6 +53,9 @@ The -format flag controls the output format:
 
 The -pos flag is required in all modes except 'callgraph'.
 
+The -tags flag specifies a space-separated list of build tags to
+consider satisfied when loading packages, as for 'go build -tags'.
+
 The mode argument determines the query to perform:
 
 	callees	  	show possible targets of selected function call
@@ -80,6 +84,8 @@ Print the callgraph of the trivial web-server in JSON format:
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
 
 func init() {
+	flag.Var((*buildutil.TagsFlag)(&build.Default.BuildTags), "tags", buildutil.TagsFlagDoc)
+
 	// If $GOMAXPROCS isn't set, use the full capacity of the machine.
 	// For small machines, use at least 4 threads.
 	if os.Getenv("GOMAXPROCS") == "" {
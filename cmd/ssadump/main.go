@@ -13,6 +13,7 @@ import (
 	"runtime"
 	"runtime/pprof"
 
+	"golang.org/x/tools/go/buildutil"
 	"golang.org/x/tools/go/loader"
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/interp"
@@ -56,11 +57,16 @@ When -run is specified, ssadump will run the program.
 The entry point depends on the -test flag:
 if clear, it runs the first package named main.
 if set, it runs the tests of each package.
+
+The -tags flag specifies a space-separated list of build tags to
+consider satisfied when loading packages, as for 'go build -tags'.
 `
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
 
 func init() {
+	flag.Var((*buildutil.TagsFlag)(&build.Default.BuildTags), "tags", buildutil.TagsFlagDoc)
+
 	// If $GOMAXPROCS isn't set, use the full capacity of the machine.
 	// For small machines, use at least 4 threads.
 	if os.Getenv("GOMAXPROCS") == "" {
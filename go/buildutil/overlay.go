@@ -0,0 +1,120 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildutil
+
+import (
+	"bytes"
+	"go/build"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// OverlayContext returns a copy of the build context ctxt whose file
+// system interface overlays the files in overlay, which maps file
+// names to contents, over the file system of ctxt. The overlay files
+// take precedence over the underlying files of the same name, and they
+// need not exist there: ReadDir lists them and their directories, and
+// IsDir reports those directories, so that go/build, the loader, and
+// AllPackages see packages and files that exist only in memory.
+//
+// A common use is to let an editor analyze its modified but unsaved
+// buffers. File names are compared after cleaning them with
+// filepath.Clean; they should be absolute, like the names that
+// go/build uses.
+//
+func OverlayContext(ctxt *build.Context, overlay map[string][]byte) *build.Context {
+	orig := *ctxt // copy
+
+	files := make(map[string][]byte)     // cleaned file name -> contents
+	dirs := make(map[string][]string)    // directory -> base names of its overlay files
+	subdirs := make(map[string][]string) // directory -> base names of its overlay directories
+	isDir := make(map[string]bool)       // directories containing overlay files, transitively
+	for name, content := range overlay {
+		name = filepath.Clean(name)
+		if _, dup := files[name]; dup {
+			files[name] = content
+			continue
+		}
+		files[name] = content
+		dir, base := filepath.Split(name)
+		dir = filepath.Clean(dir)
+		dirs[dir] = append(dirs[dir], base)
+		for !isDir[dir] {
+			isDir[dir] = true
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			subdirs[parent] = append(subdirs[parent], filepath.Base(dir))
+			dir = parent
+		}
+	}
+
+	res := orig
+	res.OpenFile = func(path string) (io.ReadCloser, error) {
+		if content, ok := files[filepath.Clean(path)]; ok {
+			return ioutil.NopCloser(bytes.NewReader(content)), nil
+		}
+		return OpenFile(&orig, path)
+	}
+	res.IsDir = func(path string) bool {
+		return isDir[filepath.Clean(path)] || IsDir(&orig, path)
+	}
+	res.ReadDir = func(path string) ([]os.FileInfo, error) {
+		bases := dirs[filepath.Clean(path)]
+		subs := subdirs[filepath.Clean(path)]
+		fis, err := ReadDir(&orig, path)
+		if bases == nil && subs == nil {
+			return fis, err
+		}
+		// The directory may exist in the overlay only.
+		replaced := make(map[string]bool)
+		for _, base := range bases {
+			replaced[base] = true
+		}
+		var list []os.FileInfo
+		for _, fi := range fis {
+			if !replaced[fi.Name()] {
+				list = append(list, fi)
+				replaced[fi.Name()] = true
+			}
+		}
+		for _, base := range bases {
+			list = append(list, overlayFileInfo{base, int64(len(files[filepath.Join(path, base)])), false})
+		}
+		for _, base := range subs {
+			if !replaced[base] {
+				list = append(list, overlayFileInfo{base, 0, true})
+			}
+		}
+		sort.Sort(byName(list))
+		return list, nil
+	}
+	return &res
+}
+
+// An overlayFileInfo describes a file or directory of an overlay.
+type overlayFileInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func (fi overlayFileInfo) Name() string    { return fi.name }
+func (overlayFileInfo) Sys() interface{}   { return nil }
+func (overlayFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi overlayFileInfo) IsDir() bool     { return fi.isDir }
+func (fi overlayFileInfo) Size() int64     { return fi.size }
+
+func (fi overlayFileInfo) Mode() os.FileMode {
+	if fi.isDir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
@@ -0,0 +1,114 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildutil_test
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+func TestOverlayContext(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "buildutil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+
+	// package p exists on disk; package q only in the overlay
+	pdir := filepath.Join(gopath, "src", "p")
+	if err := os.MkdirAll(pdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"a.go": "package p; const A = 1",
+		"c.go": "package p; const C = 3",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(pdir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	qdir := filepath.Join(gopath, "src", "q")
+
+	orig := build.Default // copy
+	orig.GOROOT = filepath.Join(gopath, "nonexistent")
+	orig.GOPATH = gopath
+	ctxt := buildutil.OverlayContext(&orig, map[string][]byte{
+		filepath.Join(pdir, "a.go"):            []byte("package p; const A = 2"),
+		filepath.Join(pdir, "b.go"):            []byte("package p; const B = 2"),
+		filepath.Join(qdir, ".", "q.go"):       []byte("package q"),
+		filepath.Join(gopath, "src", "r", "x"): []byte("not Go"),
+	})
+
+	read := func(name string) string {
+		rd, err := buildutil.OpenFile(ctxt, name)
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		defer rd.Close()
+		data, err := ioutil.ReadAll(rd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	for _, test := range []struct{ name, want string }{
+		{filepath.Join(pdir, "a.go"), "package p; const A = 2"}, // overlaid
+		{filepath.Join(pdir, "b.go"), "package p; const B = 2"}, // overlay only
+		{filepath.Join(pdir, "c.go"), "package p; const C = 3"}, // disk only
+		{filepath.Join(qdir, "q.go"), "package q"},
+	} {
+		if got := read(test.name); got != test.want {
+			t.Errorf("OpenFile(%s) = %q, want %q", test.name, got, test.want)
+		}
+	}
+	if got := read(filepath.Join(pdir, "d.go")); !strings.HasPrefix(got, "error: ") {
+		t.Errorf("OpenFile(d.go) = %q, want error", got)
+	}
+
+	// go/build sees the overlay files and directories
+	for _, test := range []struct {
+		path string
+		want []string
+	}{
+		{"p", []string{"a.go", "b.go", "c.go"}},
+		{"q", []string{"q.go"}},
+	} {
+		bp, err := ctxt.Import(test.path, "", 0)
+		if err != nil {
+			t.Errorf("Import(%s): %s", test.path, err)
+			continue
+		}
+		if got := strings.Join(bp.GoFiles, " "); got != strings.Join(test.want, " ") {
+			t.Errorf("Import(%s).GoFiles = %s, want %s", test.path, got, test.want)
+		}
+	}
+	fis, err := buildutil.ReadDir(ctxt, pdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fi := range fis {
+		if fi.Name() == "b.go" && fi.Size() != int64(len("package p; const B = 2")) {
+			t.Errorf("b.go has size %d", fi.Size())
+		}
+	}
+	// directories that exist only in the overlay are listed by their parent
+	if got, want := strings.Join(buildutil.AllPackages(ctxt), " "), "p q r"; got != want {
+		t.Errorf("AllPackages = %s, want %s", got, want)
+	}
+	if !buildutil.IsDir(ctxt, filepath.Join(gopath, "src", "r")) || buildutil.IsDir(ctxt, filepath.Join(gopath, "src", "s")) {
+		t.Errorf("IsDir reports wrong overlay directories")
+	}
+
+	// the original context is unchanged
+	if orig.OpenFile != nil || orig.ReadDir != nil || orig.IsDir != nil {
+		t.Errorf("original context was modified")
+	}
+}
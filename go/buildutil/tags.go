@@ -0,0 +1,88 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildutil
+
+// This logic was copied from stringsFlag from $GOROOT/src/cmd/go/build.go.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagsFlagDoc is the usage message of a -tags flag implemented by TagsFlag.
+const TagsFlagDoc = "a list of `build tags` to consider satisfied during the build. " +
+	"For more information about build tags, see the description of " +
+	"build constraints in the documentation for the go/build package"
+
+// TagsFlag is an implementation of the flag.Value and flag.Getter
+// interfaces that parses a flag value in the same manner as the -tags
+// flag of go build, a space-separated list of build tags (which may be
+// quoted), and populates a []string slice. A tool that locates packages
+// with build.Default declares the flag like this:
+//
+//	flag.Var((*buildutil.TagsFlag)(&build.Default.BuildTags), "tags", buildutil.TagsFlagDoc)
+//
+// See $GOROOT/src/go/build/doc.go for a description of build tags.
+//
+type TagsFlag []string
+
+func (v *TagsFlag) Set(s string) error {
+	tags, err := splitQuotedFields(s)
+	if err != nil {
+		return err
+	}
+	*v = tags
+	return nil
+}
+
+func (v *TagsFlag) Get() interface{} { return []string(*v) }
+
+func (v *TagsFlag) String() string {
+	if v == nil {
+		return "" // (the flag package calls String on a zero value)
+	}
+	return strings.Join(*v, " ")
+}
+
+// splitQuotedFields splits s into fields separated by white space;
+// a field may be enclosed in single or double quotes, in which case
+// it extends to the closing quote. Quotes within a field do not count.
+func splitQuotedFields(s string) ([]string, error) {
+	var f []string
+	for len(s) > 0 {
+		for len(s) > 0 && isSpaceByte(s[0]) {
+			s = s[1:]
+		}
+		if len(s) == 0 {
+			break
+		}
+		// Accepted quoted string. No unescaping inside.
+		if s[0] == '"' || s[0] == '\'' {
+			quote := s[0]
+			s = s[1:]
+			i := 0
+			for i < len(s) && s[i] != quote {
+				i++
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("unterminated %c string", quote)
+			}
+			f = append(f, s[:i])
+			s = s[i+1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && !isSpaceByte(s[i]) {
+			i++
+		}
+		f = append(f, s[:i])
+		s = s[i:]
+	}
+	return f, nil
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,44 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildutil_test
+
+import (
+	"flag"
+	"go/build"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+func TestTags(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		want []string
+		err  bool
+	}{
+		{nil, nil, false},
+		{[]string{"-tags", ""}, nil, false},
+		{[]string{"-tags", "foo"}, []string{"foo"}, false},
+		{[]string{"-tags", " foo  bar\tbaz "}, []string{"foo", "bar", "baz"}, false},
+		{[]string{"-tags", `'foo bar' "baz"`}, []string{"foo bar", "baz"}, false},
+		{[]string{"-tags", "a", "-tags", "b c"}, []string{"b", "c"}, false}, // last flag wins
+		{[]string{"-tags", "'foo"}, nil, true},
+	} {
+		f := flag.NewFlagSet("test", flag.ContinueOnError)
+		f.SetOutput(ioutil.Discard)
+		var ctxt build.Context
+		f.Var((*buildutil.TagsFlag)(&ctxt.BuildTags), "tags", buildutil.TagsFlagDoc)
+		err := f.Parse(test.args)
+		if (err != nil) != test.err {
+			t.Errorf("%q: got error %v, want error %t", test.args, err, test.err)
+			continue
+		}
+		if !test.err && !reflect.DeepEqual(ctxt.BuildTags, test.want) {
+			t.Errorf("%q: got tags %q, want %q", test.args, ctxt.BuildTags, test.want)
+		}
+	}
+}
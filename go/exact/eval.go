@@ -0,0 +1,210 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exact
+
+// This file implements Eval, which folds constant expressions
+// without type-checking them.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// maxShift is the largest shift count accepted by Eval; it matches
+// the limit imposed by the type checker.
+const maxShift = 1023 - 1 + 52
+
+// Eval folds the constant expression x and returns its value and kind.
+// x may consist of basic literals, parentheses, unary and binary
+// operators (including shifts and comparisons), the identifiers true
+// and false, and the names of the constants in consts.
+//
+// The constants are untyped, and the expression is evaluated as an
+// untyped constant expression per the Go spec. Since values have the
+// smallest kind in which they are represented exactly, the kind of the
+// result may be larger than the kind of its value: for instance, the
+// expression 3.0 / 1.5 has kind Float and the Int value 2. Rune
+// constants have kind Int, and the kind of a named constant is the
+// kind of its value.
+//
+// An error is returned if x contains other expressions or undeclared
+// names, or if an operation is invalid, such as a division by zero.
+func Eval(x ast.Expr, consts map[string]Value) (Value, Kind, error) {
+	e := evaluator{consts}
+	c, err := e.eval(x)
+	if err != nil {
+		return nil, Unknown, err
+	}
+	return c.val, c.kind, nil
+}
+
+// A result is a constant value together with the kind of the
+// untyped constant it represents.
+type result struct {
+	val  Value
+	kind Kind
+}
+
+type evaluator struct {
+	consts map[string]Value
+}
+
+func (e evaluator) eval(x ast.Expr) (result, error) {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		val := MakeFromLiteral(x.Value, x.Kind)
+		if val.Kind() == Unknown {
+			return result{}, fmt.Errorf("malformed constant: %s", x.Value)
+		}
+		switch x.Kind {
+		case token.INT, token.CHAR:
+			return result{val, Int}, nil
+		case token.FLOAT:
+			return result{val, Float}, nil
+		case token.IMAG:
+			return result{val, Complex}, nil
+		}
+		return result{val, String}, nil
+
+	case *ast.Ident:
+		if val, ok := e.consts[x.Name]; ok {
+			if val.Kind() == Unknown {
+				return result{}, fmt.Errorf("%s has unknown value", x.Name)
+			}
+			return result{val, val.Kind()}, nil
+		}
+		switch x.Name {
+		case "true", "false":
+			return result{MakeBool(x.Name == "true"), Bool}, nil
+		}
+		return result{}, fmt.Errorf("undeclared name: %s", x.Name)
+
+	case *ast.ParenExpr:
+		return e.eval(x.X)
+
+	case *ast.UnaryExpr:
+		y, err := e.eval(x.X)
+		if err != nil {
+			return result{}, err
+		}
+		ok := false
+		switch x.Op {
+		case token.ADD, token.SUB:
+			ok = isNumeric(y.kind)
+		case token.XOR:
+			ok = y.kind == Int
+		case token.NOT:
+			ok = y.kind == Bool
+		}
+		if !ok {
+			return result{}, fmt.Errorf("invalid operation: operator %s not defined for %s", x.Op, y.val)
+		}
+		return result{UnaryOp(x.Op, y.val, -1), y.kind}, nil
+
+	case *ast.BinaryExpr:
+		lhs, err := e.eval(x.X)
+		if err != nil {
+			return result{}, err
+		}
+		rhs, err := e.eval(x.Y)
+		if err != nil {
+			return result{}, err
+		}
+		switch x.Op {
+		case token.SHL, token.SHR:
+			return shift(lhs, x.Op, rhs)
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return compare(lhs, x.Op, rhs)
+		}
+		return binary(lhs, x.Op, rhs)
+	}
+
+	return result{}, fmt.Errorf("%T is not a constant expression", x)
+}
+
+func isNumeric(k Kind) bool {
+	return k == Int || k == Float || k == Complex
+}
+
+// matchKinds returns the kind of a binary operation on operands of
+// kinds x and y, or Unknown if the operands don't match.
+func matchKinds(x, y Kind) Kind {
+	if isNumeric(x) && isNumeric(y) {
+		if x < y {
+			return y
+		}
+		return x
+	}
+	if x == y {
+		return x
+	}
+	return Unknown
+}
+
+func binary(x result, op token.Token, y result) (result, error) {
+	kind := matchKinds(x.kind, y.kind)
+	if kind == Unknown {
+		return result{}, fmt.Errorf("invalid operation: mismatched constants %s and %s", x.val, y.val)
+	}
+
+	ok := false
+	switch op {
+	case token.ADD:
+		ok = isNumeric(kind) || kind == String
+	case token.SUB, token.MUL, token.QUO:
+		ok = isNumeric(kind)
+	case token.REM, token.AND, token.OR, token.XOR, token.AND_NOT:
+		ok = kind == Int
+	case token.LAND, token.LOR:
+		ok = kind == Bool
+	}
+	if !ok {
+		return result{}, fmt.Errorf("invalid operation: operator %s not defined for %s", op, x.val)
+	}
+
+	if (op == token.QUO || op == token.REM) && Sign(y.val) == 0 {
+		return result{}, fmt.Errorf("invalid operation: division by zero")
+	}
+	if op == token.QUO && kind == Int {
+		op = token.QUO_ASSIGN // integer division
+	}
+	return result{BinaryOp(x.val, op, y.val), kind}, nil
+}
+
+func shift(x result, op token.Token, y result) (result, error) {
+	// spec: "If the left operand of a constant shift expression is
+	// an untyped constant, the result is an integer constant."
+	xval, exact := Value(nil), false
+	if isNumeric(x.kind) {
+		xval, exact = ToInt(x.val)
+	}
+	if !exact {
+		return result{}, fmt.Errorf("invalid operation: shifted operand %s must be integer", x.val)
+	}
+
+	if isNumeric(y.kind) {
+		if sval, exact := ToInt(y.val); exact {
+			if s, exact := Uint64Val(sval); exact && s <= maxShift {
+				return result{Shift(xval, op, uint(s)), Int}, nil
+			}
+		}
+	}
+	return result{}, fmt.Errorf("invalid shift count %s", y.val)
+}
+
+func compare(x result, op token.Token, y result) (result, error) {
+	kind := matchKinds(x.kind, y.kind)
+	if kind == Unknown {
+		return result{}, fmt.Errorf("invalid operation: mismatched constants %s and %s", x.val, y.val)
+	}
+	switch op {
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		if kind == Bool || kind == Complex {
+			return result{}, fmt.Errorf("invalid operation: operator %s not defined for %s", op, x.val)
+		}
+	}
+	return result{MakeBool(Compare(x.val, op, y.val)), Bool}, nil
+}
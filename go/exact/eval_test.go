@@ -0,0 +1,81 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exact
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	consts := map[string]Value{
+		"K":   MakeInt64(1024),
+		"Pi":  MakeFromLiteral("3.14159265358979323846264338327950288419716939937510582097494459", token.FLOAT),
+		"S":   MakeString("foo"),
+		"Big": MakeFromLiteral("1e100", token.FLOAT),
+	}
+
+	for _, test := range []struct {
+		src, want string
+		kind      Kind
+	}{
+		{`1 + 2*3`, "7", Int},
+		{`7 / 2`, "3", Int},
+		{`7 / 2.0`, "7/2", Float},
+		{`3.0 / 1.5`, "2", Float},
+		{`-7 % 3`, "-1", Int},
+		{`'a' + 1`, "98", Int},
+		{`1 << 100 >> 98`, "4", Int},
+		{`1.0 << 3`, "8", Int},
+		{`^0`, "-1", Int},
+		{`K &^ 0xff | 3`, "1027", Int},
+		{`Big / 1e99`, "10", Float},
+		{`Pi * 2 > 6.28`, "true", Bool},
+		{`(1 + 2i) * (1 - 2i)`, "5", Complex},
+		{`S + "bar"`, `"foobar"`, String},
+		{`S < "goo" && !false`, "true", Bool},
+		{`1/3.0*3 == 1`, "true", Bool},
+	} {
+		x, err := parser.ParseExpr(test.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, kind, err := Eval(x, consts)
+		if err != nil {
+			t.Errorf("%s: %s", test.src, err)
+			continue
+		}
+		if val.String() != test.want || kind != test.kind {
+			t.Errorf("%s: got %s (kind %d); want %s (kind %d)", test.src, val, kind, test.want, test.kind)
+		}
+	}
+
+	for _, test := range []struct {
+		src, err string
+	}{
+		{`1 / 0`, "division by zero"},
+		{`1.5 % 1`, "operator % not defined"},
+		{`S - "x"`, "operator - not defined"},
+		{`S + 1`, "mismatched constants"},
+		{`1.5 << 1`, "must be integer"},
+		{`1 << -1`, "invalid shift count"},
+		{`1 << 10000`, "invalid shift count"},
+		{`1i < 2i`, "operator < not defined"},
+		{`true < false`, "operator < not defined"},
+		{`!1`, "operator ! not defined"},
+		{`x + 1`, "undeclared name: x"},
+		{`len(S)`, "not a constant expression"},
+	} {
+		x, err := parser.ParseExpr(test.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := Eval(x, consts); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v; want error containing %q", test.src, err, test.err)
+		}
+	}
+}
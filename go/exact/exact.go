@@ -18,6 +18,8 @@ import (
 	"go/token"
 	"math/big"
 	"strconv"
+	"strings"
+	"sync"
 )
 
 // Kind specifies the kind of value represented by a Value.
@@ -40,7 +42,25 @@ const (
 	Complex
 )
 
+// MinPrecision is the minimum mantissa precision in bits that the
+// Go spec requires for the representation of floating-point constants.
+//
+// Float and Complex values exceed it: they are represented as fractions
+// of arbitrary-precision integers, and the operations on them are exact.
+// Thus, folding constant expressions with this package is at least as
+// precise as with the gc compiler, which uses 512-bit mantissas. Values
+// are only rounded when converted to Go float32 or float64 values, to
+// the nearest representable value with ties to even (see Float32Val and
+// Float64Val).
+const MinPrecision = 256
+
 // A Value represents a mathematically exact value of a given Kind.
+//
+// Values must be compared with Compare, not with ==, and they must not
+// be used as map keys: equal values may have different representations.
+// In particular, a String value that is the result of a concatenation of
+// 256 or more bytes is represented lazily, to keep long chains of
+// concatenations linear; use StringVal to obtain a comparable key.
 type Value interface {
 	// Kind returns the value kind; it is always the smallest
 	// kind in which the value can be represented exactly.
@@ -66,9 +86,23 @@ type (
 	complexVal struct{ re, im *big.Rat }
 )
 
+// A concatVal is a String value that is the concatenation l + r of
+// two long String values; it is flattened when its string is needed.
+// Thus, folding long chains of string additions such as a + b + c + ...
+// takes time linear in the length of the result. Shorter concatenations
+// are flattened immediately (see concat).
+type concatVal struct {
+	l, r Value // stringVal or *concatVal; immutable
+	len  int   // length of the string
+
+	once sync.Once
+	s    string // flattened string; set by once
+}
+
 func (unknownVal) Kind() Kind { return Unknown }
 func (boolVal) Kind() Kind    { return Bool }
 func (stringVal) Kind() Kind  { return String }
+func (*concatVal) Kind() Kind { return String }
 func (int64Val) Kind() Kind   { return Int }
 func (intVal) Kind() Kind     { return Int }
 func (floatVal) Kind() Kind   { return Float }
@@ -77,6 +111,7 @@ func (complexVal) Kind() Kind { return Complex }
 func (unknownVal) String() string   { return "unknown" }
 func (x boolVal) String() string    { return fmt.Sprintf("%v", bool(x)) }
 func (x stringVal) String() string  { return strconv.Quote(string(x)) }
+func (x *concatVal) String() string { return strconv.Quote(x.string()) }
 func (x int64Val) String() string   { return strconv.FormatInt(int64(x), 10) }
 func (x intVal) String() string     { return x.val.String() }
 func (x floatVal) String() string   { return x.val.String() }
@@ -85,11 +120,74 @@ func (x complexVal) String() string { return fmt.Sprintf("(%s + %si)", x.re, x.i
 func (unknownVal) implementsValue() {}
 func (boolVal) implementsValue()    {}
 func (stringVal) implementsValue()  {}
+func (*concatVal) implementsValue() {}
 func (int64Val) implementsValue()   {}
 func (intVal) implementsValue()     {}
 func (floatVal) implementsValue()   {}
 func (complexVal) implementsValue() {}
 
+// concatMin is the minimum length of a concatenation that is
+// represented by a concatVal.
+const concatMin = 256
+
+// concat returns the String value x + y.
+func concat(x, y Value) Value {
+	if n := strLen(x) + strLen(y); n >= concatMin {
+		return &concatVal{l: x, r: y, len: n}
+	}
+	return stringVal(strVal(x) + strVal(y))
+}
+
+// strLen returns the length of the String value x.
+func strLen(x Value) int {
+	if x, ok := x.(*concatVal); ok {
+		return x.len
+	}
+	return len(x.(stringVal))
+}
+
+// strVal returns the string of the String value x.
+func strVal(x Value) string {
+	if x, ok := x.(*concatVal); ok {
+		return x.string()
+	}
+	return string(x.(stringVal))
+}
+
+// string returns the string of x. It is computed once, when
+// it is first needed.
+func (x *concatVal) string() string {
+	x.once.Do(func() {
+		x.s = strings.Join(reverse(appendReverse(nil, x)), "")
+	})
+	return x.s
+}
+
+// appendReverse appends the pieces of the String value x to list, in
+// reverse order, and returns the result. Since a chain a + b + c is
+// represented as (a + b) + c, left operands are processed in a loop and
+// right operands recursively; this avoids deep recursion for long chains.
+// Only the immutable operands of concatenations are accessed, so that x
+// may be shared by concurrent clients.
+func appendReverse(list []string, x Value) []string {
+	for {
+		y, ok := x.(*concatVal)
+		if !ok {
+			return append(list, string(x.(stringVal)))
+		}
+		list = appendReverse(list, y.r)
+		x = y.l
+	}
+}
+
+// reverse reverses list in place and returns it.
+func reverse(list []string) []string {
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list
+}
+
 // int64 bounds
 var (
 	minInt64 = big.NewInt(-1 << 63)
@@ -144,6 +242,12 @@ func MakeFloat64(x float64) Value {
 	return unknownVal{}
 }
 
+// MakeBigInt returns the Int value for x; x is not retained.
+func MakeBigInt(x *big.Int) Value { return normInt(new(big.Int).Set(x)) }
+
+// MakeBigRat returns the numeric value for x; x is not retained.
+func MakeBigRat(x *big.Rat) Value { return normFloat(new(big.Rat).Set(x)) }
+
 // MakeFromLiteral returns the corresponding integer, floating-point,
 // imaginary, character, or string value for a Go literal string. The
 // result is nil if the literal string is invalid.
@@ -190,6 +294,11 @@ func MakeFromLiteral(lit string, tok token.Token) Value {
 //
 // For unknown arguments the result is the zero value for the respective
 // accessor type, except for Sign, where the result is 1.
+//
+// The accessors for numeric Go types also report whether the result is
+// exact, that is, whether x is representable as a value of the respective
+// type; clients must not use the result otherwise. The BoolVal, StringVal,
+// BigIntVal, and BigRatVal results are always exact.
 
 // BoolVal returns the Go boolean value of x, which must be a Bool or an Unknown.
 // If x is Unknown, the result is false.
@@ -207,8 +316,8 @@ func BoolVal(x Value) bool {
 // If x is Unknown, the result is "".
 func StringVal(x Value) string {
 	switch x := x.(type) {
-	case stringVal:
-		return string(x)
+	case stringVal, *concatVal:
+		return strVal(x)
 	case unknownVal:
 		return ""
 	}
@@ -281,6 +390,38 @@ func Float64Val(x Value) (float64, bool) {
 	panic(fmt.Sprintf("%v not a Float", x))
 }
 
+// BigIntVal returns a new big.Int with the value of x; x must be an Int
+// or an Unknown. The caller may modify the result.
+// If x is Unknown, the result is nil.
+func BigIntVal(x Value) *big.Int {
+	switch x := x.(type) {
+	case int64Val:
+		return big.NewInt(int64(x))
+	case intVal:
+		return new(big.Int).Set(x.val)
+	case unknownVal:
+		return nil
+	}
+	panic(fmt.Sprintf("%v not an Int", x))
+}
+
+// BigRatVal returns a new big.Rat with the value of x; x must be numeric
+// but not Complex, or Unknown. The caller may modify the result.
+// If x is Unknown, the result is nil.
+func BigRatVal(x Value) *big.Rat {
+	switch x := x.(type) {
+	case int64Val:
+		return big.NewRat(int64(x), 1)
+	case intVal:
+		return new(big.Rat).SetInt(x.val)
+	case floatVal:
+		return new(big.Rat).Set(x.val)
+	case unknownVal:
+		return nil
+	}
+	panic(fmt.Sprintf("%v not a Float", x))
+}
+
 // BitLen returns the number of bits required to represent
 // the absolute value x in binary representation; x must be an Int or an Unknown.
 // If x is Unknown, the result is 0.
@@ -472,6 +613,61 @@ func Imag(x Value) Value {
 	panic(fmt.Sprintf("%v not numeric", x))
 }
 
+// ----------------------------------------------------------------------------
+// Conversions
+//
+// Since a Value always has the smallest kind in which it can be represented
+// exactly, the result of a conversion may have a smaller kind than the one
+// converted to; for instance, ToFloat(MakeInt64(1)) is an Int value.
+
+// ToInt returns x converted to an Int value, and reports whether the
+// conversion is exact. A Float value is truncated towards zero; the
+// conversion is exact if x is integral. x must be numeric or Unknown.
+// If x is Unknown or a Complex value with a non-zero imaginary part,
+// the result is Unknown and the conversion is not exact.
+func ToInt(x Value) (Value, bool) {
+	switch x := x.(type) {
+	case unknownVal, complexVal:
+		return unknownVal{}, false
+	case int64Val, intVal:
+		return x, true
+	case floatVal:
+		// x.val is never integral (see normFloat)
+		return normInt(new(big.Int).Quo(x.val.Num(), x.val.Denom())), false
+	}
+	panic(fmt.Sprintf("%v not numeric", x))
+}
+
+// ToFloat returns x converted to a Float value, and reports whether the
+// conversion is exact. x must be numeric or Unknown. Int and Float values
+// are converted exactly. If x is Unknown or a Complex value with a non-zero
+// imaginary part, the result is Unknown and the conversion is not exact.
+// Whether the result is representable as a float32 or float64 value is
+// reported by Float32Val and Float64Val.
+func ToFloat(x Value) (Value, bool) {
+	switch x := x.(type) {
+	case unknownVal, complexVal:
+		return unknownVal{}, false
+	case int64Val, intVal, floatVal:
+		return x, true
+	}
+	panic(fmt.Sprintf("%v not numeric", x))
+}
+
+// ToComplex returns x converted to a Complex value, and reports whether
+// the conversion is exact. x must be numeric or Unknown. Numeric values
+// are converted exactly. If x is Unknown, the result is Unknown and the
+// conversion is not exact.
+func ToComplex(x Value) (Value, bool) {
+	switch x := x.(type) {
+	case unknownVal:
+		return x, false
+	case int64Val, intVal, floatVal, complexVal:
+		return x, true
+	}
+	panic(fmt.Sprintf("%v not numeric", x))
+}
+
 // ----------------------------------------------------------------------------
 // Operations
 
@@ -560,7 +756,7 @@ func ord(x Value) int {
 	switch x.(type) {
 	default:
 		return 0
-	case boolVal, stringVal:
+	case boolVal, stringVal, *concatVal:
 		return 1
 	case int64Val:
 		return 2
@@ -589,7 +785,7 @@ func match(x, y Value) (_, _ Value) {
 	case unknownVal:
 		return x, x
 
-	case boolVal, stringVal, complexVal:
+	case boolVal, stringVal, *concatVal, complexVal:
 		return x, y
 
 	case int64Val:
@@ -778,9 +974,9 @@ func BinaryOp(x Value, op token.Token, y Value) Value {
 		}
 		return normComplex(&re, &im)
 
-	case stringVal:
+	case stringVal, *concatVal:
 		if op == token.ADD {
-			return x + y.(stringVal)
+			return concat(x, y)
 		}
 	}
 
@@ -898,21 +1094,21 @@ func Compare(x Value, op token.Token, y Value) bool {
 			return re != 0 || im != 0
 		}
 
-	case stringVal:
-		y := y.(stringVal)
+	case stringVal, *concatVal:
+		xs, ys := strVal(x), strVal(y)
 		switch op {
 		case token.EQL:
-			return x == y
+			return xs == ys
 		case token.NEQ:
-			return x != y
+			return xs != ys
 		case token.LSS:
-			return x < y
+			return xs < ys
 		case token.LEQ:
-			return x <= y
+			return xs <= ys
 		case token.GTR:
-			return x > y
+			return xs > ys
 		case token.GEQ:
-			return x >= y
+			return xs >= ys
 		}
 	}
 
@@ -6,6 +6,8 @@ package exact
 
 import (
 	"go/token"
+	"math/big"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -320,6 +322,239 @@ func TestFractions(t *testing.T) {
 	}
 }
 
+// Each test lists a value and the results of ToInt, ToFloat, and
+// ToComplex; a trailing ! marks an inexact conversion.
+var convTests = []string{
+	"0 0 0 0",
+	"-42 -42 -42 -42",
+	"1e100 1e100 1e100 1e100",
+	"1.5 1! 1.5 1.5",
+	"-1.5 -1! -1.5 -1.5",
+	"1e-100 0! 1e-100 1e-100",
+	"1234567890123456789012345678901.5 1234567890123456789012345678901! 1234567890123456789012345678901.5 1234567890123456789012345678901.5",
+	"2i ?! ?! 2i",
+	"? ?! ?! ?!",
+}
+
+func TestConversions(t *testing.T) {
+	for _, test := range convTests {
+		a := strings.Split(test, " ")
+		if len(a) != 4 {
+			t.Errorf("invalid test case: %s", test)
+			continue
+		}
+
+		x := val(a[0])
+		for i, conv := range []struct {
+			name string
+			f    func(Value) (Value, bool)
+		}{
+			{"ToInt", ToInt},
+			{"ToFloat", ToFloat},
+			{"ToComplex", ToComplex},
+		} {
+			want := a[i+1]
+			wantExact := !strings.HasSuffix(want, "!")
+			want = strings.TrimSuffix(want, "!")
+			got, exact := conv.f(x)
+			if !eql(got, val(want)) || exact != wantExact {
+				t.Errorf("%s(%s): got %s, %v; want %s, %v", conv.name, a[0], got, exact, want, wantExact)
+			}
+		}
+		if got, _ := ToInt(x); got.Kind() != Int && got.Kind() != Unknown {
+			t.Errorf("ToInt(%s): got kind %d; want Int", a[0], got.Kind())
+		}
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	for _, test := range []struct {
+		x             string
+		i64, u64, f64 bool // exactness of Int64Val, Uint64Val, Float64Val
+	}{
+		{"0", true, true, true},
+		{"-1", true, false, true},
+		{"9223372036854775807", true, true, false}, // 1<<63 - 1
+		{"9223372036854775808", false, true, true}, // 1<<63
+		{"18446744073709551615", false, true, false},
+		{"18446744073709551616", false, false, true},
+		{"-9223372036854775808", true, false, true},
+		{"-9223372036854775809", false, false, false},
+		{"1e400", false, false, false},
+	} {
+		x := val(test.x)
+		if _, exact := Int64Val(x); exact != test.i64 {
+			t.Errorf("Int64Val(%s): got exact = %v; want %v", test.x, exact, test.i64)
+		}
+		if _, exact := Uint64Val(x); exact != test.u64 {
+			t.Errorf("Uint64Val(%s): got exact = %v; want %v", test.x, exact, test.u64)
+		}
+		if _, exact := Float64Val(x); exact != test.f64 {
+			t.Errorf("Float64Val(%s): got exact = %v; want %v", test.x, exact, test.f64)
+		}
+	}
+
+	// floating-point precision and range
+	for _, test := range []struct {
+		x        string
+		f32, f64 bool // exactness of Float32Val, Float64Val
+	}{
+		{"0.5", true, true},
+		{"0.1", false, false},
+		{"1.0000001", false, false},
+		{"16777217", false, true}, // 1<<24 + 1
+		{"1e39", false, false},
+	} {
+		x := val(test.x)
+		if _, exact := Float32Val(x); exact != test.f32 {
+			t.Errorf("Float32Val(%s): got exact = %v; want %v", test.x, exact, test.f32)
+		}
+		if _, exact := Float64Val(x); exact != test.f64 {
+			t.Errorf("Float64Val(%s): got exact = %v; want %v", test.x, exact, test.f64)
+		}
+	}
+}
+
+var bigTests = []string{
+	"0",
+	"-42",
+	"123456789012345678901234567890",
+	"-1.25",
+	"1e-100",
+	"12345678901234567890.5",
+}
+
+func TestBigVals(t *testing.T) {
+	for _, lit := range bigTests {
+		x := val(lit)
+
+		r := BigRatVal(x)
+		if y := MakeBigRat(r); !Compare(y, token.EQL, x) {
+			t.Errorf("MakeBigRat(BigRatVal(%s)) = %s", lit, y)
+		}
+		// the result is a copy
+		r.Add(r, big.NewRat(1, 1))
+		if y := MakeBigRat(BigRatVal(x)); !Compare(y, token.EQL, x) {
+			t.Errorf("BigRatVal(%s) shares its result", lit)
+		}
+
+		if x.Kind() != Int {
+			continue
+		}
+		i := BigIntVal(x)
+		if y := MakeBigInt(i); !Compare(y, token.EQL, x) {
+			t.Errorf("MakeBigInt(BigIntVal(%s)) = %s", lit, y)
+		}
+		i.Neg(i)
+		if y := MakeBigInt(BigIntVal(x)); !Compare(y, token.EQL, x) {
+			t.Errorf("BigIntVal(%s) shares its result", lit)
+		}
+	}
+
+	if BigIntVal(MakeUnknown()) != nil || BigRatVal(MakeUnknown()) != nil {
+		t.Errorf("got non-nil big values for Unknown")
+	}
+}
+
+func TestPrecision(t *testing.T) {
+	one := MakeInt64(1)
+	tiny := BinaryOp(one, token.QUO, Shift(one, token.SHL, 2*MinPrecision)) // 2**-512
+
+	// 1 + tiny - 1 == tiny: no precision is lost
+	if x := BinaryOp(BinaryOp(one, token.ADD, tiny), token.SUB, one); !Compare(x, token.EQL, tiny) {
+		t.Errorf("got 1 + 2**-512 - 1 = %s; want %s", x, tiny)
+	}
+
+	// 1/3 * 3 == 1
+	third := BinaryOp(one, token.QUO, MakeInt64(3))
+	if x := BinaryOp(third, token.MUL, MakeInt64(3)); !Compare(x, token.EQL, one) {
+		t.Errorf("got 1/3 * 3 = %s; want 1", x)
+	}
+}
+
+func TestRounding(t *testing.T) {
+	one := MakeInt64(1)
+	pow2 := func(n int) Value { // 2**-n
+		return BinaryOp(one, token.QUO, Shift(one, token.SHL, uint(n)))
+	}
+	add := func(x, y Value) Value { return BinaryOp(x, token.ADD, y) }
+	mul := func(x Value, n int64) Value { return BinaryOp(x, token.MUL, MakeInt64(n)) }
+
+	for _, test := range []struct {
+		x    Value
+		want float64
+	}{
+		{add(one, pow2(53)), 1},                               // tie: round to even
+		{add(one, mul(pow2(53), 3)), 1 + 1.0/(1<<51)},         // tie: round to even
+		{add(one, add(pow2(53), pow2(200))), 1 + 1.0/(1<<52)}, // above tie: round up
+		{add(one, mul(pow2(54), 3)), 1 + 1.0/(1<<52)},         // nearest
+	} {
+		if got, _ := Float64Val(test.x); got != test.want {
+			t.Errorf("Float64Val(%s) = %g; want %g", test.x, got, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		x    Value
+		want float32
+	}{
+		{add(one, pow2(24)), 1},                       // tie: round to even
+		{add(one, mul(pow2(24), 3)), 1 + 1.0/(1<<22)}, // tie: round to even
+	} {
+		if got, _ := Float32Val(test.x); got != test.want {
+			t.Errorf("Float32Val(%s) = %g; want %g", test.x, got, test.want)
+		}
+	}
+}
+
+func TestStringConcat(t *testing.T) {
+	// A long chain of additions takes linear time
+	// (this test would take minutes otherwise).
+	const n = 100000
+	piece := MakeString(strings.Repeat("x", 100))
+	x := MakeString("")
+	for i := 0; i < n; i++ {
+		x = BinaryOp(x, token.ADD, piece)
+	}
+	if got := len(StringVal(x)); got != n*100 {
+		t.Errorf("got length %d; want %d", got, n*100)
+	}
+
+	// Shared operands, and operands that were flattened before,
+	// used concurrently.
+	a := MakeString(strings.Repeat("a", concatMin))
+	b := BinaryOp(a, token.ADD, a)
+	_ = StringVal(b)
+	c := BinaryOp(BinaryOp(b, token.ADD, a), token.ADD, BinaryOp(MakeString("c"), token.ADD, b))
+	want := strings.Repeat("a", 3*concatMin) + "c" + strings.Repeat("a", 2*concatMin)
+	done := make(chan bool)
+	for i := 0; i < 4; i++ {
+		go func() {
+			if got := StringVal(c); got != want {
+				t.Errorf("got %q; want %q", got, want)
+			}
+			done <- true
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	if got := c.String(); got != strconv.Quote(want) {
+		t.Errorf("got %s; want %q", got, want)
+	}
+	if !Compare(c, token.EQL, MakeString(want)) || !Compare(b, token.LSS, c) {
+		t.Errorf("comparisons of %s failed", c)
+	}
+
+	// Short strings are values that can be compared with ==.
+	if x, y := BinaryOp(MakeString("f"), token.ADD, MakeString("oo")), MakeString("foo"); x != y {
+		t.Errorf("%s != %s", x, y)
+	}
+	if x, y := MakeFromLiteral(`"foo"`, token.STRING), MakeString("foo"); x != y {
+		t.Errorf("%s != %s", x, y)
+	}
+}
+
 var bytesTests = []string{
 	"0",
 	"1",
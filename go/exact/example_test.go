@@ -0,0 +1,44 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exact_test
+
+import (
+	"fmt"
+	"go/token"
+
+	"golang.org/x/tools/go/exact"
+)
+
+// This example folds the constant expression (1<<100 + 1) / 3 * 3 == 1<<100 + 1
+// the way a Go compiler does: with exact, unbounded arithmetic on untyped constants.
+func Example() {
+	one := exact.MakeInt64(1)
+	x := exact.BinaryOp(exact.Shift(one, token.SHL, 100), token.ADD, one)
+	fmt.Println(x)
+
+	// Integer division (token.QUO_ASSIGN) truncates;
+	// token.QUO produces an exact fraction.
+	q := exact.BinaryOp(x, token.QUO_ASSIGN, exact.MakeInt64(3))
+	r := exact.BinaryOp(x, token.QUO, exact.MakeInt64(3))
+	fmt.Println(q, r.Kind() == exact.Float)
+
+	fmt.Println(exact.Compare(exact.BinaryOp(q, token.MUL, exact.MakeInt64(3)), token.EQL, x))
+	fmt.Println(exact.Compare(exact.BinaryOp(r, token.MUL, exact.MakeInt64(3)), token.EQL, x))
+
+	// Constants may be converted to Go values if they are representable.
+	if _, ok := exact.Int64Val(x); !ok {
+		fmt.Println("x overflows int64")
+	}
+	u, _ := exact.Uint64Val(exact.UnaryOp(token.XOR, exact.MakeInt64(0), 64))
+	fmt.Println(u)
+
+	// Output:
+	// 1267650600228229401496703205377
+	// 422550200076076467165567735125 true
+	// false
+	// true
+	// x overflows int64
+	// 18446744073709551615
+}
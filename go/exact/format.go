@@ -0,0 +1,105 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exact
+
+// This file implements Format and Parse, which convert
+// Values to and from Go constant expressions.
+
+import (
+	"fmt"
+	"go/parser"
+	"math/big"
+	"strconv"
+)
+
+// Format returns a Go constant expression denoting x exactly; x must
+// not be Unknown. Integers and fractions whose denominator has no
+// prime factors other than 2 and 5 are formatted as literals. Other
+// fractions are formatted as parenthesized divisions such as (1/3.0),
+// and complex values as sums of a real part and an imaginary literal,
+// possibly scaled, such as (1 - 2i) or (1i * (1/3.0)). Parse returns
+// the value of the result.
+func Format(x Value) string {
+	switch x := x.(type) {
+	case boolVal:
+		return x.String()
+	case stringVal, *concatVal:
+		return strconv.Quote(strVal(x))
+	case int64Val, intVal:
+		return x.String()
+	case floatVal:
+		return formatRat(x.val)
+	case complexVal:
+		if x.re.Sign() == 0 {
+			return formatImag(x.im)
+		}
+		op, im := "+", x.im
+		if im.Sign() < 0 {
+			op, im = "-", new(big.Rat).Neg(im)
+		}
+		return fmt.Sprintf("(%s %s %s)", formatRat(x.re), op, formatImag(im))
+	}
+	panic(fmt.Sprintf("cannot format %v", x))
+}
+
+// formatRat formats x as a literal if it has an exact decimal
+// representation, and as a parenthesized division otherwise.
+func formatRat(x *big.Rat) string {
+	if x.IsInt() {
+		return x.Num().String()
+	}
+	if prec, ok := decimalPrec(x.Denom()); ok {
+		return x.FloatString(prec)
+	}
+	return fmt.Sprintf("(%s/%s.0)", x.Num(), x.Denom())
+}
+
+// formatImag formats x*i.
+func formatImag(x *big.Rat) string {
+	s := formatRat(x)
+	if s[0] == '(' {
+		return "(1i * " + s + ")"
+	}
+	return s + "i"
+}
+
+// decimalPrec reports whether 1/d has a finite decimal representation,
+// and if so, the number of its decimal places; d must be > 0.
+func decimalPrec(d *big.Int) (int, bool) {
+	d = new(big.Int).Set(d)
+	twos := 0
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		twos++
+	}
+	fives := 0
+	five := big.NewInt(5)
+	for q, r := new(big.Int), new(big.Int); ; fives++ {
+		q.QuoRem(d, five, r)
+		if r.Sign() != 0 {
+			break
+		}
+		d.Set(q)
+	}
+	if d.Cmp(int1) != 0 {
+		return 0, false
+	}
+	if twos < fives {
+		return fives, true
+	}
+	return twos, true
+}
+
+// Parse returns the value of the constant expression src, which may
+// consist of literals, the identifiers true and false, and operators,
+// as formatted by Format. See Eval for the rules used for evaluation.
+func Parse(src string) (Value, error) {
+	x, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	val, _, err := Eval(x, nil)
+	return val, err
+}
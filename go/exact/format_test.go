@@ -0,0 +1,50 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exact
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	third := BinaryOp(MakeInt64(1), token.QUO, MakeInt64(3))
+	for _, test := range []struct {
+		x    Value
+		want string
+	}{
+		{MakeBool(true), `true`},
+		{MakeString("a\"b\n"), `"a\"b\n"`},
+		{MakeInt64(-42), `-42`},
+		{val("123456789012345678901234567890"), `123456789012345678901234567890`},
+		{val("1e30"), `1000000000000000000000000000000`},
+		{val("0.5"), `0.5`},
+		{val("-1.25e-3"), `-0.00125`},
+		{val("0.1"), `0.1`},
+		{third, `(1/3.0)`},
+		{UnaryOp(token.SUB, third, -1), `(-1/3.0)`},
+		{val("2i"), `2i`},
+		{val("-0.5i"), `-0.5i`},
+		{BinaryOp(MakeInt64(1), token.ADD, val("2i")), `(1 + 2i)`},
+		{BinaryOp(third, token.SUB, val("2i")), `((1/3.0) - 2i)`},
+		{MakeImag(third), `(1i * (1/3.0))`},
+		{BinaryOp(val("0.5"), token.ADD, MakeImag(UnaryOp(token.SUB, third, -1))), `(0.5 - (1i * (1/3.0)))`},
+	} {
+		got := Format(test.x)
+		if got != test.want {
+			t.Errorf("Format(%s) = %s; want %s", test.x, got, test.want)
+		}
+
+		// round trip
+		y, err := Parse(got)
+		if err != nil {
+			t.Errorf("Parse(%s): %s", got, err)
+			continue
+		}
+		if y.Kind() != test.x.Kind() || !Compare(y, token.EQL, test.x) {
+			t.Errorf("Parse(%s) = %s; want %s", got, y, test.x)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gccgoimporter
+
+// This file implements reading the members of ar archives,
+// such as the libraries produced by gccgo.
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	arMagic      = "!<arch>\n"
+	arHeaderSize = 60
+)
+
+// firstArchiveMember returns a reader for the contents of the first
+// member of the ar archive r that is not a symbol or name table; it
+// supports the GNU and BSD variants of the format. This replicates the
+// behavior of "ar p", which gofrontend relies on for archives.
+func firstArchiveMember(r io.ReaderAt) (*io.SectionReader, error) {
+	var magic [len(arMagic)]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return nil, err
+	}
+	if string(magic[:]) != arMagic {
+		return nil, fmt.Errorf("unsupported archive format %q", magic[:])
+	}
+
+	off := int64(len(arMagic))
+	for {
+		var hdr [arHeaderSize]byte
+		if n, err := r.ReadAt(hdr[:], off); err != nil {
+			if err == io.EOF {
+				if n == 0 {
+					err = errors.New("archive has no members")
+				} else {
+					err = io.ErrUnexpectedEOF
+				}
+			}
+			return nil, err
+		}
+		if string(hdr[58:60]) != "`\n" {
+			return nil, fmt.Errorf("invalid archive header at offset %d", off)
+		}
+		name := strings.TrimRight(string(hdr[0:16]), " ")
+		size, err := strconv.ParseInt(strings.TrimRight(string(hdr[48:58]), " "), 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid archive member size at offset %d", off)
+		}
+		off += arHeaderSize
+
+		switch name {
+		case "/", "/SYM64/", "//", "__.SYMDEF", "__.SYMDEF SORTED":
+			// symbol or name table; skip
+		default:
+			data := off
+			if strings.HasPrefix(name, "#1/") {
+				// BSD: the name of the given length precedes the data
+				n, err := strconv.ParseInt(name[3:], 10, 64)
+				if err != nil || n < 0 || n > size {
+					return nil, fmt.Errorf("invalid archive member name %q", name)
+				}
+				data += n
+				size -= n
+			}
+			return io.NewSectionReader(r, data, size), nil
+		}
+
+		off += size + size&1 // members are 2-byte aligned
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gccgoimporter
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+)
+
+// elfObject returns a minimal ELF object file whose .go_export section
+// contains exports.
+func elfObject(exports []byte) []byte {
+	const shstrtab = "\x00.go_export\x00.shstrtab\x00"
+	hdrSize := int64(binary.Size(elf.Header64{}))
+	secSize := int64(binary.Size(elf.Section64{}))
+
+	exportsOff := hdrSize
+	shstrtabOff := exportsOff + int64(len(exports))
+	shoff := (shstrtabOff + int64(len(shstrtab)) + 7) &^ 7
+
+	hdr := elf.Header64{
+		Type:      uint16(elf.ET_REL),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     uint64(shoff),
+		Ehsize:    uint16(hdrSize),
+		Shentsize: uint16(secSize),
+		Shnum:     3,
+		Shstrndx:  2,
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	sections := []elf.Section64{
+		{},
+		{Name: 1, Type: uint32(elf.SHT_PROGBITS), Off: uint64(exportsOff), Size: uint64(len(exports)), Addralign: 1},
+		{Name: 12, Type: uint32(elf.SHT_STRTAB), Off: uint64(shstrtabOff), Size: uint64(len(shstrtab)), Addralign: 1},
+	}
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, &hdr)
+	buf.Write(exports)
+	buf.WriteString(shstrtab)
+	for int64(buf.Len()) < shoff {
+		buf.WriteByte(0)
+	}
+	binary.Write(&buf, binary.LittleEndian, sections)
+	return buf.Bytes()
+}
+
+// writeArMember writes the archive member with the given name and data to buf.
+func writeArMember(buf *bytes.Buffer, name string, data []byte) {
+	fmt.Fprintf(buf, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", name, 0, 0, 0, 0644, len(data))
+	buf.Write(data)
+	if len(data)%2 != 0 {
+		buf.WriteByte('\n')
+	}
+}
+
+func TestArchiveImporter(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "gccgoimporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	for _, format := range []string{"gnu", "bsd"} {
+		dir := filepath.Join(tmpdir, format)
+		if err := os.Mkdir(dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, test := range importerTests {
+			exports, err := ioutil.ReadFile(filepath.Join("testdata", test.pkgpath+".gox"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj := elfObject(exports)
+
+			var buf bytes.Buffer
+			buf.WriteString(arMagic)
+			switch format {
+			case "gnu":
+				writeArMember(&buf, "/", []byte("\x00\x00\x00\x00"))
+				writeArMember(&buf, "//", []byte("a_rather_long_object_file_name.o/\n"))
+				writeArMember(&buf, "/0", obj)
+			case "bsd":
+				writeArMember(&buf, "__.SYMDEF SORTED", []byte("\x00\x00\x00\x00\x00\x00\x00\x00"))
+				name := "a_rather_long_object_file_name.o"
+				writeArMember(&buf, fmt.Sprintf("#1/%d", len(name)), append([]byte(name), obj...))
+			}
+			afile := filepath.Join(dir, "lib"+test.pkgpath+".a")
+			if err := ioutil.WriteFile(afile, buf.Bytes(), 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		initmap := make(map[*types.Package]InitData)
+		imp := GetImporter([]string{dir}, initmap)
+		for _, test := range importerTests {
+			runImporterTest(t, imp, initmap, &test)
+		}
+	}
+}
+
+func TestArchiveErrors(t *testing.T) {
+	var member bytes.Buffer
+	writeArMember(&member, "x.o", []byte("data"))
+
+	for _, test := range []struct {
+		archive, err string
+	}{
+		{"!<thin>\n", `unsupported archive format "!<thin>\n"`},
+		{arMagic, "archive has no members"},
+		{arMagic + "short", "unexpected EOF"},
+		{arMagic + member.String()[:58] + "XX", "invalid archive header at offset 8"},
+		{arMagic + member.String()[:48] + "-1        `\n", "invalid archive member size at offset 8"},
+	} {
+		_, err := firstArchiveMember(bytes.NewReader([]byte(test.archive)))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: got error %v; want %s", test.archive, err, test.err)
+		}
+	}
+}
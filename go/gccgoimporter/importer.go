@@ -12,7 +12,6 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
@@ -100,19 +99,12 @@ func openExportFile(fpath string) (reader io.ReadSeeker, closer io.Closer, err e
 		return
 
 	case archiveMagic:
-		// TODO(pcc): Read the archive directly instead of using "ar".
-		f.Close()
-		closer = nil
-
-		cmd := exec.Command("ar", "p", fpath)
-		var out []byte
-		out, err = cmd.Output()
+		elfreader, err = firstArchiveMember(f)
 		if err != nil {
+			err = fmt.Errorf("%s: %v", fpath, err)
 			return
 		}
 
-		elfreader = bytes.NewReader(out)
-
 	default:
 		elfreader = f
 	}
@@ -132,6 +124,11 @@ func openExportFile(fpath string) (reader io.ReadSeeker, closer io.Closer, err e
 	return
 }
 
+// GetImporter returns an Importer that reads the export data of gccgo-generated
+// packages, found in the directories searchpaths as gofrontend would find
+// them: in raw export data files, ELF object files, or archives of ELF object
+// files. If initmap is not nil, the init data of each imported package is
+// recorded in it.
 func GetImporter(searchpaths []string, initmap map[*types.Package]InitData) types.Importer {
 	return func(imports map[string]*types.Package, pkgpath string) (pkg *types.Package, err error) {
 		if pkgpath == "unsafe" {
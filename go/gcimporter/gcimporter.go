@@ -38,6 +38,10 @@ var pkgExts = [...]string{".a", ".5", ".6", ".8"}
 // If no file was found, an empty filename is returned.
 //
 func FindPkg(path, srcDir string) (filename, id string) {
+	return findPkg(&build.Default, path, srcDir)
+}
+
+func findPkg(ctxt *build.Context, path, srcDir string) (filename, id string) {
 	if len(path) == 0 {
 		return
 	}
@@ -48,11 +52,12 @@ func FindPkg(path, srcDir string) (filename, id string) {
 	default:
 		// "x" -> "$GOPATH/pkg/$GOOS_$GOARCH/x.ext", "x"
 		// Don't require the source files to be present.
-		bp, _ := build.Import(path, srcDir, build.FindOnly|build.AllowBinary)
+		bp, _ := ctxt.Import(path, srcDir, build.FindOnly|build.AllowBinary)
 		if bp.PkgObj == "" {
 			return
 		}
 		noext = strings.TrimSuffix(bp.PkgObj, ".a")
+		id = bp.ImportPath // canonical path of vendored packages
 
 	case build.IsLocalImport(path):
 		// "./x" -> "/this/directory/x.ext", "/this/directory/x"
@@ -116,6 +121,88 @@ func ImportData(imports map[string]*types.Package, filename, id string, data io.
 // The imports map must contains all packages already imported.
 //
 func Import(imports map[string]*types.Package, path string) (pkg *types.Package, err error) {
+	return importPkg(FindPkg, imports, path)
+}
+
+// ImportFor returns an Importer like Import that locates the installed
+// packages using the build context ctxt instead of build.Default. With
+// the GOOS and GOARCH of ctxt set accordingly, it imports the packages
+// compiled for a target other than the host, for instance from
+// $GOROOT/pkg/$GOOS_$GOARCH after a cross-compiling go install std.
+// If ctxt is nil, build.Default is used.
+//
+func ImportFor(ctxt *build.Context) types.Importer {
+	if ctxt == nil {
+		ctxt = &build.Default
+	}
+	return ImportWith(func(path, srcDir string) (filename, id string) {
+		return findPkg(ctxt, path, srcDir)
+	})
+}
+
+// ImportWith returns an Importer like Import that uses find instead
+// of FindPkg to locate packages. Given an import path and the directory
+// relative to which local import paths are interpreted, find returns
+// the name of the gc-generated object or archive file of the package
+// and a unique package id, which is used as the key in the imports map;
+// if the package is not found, the filename is empty. This makes it
+// possible to support custom package layouts, such as the output
+// directories of other build systems.
+//
+func ImportWith(find func(path, srcDir string) (filename, id string)) types.Importer {
+	return func(imports map[string]*types.Package, path string) (*types.Package, error) {
+		return importPkg(find, imports, path)
+	}
+}
+
+// VendorImporter returns an ImporterFrom that imports installed
+// gc-generated packages like ImportFor(ctxt), except that import paths
+// are resolved relative to the directory of the importing package: as
+// with the go command, a package in an enclosing vendor directory takes
+// precedence, and local import paths are interpreted relative to that
+// directory. Packages are recorded in imports under their canonical
+// paths, such as "a/vendor/x"; if imports is nil, a new map is used.
+// If ctxt is nil, build.Default is used.
+//
+func VendorImporter(ctxt *build.Context, imports map[string]*types.Package) types.ImporterFrom {
+	if ctxt == nil {
+		ctxt = &build.Default
+	}
+	if imports == nil {
+		imports = make(map[string]*types.Package)
+	}
+	return &vendorImporter{ctxt, imports}
+}
+
+type vendorImporter struct {
+	ctxt    *build.Context
+	imports map[string]*types.Package
+}
+
+func (imp *vendorImporter) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if mode != 0 {
+		return nil, fmt.Errorf("unsupported import mode %d", mode)
+	}
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	// go/build finds vendor directories for absolute directories only
+	if !filepath.IsAbs(dir) {
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	}
+
+	filename, id := findPkg(imp.ctxt, path, dir)
+	if filename == "" {
+		return nil, fmt.Errorf("can't find import: %s", id)
+	}
+
+	return importFile(imp.imports, filename, id)
+}
+
+func importPkg(find func(path, srcDir string) (filename, id string), imports map[string]*types.Package, path string) (pkg *types.Package, err error) {
 	if path == "unsafe" {
 		return types.Unsafe, nil
 	}
@@ -128,12 +215,18 @@ func Import(imports map[string]*types.Package, path string) (pkg *types.Package,
 		}
 	}
 
-	filename, id := FindPkg(path, srcDir)
+	filename, id := find(path, srcDir)
 	if filename == "" {
 		err = fmt.Errorf("can't find import: %s", id)
 		return
 	}
 
+	return importFile(imports, filename, id)
+}
+
+// importFile imports the package with the given id from the
+// gc-generated object or archive file filename.
+func importFile(imports map[string]*types.Package, filename, id string) (pkg *types.Package, err error) {
 	// no need to re-import if the package was imported completely before
 	if pkg = imports[id]; pkg != nil && pkg.Complete() {
 		return
@@ -5,6 +5,8 @@
 package gcimporter
 
 import (
+	"bytes"
+	"fmt"
 	"go/build"
 	"io/ioutil"
 	"os"
@@ -120,6 +122,165 @@ func TestImport(t *testing.T) {
 	t.Logf("tested %d imports", nimports)
 }
 
+// writeArchive writes a gc archive with the given export data to filename.
+func writeArchive(t *testing.T, filename, goos, goarch, exports string) {
+	pkgdef := fmt.Sprintf("go object %s %s go1.4 X:none\n\n$$\n%s$$\n", goos, goarch, exports)
+	var buf bytes.Buffer
+	buf.WriteString("!<arch>\n")
+	fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", "__.PKGDEF", 0, 0, 0, 0644, len(pkgdef))
+	buf.WriteString(pkgdef)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportFor(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gcimporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+
+	// a package installed for another target only
+	const goos, goarch = "plan9", "arm"
+	writeArchive(t, filepath.Join(gopath, "pkg", goos+"_"+goarch, "example.com", "x.a"), goos, goarch,
+		"package x\n\tconst @\"\".Answer = 42\n\ttype @\"\".T int\n\tfunc @\"\".F(@\"\".x @\"\".T) (? int)\n")
+	if err := os.MkdirAll(filepath.Join(gopath, "src", "example.com", "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctxt := build.Default
+	ctxt.GOPATH = gopath
+	ctxt.GOOS = goos
+	ctxt.GOARCH = goarch
+	ctxt.CgoEnabled = false
+
+	pkg, err := ImportFor(&ctxt)(make(map[string]*types.Package), "example.com/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pkg.Complete() || pkg.Name() != "x" {
+		t.Errorf("got package %s (complete = %v); want complete package x", pkg.Name(), pkg.Complete())
+	}
+	for _, test := range []struct{ name, want string }{
+		{"Answer", "const example.com/x.Answer untyped int"},
+		{"T", "type example.com/x.T int"},
+		{"F", "func example.com/x.F(x example.com/x.T) int"},
+	} {
+		obj := pkg.Scope().Lookup(test.name)
+		if obj == nil {
+			t.Errorf("%s not found", test.name)
+			continue
+		}
+		if got := obj.String(); got != test.want {
+			t.Errorf("%s: got %q; want %q", test.name, got, test.want)
+		}
+	}
+
+	// the package is not installed for the host
+	ctxt.GOOS = "linux"
+	if _, err := ImportFor(&ctxt)(make(map[string]*types.Package), "example.com/x"); err == nil {
+		t.Errorf("import for linux/arm succeeded unexpectedly")
+	}
+}
+
+func TestVendorImporter(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gcimporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+
+	// a package x and a copy vendored by package a
+	pkgdir := filepath.Join(gopath, "pkg", runtime.GOOS+"_"+runtime.GOARCH)
+	writeArchive(t, filepath.Join(pkgdir, "x.a"), runtime.GOOS, runtime.GOARCH,
+		"package x\n\ttype @\"\".T int\n")
+	writeArchive(t, filepath.Join(pkgdir, "a", "vendor", "x.a"), runtime.GOOS, runtime.GOARCH,
+		"package x\n\ttype @\"\".T string\n")
+	for _, dir := range []string{"x", "a/vendor/x", "a/b", "c"} {
+		if err := os.MkdirAll(filepath.Join(gopath, "src", filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// go/build only considers vendor directories with Go files
+	if err := ioutil.WriteFile(filepath.Join(gopath, "src", "a", "vendor", "x", "x.go"), []byte("package x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctxt := build.Default
+	ctxt.GOPATH = gopath
+	ctxt.CgoEnabled = false
+	imports := make(map[string]*types.Package)
+	imp := VendorImporter(&ctxt, imports)
+
+	for _, test := range []struct{ dir, path, want string }{
+		{"a/b", "x", "type a/vendor/x.T string"},
+		{"a", "x", "type a/vendor/x.T string"},
+		{"c", "x", "type x.T int"},
+		{"a/b", "unsafe", ""},
+	} {
+		pkg, err := imp.ImportFrom(test.path, filepath.Join(gopath, "src", filepath.FromSlash(test.dir)), 0)
+		if err != nil {
+			t.Errorf("%s from %s: %s", test.path, test.dir, err)
+			continue
+		}
+		if test.path == "unsafe" {
+			if pkg != types.Unsafe {
+				t.Errorf("unsafe from %s: got package %s", test.dir, pkg.Path())
+			}
+			continue
+		}
+		if got := pkg.Scope().Lookup("T").String(); got != test.want {
+			t.Errorf("%s from %s: got %s; want %s", test.path, test.dir, got, test.want)
+		}
+		if imports[pkg.Path()] != pkg {
+			t.Errorf("%s from %s: package %s not recorded in imports", test.path, test.dir, pkg.Path())
+		}
+	}
+
+	if _, err := imp.ImportFrom("x", gopath, 1); err == nil {
+		t.Errorf("import with mode 1 succeeded unexpectedly")
+	}
+}
+
+func TestImportWith(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gcimporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a custom layout: one archive per package, named after the last path element
+	writeArchive(t, filepath.Join(dir, "out", "x", "libx.a"), runtime.GOOS, runtime.GOARCH,
+		"package x\n\ttype @\"\".T int\n")
+	find := func(path, srcDir string) (filename, id string) {
+		filename = filepath.Join(dir, "out", filepath.Base(path), "lib"+filepath.Base(path)+".a")
+		if _, err := os.Stat(filename); err != nil {
+			return "", path
+		}
+		return filename, path
+	}
+
+	imports := make(map[string]*types.Package)
+	pkg, err := ImportWith(find)(imports, "example.com/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pkg.Scope().Lookup("T").String(), "type example.com/x.T int"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	if imports["example.com/x"] != pkg {
+		t.Errorf("package not recorded under its id")
+	}
+
+	if _, err := ImportWith(find)(imports, "example.com/y"); err == nil || !strings.Contains(err.Error(), "can't find import: example.com/y") {
+		t.Errorf("got error %v; want can't find import", err)
+	}
+}
+
 var importedObjectTests = []struct {
 	name string
 	want string
@@ -157,7 +318,7 @@ func TestImportedTypes(t *testing.T) {
 			continue
 		}
 
-		got := types.ObjectString(pkg, obj)
+		got := types.ObjectString(obj, types.RelativeTo(pkg))
 		if got != test.want {
 			t.Errorf("%s: got %q; want %q", test.name, got, test.want)
 		}
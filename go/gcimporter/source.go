@@ -0,0 +1,117 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements FallbackImporter.
+
+package gcimporter
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/types"
+)
+
+// FallbackImporter returns an Importer that imports the installed
+// gc-generated package for an import path like ImportFor(ctxt) but,
+// if no compiled package is found, parses and type-checks the package
+// from its source files instead, as located by ctxt. Dependencies of
+// packages checked from source are imported the same way. This makes
+// it possible to analyze code whose dependencies were never built.
+//
+// Source files are parsed with file set fset, which may be nil; function
+// bodies are not checked. An import cycle among the packages checked
+// from source is reported as an error. Packages with type errors are
+// not imported: the importer returns the first error instead. The
+// importer is not safe for concurrent use.
+//
+func FallbackImporter(ctxt *build.Context, fset *token.FileSet) types.Importer {
+	if ctxt == nil {
+		ctxt = &build.Default
+	}
+	if fset == nil {
+		fset = token.NewFileSet()
+	}
+	s := &sourceImporter{
+		ctxt:       ctxt,
+		fset:       fset,
+		inProgress: make(map[string]bool),
+	}
+	return func(imports map[string]*types.Package, path string) (*types.Package, error) {
+		srcDir := "."
+		if build.IsLocalImport(path) {
+			var err error
+			if srcDir, err = os.Getwd(); err != nil {
+				return nil, err
+			}
+		}
+		return s.importPkg(imports, path, srcDir)
+	}
+}
+
+// A sourceImporter is the state of an importer returned by FallbackImporter.
+type sourceImporter struct {
+	ctxt       *build.Context
+	fset       *token.FileSet
+	inProgress map[string]bool // packages being checked from source
+}
+
+// importPkg imports the package with the given import path; local
+// import paths are interpreted relative to srcDir.
+func (s *sourceImporter) importPkg(imports map[string]*types.Package, path, srcDir string) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+
+	if filename, id := findPkg(s.ctxt, path, srcDir); filename != "" {
+		return importFile(imports, filename, id)
+	}
+
+	// no export data: check the package from source
+	bp, err := s.ctxt.Import(path, srcDir, 0)
+	if err != nil {
+		return nil, err
+	}
+	id := bp.ImportPath
+	if build.IsLocalImport(id) {
+		id = bp.Dir // local import outside a workspace
+	}
+	if pkg := imports[id]; pkg != nil && pkg.Complete() {
+		return pkg, nil
+	}
+	if s.inProgress[id] {
+		return nil, fmt.Errorf("import cycle through package %s", id)
+	}
+	s.inProgress[id] = true
+	defer delete(s.inProgress, id)
+
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := goparser.ParseFile(s.fset, filepath.Join(bp.Dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	conf := types.Config{
+		IgnoreFuncBodies: true,
+		FakeImportC:      len(bp.CgoFiles) > 0,
+		Import: func(_ map[string]*types.Package, path string) (*types.Package, error) {
+			return s.importPkg(imports, path, bp.Dir)
+		},
+		DisableUnusedImportCheck: true,
+	}
+	pkg, err := conf.Check(id, s.fset, files, nil)
+	if err != nil {
+		return nil, fmt.Errorf("type-checking package %s failed (%v)", id, err)
+	}
+	imports[id] = pkg
+	return pkg, nil
+}
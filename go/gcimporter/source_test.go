@@ -0,0 +1,73 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gcimporter
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+)
+
+func TestFallbackImporter(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gcimporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+
+	ctxt := build.Default
+	ctxt.GOPATH = gopath
+	ctxt.CgoEnabled = false
+
+	// example.com/x is installed; the other packages exist as source only
+	writeArchive(t, filepath.Join(gopath, "pkg", ctxt.GOOS+"_"+ctxt.GOARCH, "example.com", "x.a"), ctxt.GOOS, ctxt.GOARCH,
+		"package x\n\ttype @\"\".T int\n")
+	for path, src := range map[string]string{
+		"example.com/x": `package x; type T int`,
+		"example.com/a": `package a; import ("example.com/b"; "example.com/x"); var V b.T; var W x.T; func F() { undeclared() }`,
+		"example.com/b": `package b; import "unsafe"; type T struct{ p unsafe.Pointer }`,
+		"example.com/c": `package c; import _ "example.com/d"`,
+		"example.com/d": `package d; import _ "example.com/c"`,
+	} {
+		dir := filepath.Join(gopath, "src", filepath.FromSlash(path))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	imports := make(map[string]*types.Package)
+	imp := FallbackImporter(&ctxt, nil)
+	a, err := imp(imports, "example.com/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct{ name, want string }{
+		{"V", "var example.com/a.V example.com/b.T"},
+		{"W", "var example.com/a.W example.com/x.T"},
+	} {
+		if got := a.Scope().Lookup(test.name).String(); got != test.want {
+			t.Errorf("got %s; want %s", got, test.want)
+		}
+	}
+	if imports["example.com/b"] == nil || imports["example.com/x"] == nil {
+		t.Errorf("dependencies missing from imports map")
+	}
+	if b, err := imp(imports, "example.com/b"); err != nil || b != imports["example.com/b"] {
+		t.Errorf("importing example.com/b again: got %v, %v", b, err)
+	}
+
+	_, err = imp(imports, "example.com/c")
+	if err == nil || !strings.Contains(err.Error(), "import cycle") {
+		t.Errorf("importing example.com/c: got error %v; want import cycle", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importer
+
+// This file implements CachingImporter.
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/gcimporter"
+	"golang.org/x/tools/go/types"
+)
+
+// CachingImporter returns an Importer for gc-generated packages that
+// caches the decoded packages in the directory dir, in the export data
+// format of this package, which is faster to import. Packages are
+// located with find, or gcimporter.FindPkg if find is nil (see
+// gcimporter.ImportWith).
+//
+// Cache entries are keyed by a hash of the gc export data and the
+// version of this package's format; they never need to be invalidated.
+// The cache may be shared by concurrent processes: entries are written
+// to temporary files that are atomically renamed into place, and all
+// processes write the same data for an entry, so no locking is needed.
+// Errors writing the cache are ignored.
+func CachingImporter(dir string, find func(path, srcDir string) (filename, id string)) types.Importer {
+	if find == nil {
+		find = gcimporter.FindPkg
+	}
+	return func(imports map[string]*types.Package, path string) (*types.Package, error) {
+		if path == "unsafe" {
+			return types.Unsafe, nil
+		}
+
+		srcDir := "."
+		if build.IsLocalImport(path) {
+			var err error
+			if srcDir, err = os.Getwd(); err != nil {
+				return nil, err
+			}
+		}
+
+		filename, id := find(path, srcDir)
+		if filename == "" {
+			return nil, fmt.Errorf("can't find import: %s", id)
+		}
+
+		// no need to re-import if the package was imported completely before
+		if pkg := imports[id]; pkg != nil && pkg.Complete() {
+			return pkg, nil
+		}
+
+		data, err := readGcExportData(filename)
+		if err != nil {
+			return nil, fmt.Errorf("reading export data: %s: %v", filename, err)
+		}
+		key := fmt.Sprintf("%x-v%d", sha256.Sum256(data), version)
+		entry := filepath.Join(dir, key)
+
+		if cached, err := ioutil.ReadFile(entry); err == nil {
+			if _, pkg, err := ImportData(imports, cached); err == nil && pkg.Path() == id {
+				return pkg, nil
+			}
+			// invalid entry: replace it below
+		}
+
+		pkg, err := gcimporter.ImportData(imports, filename, id, bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("reading export data: %s: %v", filename, err)
+		}
+		writeCacheEntry(dir, key, ExportData(pkg))
+		return pkg, nil
+	}
+}
+
+// readGcExportData returns the gc export data in the object or archive
+// file filename.
+func readGcExportData(filename string) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := bufio.NewReader(f)
+	if err := gcimporter.FindExportData(buf); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(buf)
+}
+
+// writeCacheEntry atomically writes the cache entry key with the given
+// data to the cache directory dir.
+func writeCacheEntry(dir, key string, data []byte) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return
+	}
+	f, err := ioutil.TempFile(dir, key+".tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
@@ -6,9 +6,13 @@ package importer
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
 	"go/ast"
+	"go/token"
+	"io"
+	"sort"
 	"strings"
 
 	"golang.org/x/tools/go/exact"
@@ -34,12 +38,67 @@ func format() byte {
 // of package pkg and returns the corresponding data. The export
 // format is described elsewhere (TODO).
 func ExportData(pkg *types.Package) []byte {
-	p := exporter{
+	return exportData(nil, pkg, nil, version)
+}
+
+// ExportDataWithPositions is like ExportData but includes the source
+// positions of the declared objects (package-level objects, including
+// those of other packages that are part of the export data, and methods),
+// as recorded in fset. ImportDataWithPositions restores them.
+func ExportDataWithPositions(fset *token.FileSet, pkg *types.Package) []byte {
+	return exportData(fset, pkg, nil, version)
+}
+
+// ExportDataWithSections is like ExportDataWithPositions but also
+// attaches the given sections to the export data. A section is opaque
+// data derived from the package by a tool, such as analysis facts or
+// summaries of its functions, identified by a name chosen by the tool.
+// ImportData skips the sections; ImportDataWithSections returns them.
+// If fset is nil, no positions are written.
+func ExportDataWithSections(fset *token.FileSet, pkg *types.Package, sections map[string][]byte) []byte {
+	return exportData(fset, pkg, sections, version)
+}
+
+// exportData is like ExportData but writes the given version of the
+// export data format, including positions if fset is not nil and the
+// given sections.
+func exportData(fset *token.FileSet, pkg *types.Package, sections map[string][]byte, version int) []byte {
+	if fset == nil || version < 2 {
+		return newExporter(nil, nil).export(pkg, sections, version)
+	}
+	if version >= 4 {
+		// The file extents are known when the files are written.
+		return newExporter(fset, make(map[string]*fileExtent)).export(pkg, sections, version)
+	}
+	// Collect the positions first so that the extent
+	// of each file is known when it is first written.
+	p := newExporter(fset, nil)
+	p.export(pkg, nil, version)
+	return newExporter(fset, p.files).export(pkg, sections, version)
+}
+
+// newExporter returns an exporter that writes the positions recorded in
+// fset, if fset is not nil, for the files described by files; if files
+// is nil, the exporter collects the file extents instead.
+func newExporter(fset *token.FileSet, files map[string]*fileExtent) *exporter {
+	p := &exporter{
 		data:     append([]byte(magic), format()),
 		pkgIndex: make(map[*types.Package]int),
 		typIndex: make(map[types.Type]int),
+		fset:     fset,
+		files:    files,
 	}
+	if fset != nil {
+		if files == nil {
+			p.files = make(map[string]*fileExtent)
+			p.collect = true
+		}
+		p.fileIndex = make(map[string]int)
+	}
+	return p
+}
 
+func (p *exporter) export(pkg *types.Package, sections map[string][]byte, version int) []byte {
 	// populate typIndex with predeclared types
 	for _, t := range predeclared {
 		p.typIndex[t] = len(p.typIndex)
@@ -50,10 +109,37 @@ func ExportData(pkg *types.Package) []byte {
 		defer p.tracef("\n")
 	}
 
-	p.string(version)
+	p.string(fmt.Sprintf("v%d", version))
+
+	if version >= 4 {
+		p.objects(pkg)
+		p.sections(sections)
+		return p.data
+	}
 
 	p.pkg(pkg)
 
+	// write imported packages
+	if version >= 1 {
+		imports := pkg.Imports()
+		if p.noImports {
+			imports = nil
+		}
+		p.int(len(imports))
+		for _, imp := range imports {
+			p.pkg(imp)
+		}
+	}
+
+	// write positions flag
+	if version >= 2 {
+		hasPos := 0
+		if p.fset != nil {
+			hasPos = 1
+		}
+		p.int(hasPos)
+	}
+
 	// collect exported objects from package scope
 	var list []types.Object
 	scope := pkg.Scope()
@@ -69,14 +155,203 @@ func ExportData(pkg *types.Package) []byte {
 		p.obj(obj)
 	}
 
+	// write sections
+	if version >= 3 {
+		p.sections(sections)
+	}
+
 	return p.data
 }
 
+// objects writes the interface of package pkg in the format of version 4
+// and newer: the named types are written first, as a whole, followed by
+// the other exported objects, each of which is encoded separately so that
+// it can be decoded on demand. The objects refer to the named types by
+// index, and all data refers to packages and files by index into tables
+// written in advance.
+func (p *exporter) objects(pkg *types.Package) {
+	p.tables = true
+	p.namedIndex = make(map[*types.Named]int)
+	p.pkgIndexOf(pkg) // pkg is package 0
+
+	var imports []*types.Package
+	if !p.noImports {
+		imports = pkg.Imports()
+	}
+	for _, imp := range imports {
+		p.pkgIndexOf(imp)
+	}
+
+	// collect exported objects from package scope
+	var list []types.Object
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if exported(name) {
+			obj := scope.Lookup(name)
+			if obj, ok := obj.(*types.TypeName); ok {
+				p.namedIndexOf(obj.Type().(*types.Named))
+				continue
+			}
+			list = append(list, obj)
+		}
+	}
+
+	// encode objects, collecting the named types they refer to
+	data := p.data
+	enc := make([][]byte, len(list))
+	p.byRef = true
+	for i, obj := range list {
+		p.reset()
+		p.objData(obj)
+		enc[i] = p.data
+	}
+	p.byRef = false
+
+	// encode named types
+	p.reset()
+	p.int(len(p.namedList))
+	for _, t := range p.namedList {
+		p.typ(t)
+	}
+	named := p.data
+	p.data = data
+
+	// write packages
+	p.int(len(p.pkgList))
+	for _, pkg := range p.pkgList {
+		p.string(pkg.Name())
+		p.string(pkg.Path())
+	}
+
+	// write imported packages
+	p.int(len(imports))
+	for _, imp := range imports {
+		p.pkg(imp)
+	}
+
+	// write positions flag and files
+	if p.fset == nil {
+		p.int(0)
+	} else {
+		p.int(1)
+		p.int(len(p.fileList))
+		for _, name := range p.fileList {
+			f := p.files[name]
+			p.string(name)
+			p.int(f.lines)
+			p.int(f.width)
+		}
+	}
+
+	// write named types and objects
+	p.bytes(named)
+	p.int(len(list))
+	for i, obj := range list {
+		switch obj.(type) {
+		case *types.Const:
+			p.int(constTag)
+		case *types.Var:
+			p.int(varTag)
+		case *types.Func:
+			p.int(funcTag)
+		default:
+			panic(fmt.Sprintf("unexpected object type %T", obj))
+		}
+		p.string(obj.Name())
+		p.bytes(enc[i])
+	}
+}
+
+// reset prepares p for encoding separate data.
+func (p *exporter) reset() {
+	p.data = nil
+	p.typIndex = make(map[types.Type]int)
+	for _, t := range predeclared {
+		p.typIndex[t] = len(p.typIndex)
+	}
+}
+
+// objData writes the data of obj, which is not a type name.
+func (p *exporter) objData(obj types.Object) {
+	if trace {
+		p.tracef("object %s {\n", obj.Name())
+		defer p.tracef("}\n")
+	}
+
+	p.pos(obj)
+	p.typ(obj.Type())
+	if obj, ok := obj.(*types.Const); ok {
+		p.value(obj.Val())
+	}
+}
+
+// sections writes the given sections, sorted by name so that
+// the data is deterministic.
+func (p *exporter) sections(sections map[string][]byte) {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	p.int(len(names))
+	for _, name := range names {
+		p.string(name)
+		p.bytes(sections[name])
+	}
+}
+
+// ExportHash returns a hash of the export data of package pkg, as
+// returned by ExportData but without the list of imported packages.
+// The data describes the interface of pkg only, without source
+// positions, and includes other packages only if the interface refers
+// to them; thus, the hash remains the same if pkg is checked again
+// after changes that don't affect its interface, such as changes to
+// function bodies and the imports they use. Build and analysis caches
+// may use it to avoid invalidating the packages that depend on pkg.
+func ExportHash(pkg *types.Package) [sha256.Size]byte {
+	// Omit the list of imports: it includes packages
+	// imported only for use in function bodies.
+	p := newExporter(nil, nil)
+	p.noImports = true
+	return sha256.Sum256(p.export(pkg, nil, version))
+}
+
+// WriteExportData writes the export data of pkg to w, preceded by its
+// length, so that the export data of several packages may be written
+// to the same stream (such as a file or pipe) and read back one package
+// at a time with ReadExportData.
+func WriteExportData(w io.Writer, pkg *types.Package) error {
+	data := ExportData(pkg)
+	var n [8]byte
+	binary.LittleEndian.PutUint64(n[:], uint64(len(data)))
+	if _, err := w.Write(n[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
 type exporter struct {
 	data     []byte
 	pkgIndex map[*types.Package]int
 	typIndex map[types.Type]int
 
+	// position support
+	fset      *token.FileSet         // if set, positions are written
+	files     map[string]*fileExtent // extent of each file
+	fileIndex map[string]int         // index of each file written
+	collect   bool                   // if set, the file extents are being collected
+
+	noImports bool // if set, the list of imported packages is omitted
+
+	// table support (version >= 4, see objects)
+	tables     bool                 // if set, packages and files are written by index
+	pkgList    []*types.Package     // packages in index order
+	fileList   []string             // files in index order
+	byRef      bool                 // if set, named types are written by index
+	namedIndex map[*types.Named]int // index of each named type
+	namedList  []*types.Named       // named types in index order
+
 	// tracing support
 	indent string
 }
@@ -96,6 +371,12 @@ func (p *exporter) pkg(pkg *types.Package) {
 		p.int(i)
 		return
 	}
+
+	// packages are written in advance if there are tables
+	if p.tables {
+		p.int(p.pkgIndexOf(pkg))
+		return
+	}
 	p.pkgIndex[pkg] = len(p.pkgIndex)
 
 	// otherwise, write the package tag (< 0) and package data
@@ -104,6 +385,109 @@ func (p *exporter) pkg(pkg *types.Package) {
 	p.string(pkg.Path())
 }
 
+// pkgIndexOf returns the index of pkg in the list of packages
+// written in advance, adding it if needed.
+func (p *exporter) pkgIndexOf(pkg *types.Package) int {
+	i, ok := p.pkgIndex[pkg]
+	if !ok {
+		i = len(p.pkgList)
+		p.pkgIndex[pkg] = i
+		p.pkgList = append(p.pkgList, pkg)
+	}
+	return i
+}
+
+// namedIndexOf returns the index of t in the list of named types
+// written in advance, adding it if needed.
+func (p *exporter) namedIndexOf(t *types.Named) int {
+	i, ok := p.namedIndex[t]
+	if !ok {
+		i = len(p.namedList)
+		p.namedIndex[t] = i
+		p.namedList = append(p.namedList, t)
+	}
+	return i
+}
+
+// A fileExtent describes the extent of a file in terms of
+// its number of lines and the longest line.
+type fileExtent struct {
+	lines, width int
+}
+
+// pos writes the position of obj if positions are written. A position is
+// a file reference followed by the line and column: 0 for an unknown
+// position, i+1 for the i'th file written, or -1 for a new file, followed
+// by its name and extent.
+func (p *exporter) pos(obj types.Object) {
+	if p.fset == nil {
+		return
+	}
+	pos := p.fset.Position(obj.Pos())
+
+	if p.collect {
+		if pos.IsValid() {
+			f := p.files[pos.Filename]
+			if f == nil {
+				f = new(fileExtent)
+				p.files[pos.Filename] = f
+			}
+			if pos.Line > f.lines {
+				f.lines = pos.Line
+			}
+			if pos.Column > f.width {
+				f.width = pos.Column
+			}
+		}
+		return
+	}
+
+	if !pos.IsValid() {
+		p.int(0)
+		return
+	}
+	if p.tables {
+		// files are written in advance, by index
+		p.int(p.fileIndexOf(pos) + 1)
+		p.int(pos.Line)
+		p.int(pos.Column)
+		return
+	}
+	if i, ok := p.fileIndex[pos.Filename]; ok {
+		p.int(i + 1)
+	} else {
+		p.fileIndex[pos.Filename] = len(p.fileIndex)
+		f := p.files[pos.Filename]
+		p.int(-1)
+		p.string(pos.Filename)
+		p.int(f.lines)
+		p.int(f.width)
+	}
+	p.int(pos.Line)
+	p.int(pos.Column)
+}
+
+// fileIndexOf returns the index of the file of the valid position pos
+// in the list of files written in advance, adding it if needed, and
+// extends the file's extent to include pos.
+func (p *exporter) fileIndexOf(pos token.Position) int {
+	i, ok := p.fileIndex[pos.Filename]
+	if !ok {
+		i = len(p.fileList)
+		p.fileIndex[pos.Filename] = i
+		p.fileList = append(p.fileList, pos.Filename)
+		p.files[pos.Filename] = new(fileExtent)
+	}
+	f := p.files[pos.Filename]
+	if pos.Line > f.lines {
+		f.lines = pos.Line
+	}
+	if pos.Column > f.width {
+		f.width = pos.Column
+	}
+	return i
+}
+
 func (p *exporter) obj(obj types.Object) {
 	if trace {
 		p.tracef("object %s {\n", obj.Name())
@@ -113,6 +497,7 @@ func (p *exporter) obj(obj types.Object) {
 	switch obj := obj.(type) {
 	case *types.Const:
 		p.int(constTag)
+		p.pos(obj)
 		p.string(obj.Name())
 		p.typ(obj.Type())
 		p.value(obj.Val())
@@ -122,10 +507,12 @@ func (p *exporter) obj(obj types.Object) {
 		p.typ(obj.Type().(*types.Named))
 	case *types.Var:
 		p.int(varTag)
+		p.pos(obj)
 		p.string(obj.Name())
 		p.typ(obj.Type())
 	case *types.Func:
 		p.int(funcTag)
+		p.pos(obj)
 		p.string(obj.Name())
 		p.typ(obj.Type())
 	default:
@@ -281,10 +668,17 @@ func (p *exporter) typ(typ types.Type) {
 	case *types.Named:
 		p.int(namedTag)
 
+		// objects refer to named types by index
+		if p.byRef {
+			p.int(p.namedIndexOf(t))
+			return
+		}
+
 		// write type object
 		obj := t.Obj()
 		p.string(obj.Name())
 		p.pkg(obj.Pkg())
+		p.pos(obj)
 
 		// write underlying type
 		p.typ(t.Underlying())
@@ -294,6 +688,7 @@ func (p *exporter) typ(typ types.Type) {
 		p.int(n)
 		for i := 0; i < n; i++ {
 			m := t.Method(i)
+			p.pos(m)
 			p.string(m.Name())
 			p.typ(m.Type())
 		}
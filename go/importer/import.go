@@ -11,8 +11,13 @@ package importer // import "golang.org/x/tools/go/importer"
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"go/token"
+	"io"
+	"math"
+	"strconv"
+	"strings"
 
 	"golang.org/x/tools/go/exact"
 	"golang.org/x/tools/go/types"
@@ -23,7 +28,37 @@ import (
 // If data is obviously malformed, an error is returned but in
 // general it is not recommended to call ImportData on untrusted
 // data.
+//
+// ImportData accepts the data written by the current and all previous
+// versions of ExportData; data written by a newer version causes an
+// error. For data of version v0, which does not record imports, the
+// package's list of imports is not set.
+//
+// For data of version v4 and newer, the constants, variables, and
+// functions of the package are constructed when they are first looked
+// up in the package scope, so that the objects not used by a client
+// don't occupy memory; only the named types are kept immediately. The
+// data of every object is nonetheless decoded once by ImportData, so
+// that malformed data is reported as an error and looking up an object
+// never fails.
 func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Package, error) {
+	return ImportDataWithPositions(nil, imports, data)
+}
+
+// ImportDataWithPositions is like ImportData but also restores the
+// positions of declared objects recorded by ExportDataWithPositions:
+// for each file, a file with the same name is added to fset such that
+// the positions have the recorded lines and columns. If the data has
+// no positions, or if fset is nil, the objects have no positions.
+func ImportDataWithPositions(fset *token.FileSet, imports map[string]*types.Package, data []byte) (int, *types.Package, error) {
+	n, pkg, _, err := ImportDataWithSections(fset, imports, data)
+	return n, pkg, err
+}
+
+// ImportDataWithSections is like ImportDataWithPositions but also
+// returns the sections attached by ExportDataWithSections, indexed by
+// name. The result is nil if the data has no sections.
+func ImportDataWithSections(fset *token.FileSet, imports map[string]*types.Package, data []byte) (_ int, _ *types.Package, _ map[string][]byte, err error) {
 	datalen := len(data)
 
 	// check magic string
@@ -33,7 +68,7 @@ func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Pac
 		data = data[len(magic):]
 	}
 	if s != magic {
-		return 0, nil, fmt.Errorf("incorrect magic string: got %q; want %q", s, magic)
+		return 0, nil, nil, fmt.Errorf("incorrect magic string: got %q; want %q", s, magic)
 	}
 
 	// check low-level encoding format
@@ -43,13 +78,14 @@ func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Pac
 		data = data[1:]
 	}
 	if m != format() {
-		return 0, nil, fmt.Errorf("incorrect low-level encoding format: got %c; want %c", m, format())
+		return 0, nil, nil, fmt.Errorf("incorrect low-level encoding format: got %c; want %c", m, format())
 	}
 
 	p := importer{
 		data:    data,
 		datalen: datalen,
 		imports: imports,
+		fset:    fset,
 	}
 
 	// populate typList with predeclared types
@@ -57,32 +93,118 @@ func ImportData(imports map[string]*types.Package, data []byte) (int, *types.Pac
 		p.typList = append(p.typList, t)
 	}
 
-	if v := p.string(); v != version {
-		return 0, nil, fmt.Errorf("unknown version: got %s; want %s", v, version)
+	// report malformed data instead of crashing
+	defer func() {
+		if r := recover(); r != nil {
+			if debug {
+				panic(r)
+			}
+			err = fmt.Errorf("malformed export data: %v", r)
+		}
+	}()
+
+	s = p.string()
+	v, err := strconv.Atoi(strings.TrimPrefix(s, "v"))
+	if !strings.HasPrefix(s, "v") || err != nil || v < 0 {
+		return 0, nil, nil, fmt.Errorf("invalid export data version %q", s)
+	}
+	if v > version {
+		return 0, nil, nil, fmt.Errorf("export data version %s is newer than the newest supported version v%d", s, version)
 	}
 
-	pkg := p.pkg()
+	var pkg *types.Package
+	if v >= 4 {
+		// read packages
+		for i, n := 0, p.int(); i < n; i++ {
+			name := p.string()
+			p.addPkg(name, p.string())
+		}
+		pkg = p.pkgList[0]
+	} else {
+		pkg = p.pkg()
+	}
 	if debug && p.pkgList[0] != pkg {
 		panic("imported packaged not found in pkgList[0]")
 	}
 
+	// read imported packages
+	if v >= 1 {
+		list := make([]*types.Package, p.int())
+		for i := range list {
+			list[i] = p.pkg()
+		}
+		pkg.SetImports(list)
+	}
+
+	// read positions flag
+	if v >= 2 {
+		p.hasPos = p.int() != 0
+	}
+
 	// read objects
-	n := p.int()
-	for i := 0; i < n; i++ {
-		p.obj(pkg)
+	if v >= 4 {
+		p.objects(pkg)
+	} else {
+		n := p.int()
+		for i := 0; i < n; i++ {
+			p.obj(pkg)
+		}
 	}
 
-	// complete interfaces
-	for _, typ := range p.typList {
-		if it, ok := typ.(*types.Interface); ok {
-			it.Complete()
+	// read sections
+	var sections map[string][]byte
+	if v >= 3 {
+		if n := p.int(); n > 0 {
+			sections = make(map[string][]byte, n)
+			for i := 0; i < n; i++ {
+				name := p.string()
+				sections[name] = append([]byte(nil), p.bytes()...)
+			}
 		}
 	}
 
+	// complete interfaces
+	p.complete()
+
 	// package was imported completely and without errors
 	pkg.MarkComplete()
 
-	return p.consumed(), pkg, nil
+	return p.consumed(), pkg, sections, nil
+}
+
+// ReadExportData reads the export data of a package written by
+// WriteExportData from r and imports the package as ImportData does.
+// If r is at the end of the stream, the error is io.EOF. Packages read
+// with the same imports map share the packages they depend on.
+func ReadExportData(imports map[string]*types.Package, r io.Reader) (*types.Package, error) {
+	var n [8]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = errors.New("truncated export data length")
+		}
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint64(n[:])
+	if size > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid export data length %d", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("reading export data: %v", err)
+	}
+
+	m, pkg, err := ImportData(imports, data)
+	if err != nil {
+		return nil, err
+	}
+	if m != len(data) {
+		return nil, fmt.Errorf("export data of package %s has %d bytes of trailing data", pkg.Path(), len(data)-m)
+	}
+	return pkg, nil
 }
 
 type importer struct {
@@ -91,6 +213,70 @@ type importer struct {
 	imports map[string]*types.Package
 	pkgList []*types.Package
 	typList []types.Type
+	named   []*types.Named // if set, named types are read by index
+
+	// position support
+	fset   *token.FileSet
+	hasPos bool      // set if the data contains positions
+	files  []posFile // files read so far
+}
+
+// A posFile is a file added to the importer's file set; its lines
+// have the same width so that every line and column can be represented.
+type posFile struct {
+	file         *token.File // nil if the importer has no file set
+	lines, width int
+}
+
+// pos reads a position written by exporter.pos.
+func (p *importer) pos() token.Pos {
+	if !p.hasPos {
+		return token.NoPos
+	}
+
+	var f posFile
+	switch ref := p.int(); {
+	case ref == 0:
+		return token.NoPos
+	case ref > 0:
+		f = p.files[ref-1]
+	case ref == -1:
+		f = p.file()
+	default:
+		panic(fmt.Sprintf("unexpected file reference %d", ref))
+	}
+
+	line := p.int()
+	col := p.int()
+	if line < 1 || line > f.lines || col < 1 || col > f.width {
+		panic(fmt.Sprintf("invalid position %d:%d", line, col))
+	}
+	if f.file == nil {
+		return token.NoPos
+	}
+	return f.file.Pos((line-1)*f.width + col - 1)
+}
+
+// file reads the name and extent of a file written by exporter.pos
+// or exporter.objects and adds the file to the files read so far.
+func (p *importer) file() posFile {
+	var f posFile
+	name := p.string()
+	f.lines = p.int()
+	f.width = p.int()
+	if f.lines <= 0 || f.width <= 0 {
+		panic(fmt.Sprintf("invalid extent of file %s", name))
+	}
+	if p.fset != nil {
+		f.file = p.fset.AddFile(name, -1, f.lines*f.width)
+		lines := make([]int, f.lines)
+		for i := range lines {
+			lines[i] = i * f.width
+		}
+		f.file.SetLines(lines)
+	}
+	p.files = append(p.files, f)
+	return f
 }
 
 func (p *importer) pkg() *types.Package {
@@ -107,8 +293,12 @@ func (p *importer) pkg() *types.Package {
 
 	// read package data
 	name := p.string()
-	path := p.string()
+	return p.addPkg(name, p.string())
+}
 
+// addPkg adds the package with the given name and path to the packages
+// read so far and returns it.
+func (p *importer) addPkg(name, path string) *types.Package {
 	// if the package was imported before, use that one; otherwise create a new one
 	pkg := p.imports[path]
 	if pkg == nil {
@@ -120,19 +310,112 @@ func (p *importer) pkg() *types.Package {
 	return pkg
 }
 
+// objects reads the files, named types, and objects written by
+// exporter.objects. The named types are read as a whole; the other
+// objects are validated and inserted into the scope of pkg such that
+// they are constructed when they are first looked up.
+func (p *importer) objects(pkg *types.Package) {
+	// read files
+	if p.hasPos {
+		for i, n := 0, p.int(); i < n; i++ {
+			p.file()
+		}
+	}
+
+	// read named types
+	q := p.sub(p.bytes(), nil)
+	named := make([]*types.Named, q.int())
+	for i := range named {
+		named[i] = q.typ().(*types.Named)
+	}
+	q.complete()
+
+	// read objects
+	scope := pkg.Scope()
+	for i, n := 0, p.int(); i < n; i++ {
+		tag := p.int()
+		if tag != constTag && tag != varTag && tag != funcTag {
+			panic(fmt.Sprintf("unexpected object tag %d", tag))
+		}
+		name := p.string()
+		data := append([]byte(nil), p.bytes()...)
+		// Decode the object now to validate its data, but discard it:
+		// the lookup decodes it again, which then cannot fail.
+		p.sub(data, named).object(pkg, tag, name)
+		if !scope.InsertLazy(name, func() types.Object {
+			return p.sub(data, named).object(pkg, tag, name)
+		}) {
+			panic(fmt.Sprintf("%s already declared", name))
+		}
+	}
+}
+
+// object decodes the object with the given tag and name from the data
+// of p, which must consist of exactly the data written by exporter.objData.
+func (p *importer) object(pkg *types.Package, tag int, name string) types.Object {
+	obj := p.objData(pkg, tag, name)
+	if len(p.data) != 0 {
+		panic(fmt.Sprintf("%d bytes of trailing data in object %s", len(p.data), name))
+	}
+	p.complete()
+	return obj
+}
+
+// sub returns an importer for the given data, which is encoded
+// separately but refers to the packages and files read by p and,
+// by index, to the given named types.
+func (p *importer) sub(data []byte, named []*types.Named) *importer {
+	q := &importer{
+		data:    data,
+		datalen: len(data),
+		pkgList: p.pkgList,
+		named:   named,
+		hasPos:  p.hasPos,
+		files:   p.files,
+	}
+	for _, t := range predeclared {
+		q.typList = append(q.typList, t)
+	}
+	return q
+}
+
+// objData reads the data written by exporter.objData and returns
+// the respective object.
+func (p *importer) objData(pkg *types.Package, tag int, name string) types.Object {
+	switch tag {
+	case constTag:
+		return types.NewConst(p.pos(), pkg, name, p.typ(), p.value())
+	case varTag:
+		return types.NewVar(p.pos(), pkg, name, p.typ())
+	case funcTag:
+		return types.NewFunc(p.pos(), pkg, name, p.typ().(*types.Signature))
+	default:
+		panic(fmt.Sprintf("unexpected object tag %d", tag))
+	}
+}
+
+// complete completes the interfaces read so far.
+func (p *importer) complete() {
+	for _, typ := range p.typList {
+		if it, ok := typ.(*types.Interface); ok {
+			it.Complete()
+		}
+	}
+}
+
 func (p *importer) obj(pkg *types.Package) {
 	var obj types.Object
 	switch tag := p.int(); tag {
 	case constTag:
-		obj = types.NewConst(token.NoPos, pkg, p.string(), p.typ(), p.value())
+		obj = types.NewConst(p.pos(), pkg, p.string(), p.typ(), p.value())
 	case typeTag:
 		// type object is added to scope via respective named type
 		_ = p.typ().(*types.Named)
 		return
 	case varTag:
-		obj = types.NewVar(token.NoPos, pkg, p.string(), p.typ())
+		obj = types.NewVar(p.pos(), pkg, p.string(), p.typ())
 	case funcTag:
-		obj = types.NewFunc(token.NoPos, pkg, p.string(), p.typ().(*types.Signature))
+		obj = types.NewFunc(p.pos(), pkg, p.string(), p.typ().(*types.Signature))
 	default:
 		panic(fmt.Sprintf("unexpected object tag %d", tag))
 	}
@@ -300,15 +583,23 @@ func (p *importer) typ() types.Type {
 		return t
 
 	case namedTag:
+		// objects refer to named types by index
+		if p.named != nil {
+			t := p.named[p.int()]
+			p.record(t)
+			return t
+		}
+
 		// read type object
 		name := p.string()
 		pkg := p.pkg()
+		pos := p.pos()
 		scope := pkg.Scope()
 		obj := scope.Lookup(name)
 
 		// if the object doesn't exist yet, create and insert it
 		if obj == nil {
-			obj = types.NewTypeName(token.NoPos, pkg, name, nil)
+			obj = types.NewTypeName(pos, pkg, name, nil)
 			scope.Insert(obj)
 		}
 
@@ -324,7 +615,7 @@ func (p *importer) typ() types.Type {
 
 		// read associated methods
 		for i, n := 0, p.int(); i < n; i++ {
-			t0.AddMethod(types.NewFunc(token.NoPos, pkg, p.string(), p.typ().(*types.Signature)))
+			t0.AddMethod(types.NewFunc(p.pos(), pkg, p.string(), p.typ().(*types.Signature)))
 		}
 
 		return t
@@ -447,6 +738,9 @@ func (p *importer) marker(want byte) {
 // rawInt64 should only be used by low-level decoders
 func (p *importer) rawInt64() int64 {
 	i, n := binary.Varint(p.data)
+	if n <= 0 {
+		panic("unexpected end of data")
+	}
 	p.data = p.data[n:]
 	return i
 }
@@ -12,11 +12,15 @@ import (
 	"go/build"
 	"go/parser"
 	"go/token"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -98,6 +102,465 @@ func TestImportSrc(t *testing.T) {
 	}
 }
 
+func TestExportHash(t *testing.T) {
+	q, err := pkgForSource(`package q; type T int; func F() int { return 0 }`)
+	if err != nil {
+		t.Fatalf("typecheck failed: %s", err)
+	}
+	conf := types.Config{
+		Import: func(imports map[string]*types.Package, path string) (*types.Package, error) {
+			if path != "q" {
+				return nil, fmt.Errorf("can't find import: %s", path)
+			}
+			imports[path] = q
+			return q, nil
+		},
+	}
+	hash := func(src string) [32]byte {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatalf("typecheck failed: %s", err)
+		}
+		return ExportHash(pkg)
+	}
+
+	h := hash(`package p; type T struct{ x int }; func F() int { return 0 }`)
+	for _, test := range []struct {
+		src     string
+		changed bool
+	}{
+		{`package p; type T struct{ x int }; func F() int { return 0 }`, false},
+		{`package p
+
+		  type T struct{ x int }
+
+		  func F() int { var x int; return x + 1 }`, false},
+		{`package p; type T struct{ x int }; func F() int { return 0 }; func g() {}`, false},
+		{`package p; import "q"; type T struct{ x int }; func F() int { return q.F() }`, false},
+		{`package p; type T struct{ x int }; func F() int64 { return 0 }`, true},
+		{`package p; type T struct{ y int }; func F() int { return 0 }`, true},
+		{`package p; type T struct{ x int }; func F() int { return 0 }; func G() {}`, true},
+		{`package p; import "q"; type T struct{ x int }; func F() int { return 0 }; var V q.T`, true},
+	} {
+		if changed := hash(test.src) != h; changed != test.changed {
+			t.Errorf("%s: got changed = %v; want %v", test.src, changed, test.changed)
+		}
+	}
+}
+
+func TestReadWriteExportData(t *testing.T) {
+	srcs := []string{
+		`package p; type T struct{ x int }; func F(T) T`,
+		`package p; const C = 1 << 100; var V []map[string]complex64`,
+		`package p; type I interface{ M() I }`,
+	}
+
+	var buf bytes.Buffer
+	var want []string
+	var pkgs []*types.Package
+	for i, src := range srcs {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// use distinct paths: the packages share the imports map
+		pkg, err := typecheck(fmt.Sprintf("p%d", i), f)
+		if err != nil {
+			t.Fatalf("typecheck failed: %s", err)
+		}
+		pkgs = append(pkgs, pkg)
+		if err := WriteExportData(&buf, pkg); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, pkgString(pkg))
+	}
+
+	imports := make(map[string]*types.Package)
+	for i := range srcs {
+		pkg, err := ReadExportData(imports, &buf)
+		if err != nil {
+			t.Fatalf("%s: %s", srcs[i], err)
+		}
+		if got := pkgString(pkg); got != want[i] {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", srcs[i], got, want[i])
+		}
+	}
+	if _, err := ReadExportData(imports, &buf); err != io.EOF {
+		t.Errorf("at end of stream: got error %v; want io.EOF", err)
+	}
+
+	// truncated data
+	WriteExportData(&buf, pkgs[0])
+	buf.Truncate(buf.Len() - 1)
+	if _, err := ReadExportData(imports, &buf); err == nil {
+		t.Errorf("truncated export data: got no error")
+	}
+}
+
+func TestExportDataVersions(t *testing.T) {
+	parse := func(src string) *ast.File {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	q, err := typecheck("q", parse(`package q; type T int`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Import: func(_ map[string]*types.Package, path string) (*types.Package, error) {
+		if path == "unsafe" {
+			return types.Unsafe, nil
+		}
+		return q, nil
+	}}
+	pkg, err := conf.Check("p", fset, []*ast.File{parse(`package p; import ("q"; "unsafe"); var V q.T; var P unsafe.Pointer`)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for v := 0; v <= version; v++ {
+		imports := make(map[string]*types.Package)
+		_, pkg1, err := ImportData(imports, exportData(nil, pkg, nil, v))
+		if err != nil {
+			t.Errorf("version %d: %s", v, err)
+			continue
+		}
+		if got, want := pkgString(pkg1), pkgString(pkg); got != want {
+			t.Errorf("version %d: got:\n%s\nwant:\n%s", v, got, want)
+		}
+		var got []string
+		for _, imp := range pkg1.Imports() {
+			got = append(got, imp.Path())
+		}
+		want := ""
+		if v >= 1 {
+			want = "q"
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("version %d: got imports %v; want %s", v, got, want)
+		}
+	}
+
+	// data of a newer version
+	data := exportData(nil, pkg, nil, version+1)
+	if _, _, err := ImportData(make(map[string]*types.Package), data); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("newer version: got error %v", err)
+	}
+
+	// malformed data
+	data = ExportData(pkg)
+	if _, _, err := ImportData(make(map[string]*types.Package), data[:len(data)/2]); err == nil {
+		t.Errorf("truncated data: got no error")
+	}
+}
+
+func TestExportDataPositions(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+const C = 1
+
+type T struct{ x int }
+
+func (T) M() {}
+
+var (
+	V T
+	P unsafe.Pointer
+)
+
+func F(float64) T { return T{} }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := defaultConf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t1 := pkg.Scope().Lookup("T").Type().(*types.Named)
+
+	// positions are restored
+	fset1 := token.NewFileSet()
+	_, pkg1, err := ImportDataWithPositions(fset1, make(map[string]*types.Package), ExportDataWithPositions(fset, pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(obj, obj1 types.Object) {
+		got, want := fset1.Position(obj1.Pos()), fset.Position(obj.Pos())
+		got.Offset, want.Offset = 0, 0 // the files differ
+		if got != want {
+			t.Errorf("%s: got position %s; want %s", obj.Name(), got, want)
+		}
+	}
+	for _, name := range pkg.Scope().Names() {
+		check(pkg.Scope().Lookup(name), pkg1.Scope().Lookup(name))
+	}
+	check(t1.Method(0), pkg1.Scope().Lookup("T").Type().(*types.Named).Method(0))
+
+	// positions are not restored without file set or positions
+	for _, test := range []struct {
+		data []byte
+		fset *token.FileSet
+	}{
+		{ExportDataWithPositions(fset, pkg), nil},
+		{ExportData(pkg), token.NewFileSet()},
+		{exportData(fset, pkg, nil, 1), token.NewFileSet()},
+	} {
+		_, pkg1, err := ImportDataWithPositions(test.fset, make(map[string]*types.Package), test.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos := pkg1.Scope().Lookup("C").Pos(); pos.IsValid() {
+			t.Errorf("got valid position %d", pos)
+		}
+	}
+}
+
+func TestExportDataSections(t *testing.T) {
+	pkg, err := pkgForSource(`package p; type T int; func F(T) {}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections := map[string][]byte{
+		"facts": []byte("F: pure"),
+		"ssa":   {0, 1, 2},
+		"empty": nil,
+	}
+	data := ExportDataWithSections(nil, pkg, sections)
+
+	// sections are returned
+	n, pkg1, got, err := ImportDataWithSections(nil, make(map[string]*types.Package), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(data) {
+		t.Errorf("consumed %d bytes; want %d", n, len(data))
+	}
+	if got, want := pkgString(pkg1), pkgString(pkg); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(got) != len(sections) {
+		t.Errorf("got %d sections; want %d", len(got), len(sections))
+	}
+	for name, want := range sections {
+		if data, ok := got[name]; !ok || !bytes.Equal(data, want) {
+			t.Errorf("section %s: got %q (present: %t); want %q", name, data, ok, want)
+		}
+	}
+
+	// sections are skipped
+	_, pkg1, err = ImportData(make(map[string]*types.Package), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pkgString(pkg1), pkgString(pkg); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// the data is deterministic
+	for i := 0; i < 10; i++ {
+		if !bytes.Equal(ExportDataWithSections(nil, pkg, sections), data) {
+			t.Fatal("export data with sections is not deterministic")
+		}
+	}
+
+	// no sections
+	for _, data := range [][]byte{ExportData(pkg), exportData(nil, pkg, nil, 2)} {
+		_, _, got, err := ImportDataWithSections(nil, make(map[string]*types.Package), data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Errorf("got sections %v; want none", got)
+		}
+	}
+}
+
+func TestImportDataOnDemand(t *testing.T) {
+	q, err := pkgForSource(`package q; type T int; func F() T { return 0 }; var Unused int`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imports := make(map[string]*types.Package)
+	_, q1, err := ImportData(imports, ExportData(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// clients type-check against the objects constructed on demand
+	conf := types.Config{
+		Import: func(_ map[string]*types.Package, path string) (*types.Package, error) {
+			if path != "q" {
+				return nil, fmt.Errorf("can't find import: %s", path)
+			}
+			return q1, nil
+		},
+	}
+	for _, src := range []string{
+		`package p; import "q"; var _ q.T = q.F()`,
+		`package p; import . "q"; var _ T = F()`,
+	} {
+		f, err := parser.ParseFile(fset, "", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+			t.Errorf("%s: typecheck failed: %s", src, err)
+		}
+	}
+
+	// an object is constructed once
+	if obj := q1.Scope().Lookup("Unused"); obj == nil || obj != q1.Scope().Lookup("Unused") {
+		t.Errorf("Unused: got different objects")
+	}
+}
+
+func TestImportDataMalformedObject(t *testing.T) {
+	q, err := pkgForSource(`package q; type T int; func F() T { return 0 }; var Unused int`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := ExportData(q)
+
+	// corrupt the data of Unused: its type is at an invalid index
+	i := bytes.Index(data, []byte("Unused"))
+	if i < 0 {
+		t.Fatal("Unused not found in export data")
+	}
+	if data[i+len("Unused")] != 2 { // length of data (varint encoded)
+		t.Fatalf("unexpected encoding of Unused: %q", data[i:])
+	}
+	data[i+len("Unused")+1] = 0x7e // 63 (varint encoded)
+	if _, _, err := ImportData(make(map[string]*types.Package), data); err == nil || !strings.Contains(err.Error(), "malformed export data") {
+		t.Errorf("corrupt object data: got error %v", err)
+	}
+
+	// Any corruption of the data is either reported by ImportData
+	// or harmless: the objects can be looked up after the import.
+	data = ExportData(q)
+	lookupAll := func(data []byte) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("lookup panicked: %v", r)
+			}
+		}()
+		_, pkg, err := ImportData(make(map[string]*types.Package), data)
+		if err != nil {
+			return nil // reported by ImportData
+		}
+		for _, name := range pkg.Scope().Names() {
+			pkg.Scope().Lookup(name).Type()
+		}
+		return nil
+	}
+	for n := range data {
+		if err := lookupAll(data[:n]); err != nil {
+			t.Errorf("data truncated to %d bytes: %v", n, err)
+		}
+		for _, b := range []byte{0x00, 0x01, 0x7e, 0x7f, 0xff} {
+			corrupt := append([]byte(nil), data...)
+			corrupt[n] = b
+			if err := lookupAll(corrupt); err != nil {
+				t.Errorf("byte %d set to %#x: %v", n, b, err)
+			}
+		}
+	}
+}
+
+// writeArchive writes a gc archive with the given export data to filename.
+func writeArchive(t *testing.T, filename, exports string) {
+	pkgdef := fmt.Sprintf("go object %s %s go1.4 X:none\n\n$$\n%s$$\n", runtime.GOOS, runtime.GOARCH, exports)
+	var buf bytes.Buffer
+	buf.WriteString("!<arch>\n")
+	fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", "__.PKGDEF", 0, 0, 0, 0644, len(pkgdef))
+	buf.WriteString(pkgdef)
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCachingImporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "importer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "x.a")
+	cache := filepath.Join(dir, "cache")
+	find := func(path, srcDir string) (filename, id string) {
+		if path == "example.com/x" {
+			filename = archive
+		}
+		return filename, path
+	}
+	entries := func() []string {
+		list, _ := filepath.Glob(filepath.Join(cache, "*"))
+		return list
+	}
+	importX := func() *types.Package {
+		pkg, err := CachingImporter(cache, find)(make(map[string]*types.Package), "example.com/x")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg
+	}
+
+	writeArchive(t, archive, "package x\n\tconst @\"\".C = 1\n\ttype @\"\".T struct { @\"\".f int }\n")
+	want := "package x\nconst example.com/x.C untyped int = 1\ntype example.com/x.T struct{f int}\n"
+
+	// concurrent imports with an empty cache
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			CachingImporter(cache, find)(make(map[string]*types.Package), "example.com/x")
+		}()
+	}
+	wg.Wait()
+	if got := pkgString(importX()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	list := entries()
+	if len(list) != 1 {
+		t.Fatalf("got cache entries %v; want one entry", list)
+	}
+
+	// the cache entry is used
+	ioutil.WriteFile(list[0], ExportData(types.NewPackage("example.com/x", "x")), 0644)
+	if got := pkgString(importX()); got != "package x\n" {
+		t.Errorf("cache entry not used: got:\n%s", got)
+	}
+
+	// a changed package has a new entry
+	writeArchive(t, archive, "package x\n\tconst @\"\".C = 2\n")
+	if got, want := pkgString(importX()), "package x\nconst example.com/x.C untyped int = 2\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if list := entries(); len(list) != 2 {
+		t.Errorf("got cache entries %v; want two entries", list)
+	}
+
+	// invalid entries are replaced
+	for _, entry := range entries() {
+		ioutil.WriteFile(entry, []byte("invalid"), 0644)
+	}
+	if got, want := pkgString(importX()), "package x\nconst example.com/x.C untyped int = 2\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
 func TestImportStdLib(t *testing.T) {
 	start := time.Now()
 
@@ -6,10 +6,21 @@ package importer
 
 import "golang.org/x/tools/go/types"
 
-const (
-	magic   = "\n$$ exports $$\n"
-	version = "v0"
-)
+const magic = "\n$$ exports $$\n"
+
+// Export data versions. The version is written as a string of the
+// form "v<n>"; ImportData reads the data of all versions <= version.
+//
+//	v0: initial format
+//	v1: the package is followed by the list of packages it imports
+//	v2: the imports are followed by a flag that reports whether the
+//	    data contains the positions of declared objects (see pos)
+//	v3: the objects are followed by the sections attached by tools,
+//	    sorted by name (see ExportDataWithSections)
+//	v4: the packages and files are written in advance, followed by the
+//	    named types and the separately encoded other objects, so that
+//	    the importer can decode the latter on demand (see objects)
+const version = 4
 
 // Tags. Must be < 0.
 const (
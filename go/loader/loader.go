@@ -25,9 +25,10 @@
 //      // See FromArgsUsage for help.
 //      rest, err := conf.FromArgs(os.Args[1:], wantTests)
 //
-//      // Parse the specified files and create an ad-hoc package with path "foo".
+//      // Create an ad-hoc package with path "foo" from
+//      // the specified files, which are parsed by Load.
 //      // All files must have the same 'package' declaration.
-//      err := conf.CreateFromFilenames("foo", "foo.go", "bar.go")
+//      conf.CreateFromFilenames("foo", "foo.go", "bar.go")
 //
 //      // Create an ad-hoc package with path "foo" from
 //      // the specified already-parsed files.
@@ -39,7 +40,7 @@
 //
 //      // Adds "fmt" and "fmt_test" to the set of packages
 //      // to be loaded.  "fmt" will include *_test.go files.
-//      err := conf.ImportWithTests("fmt")
+//      conf.ImportWithTests("fmt")
 //
 //      // Finally, load all the packages specified by the configuration.
 //      prog, err := conf.Load()
@@ -200,6 +201,7 @@ import (
 	"go/parser"
 	"go/token"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -264,9 +266,23 @@ type Config struct {
 	// By default, cgo is invoked to preprocess Go files that
 	// import the fake package "C".  This behaviour can be
 	// disabled by setting CGO_ENABLED=0 in the environment prior
-	// to startup, or by setting Build.CgoEnabled=false.
+	// to startup, or by setting Build.CgoEnabled=false.  See also
+	// ProcessCgoFiles.
 	Build *build.Context
 
+	// If ProcessCgoFiles is non-nil, it is called instead of the
+	// cgo preprocessor to obtain the syntax trees that replace the
+	// CgoFiles of package bp, e.g. files in which references to the
+	// pseudo-package "C" are resolved by declarations generated by
+	// the client.  The files must be parsed using fset and mode.
+	//
+	// A client that cannot generate such declarations may instead
+	// return the CgoFiles as parsed and set TypeChecker.FakeImportC,
+	// in which case references to "C" are not type-checked.
+	//
+	// It must be safe to call concurrently from multiple goroutines.
+	ProcessCgoFiles func(bp *build.Package, fset *token.FileSet, mode parser.Mode) ([]*ast.File, error)
+
 	// If DisplayPath is non-nil, it is used to transform each
 	// file name obtained from Build.Import().  This can be used
 	// to prevent a virtualized build.Config's file names from
@@ -281,10 +297,14 @@ type Config struct {
 
 	// CreatePkgs specifies a list of non-importable initial
 	// packages to create.  Each element specifies a list of
-	// parsed files to be type-checked into a new package, and a
-	// path for that package.  If the path is "", the package's
-	// name will be used instead.  The path needn't be globally
-	// unique.
+	// parsed files and of files to be parsed, which are
+	// type-checked into a new package, and a path for that
+	// package.  If the path is "", the package's name will be
+	// used instead.  The path needn't be globally unique.
+	//
+	// Such ad-hoc packages need not reside in a package
+	// directory: they may consist of scratch files, generated
+	// code, or editor buffers (see buildutil.OverlayContext).
 	//
 	// The resulting packages will appear in the corresponding
 	// elements of the Program.Created slice.
@@ -293,8 +313,12 @@ type Config struct {
 	// ImportPkgs specifies a set of initial packages to load from
 	// source.  The map keys are package import paths, used to
 	// locate the package relative to $GOROOT.  The corresponding
-	// values indicate whether to augment the package by *_test.go
-	// files in a second pass.
+	// values indicate whether to load the package's tests too:
+	// if true, the package is augmented by its in-package
+	// *_test.go files in a second pass, and its external test
+	// package, if any, is created along with its dependencies.
+	// The external test packages follow the CreatePkgs packages
+	// in Program.Created, in order of import path.
 	ImportPkgs map[string]bool
 
 	// PackageCreated is a hook called when a types.Package
@@ -311,9 +335,12 @@ type Config struct {
 	PackageCreated func(*types.Package)
 }
 
+// A CreatePkg specifies a non-importable package to be created from
+// a list of files, either by parsing them or by supplying the ASTs.
 type CreatePkg struct {
-	Path  string // the import path of the resulting (non-importable) types.Package
-	Files []*ast.File
+	Path      string      // the import path of the resulting (non-importable) types.Package
+	Files     []*ast.File // the parsed files, which come first in the package
+	Filenames []string    // the files to parse, relative to the current directory
 }
 
 // A Program is a Go program loaded from source or binary
@@ -441,7 +468,7 @@ func (conf *Config) FromArgs(args []string, xtest bool) (rest []string, err erro
 				return nil, fmt.Errorf("named files must be .go files: %s", arg)
 			}
 		}
-		err = conf.CreateFromFilenames("", args...)
+		conf.CreateFromFilenames("", args...)
 	} else {
 		// Assume args are directories each denoting a
 		// package and (perhaps) an external test, iff xtest.
@@ -460,21 +487,15 @@ func (conf *Config) FromArgs(args []string, xtest bool) (rest []string, err erro
 	return
 }
 
-// CreateFromFilenames is a convenience function that parses the
-// specified *.go files and adds a package entry for them to
-// conf.CreatePkgs.
+// CreateFromFilenames is a convenience function that adds a
+// conf.CreatePkgs entry to create a package of the specified path
+// from the specified *.go files, which are parsed by Load.  The files
+// are read using Build, so they need not exist on disk if Build
+// provides a virtual file system.  I/O and parse errors are reported
+// as errors of the package.
 //
-// It fails if any file could not be loaded or parsed.
-// TODO(adonovan): make it not return an error, by making CreatePkg
-// store filenames and defer parsing until Load.
-//
-func (conf *Config) CreateFromFilenames(path string, filenames ...string) error {
-	files, errs := parseFiles(conf.fset(), conf.build(), nil, ".", filenames, conf.ParserMode)
-	if len(errs) > 0 {
-		return errs[0]
-	}
-	conf.CreateFromFiles(path, files...)
-	return nil
+func (conf *Config) CreateFromFilenames(path string, filenames ...string) {
+	conf.CreatePkgs = append(conf.CreatePkgs, CreatePkg{Path: path, Filenames: filenames})
 }
 
 // CreateFromFiles is a convenience function that adds a CreatePkgs
@@ -487,7 +508,7 @@ func (conf *Config) CreateFromFiles(path string, files ...*ast.File) {
 	if conf.Fset == nil {
 		panic("nil Fset")
 	}
-	conf.CreatePkgs = append(conf.CreatePkgs, CreatePkg{path, files})
+	conf.CreatePkgs = append(conf.CreatePkgs, CreatePkg{Path: path, Files: files})
 }
 
 // ImportWithTests is a convenience function that adds path to
@@ -497,8 +518,13 @@ func (conf *Config) CreateFromFiles(path string, files ...*ast.File) {
 // declaration.
 //
 // In addition, if any *_test.go files contain a "package x_test"
-// declaration, an additional package comprising just those files will
-// be added to CreatePkgs.
+// declaration, Load creates an additional package comprising just
+// those files, with path "x_test", and adds it to Program.Created.
+// I/O and parse errors in the test files are reported as errors of
+// these packages.
+//
+// The result is always nil: the test files are located and parsed
+// by Load.
 //
 func (conf *Config) ImportWithTests(path string) error {
 	if path == "unsafe" {
@@ -506,23 +532,9 @@ func (conf *Config) ImportWithTests(path string) error {
 	}
 	conf.Import(path)
 
-	// Load the external test package.
-	bp, err := conf.findSourcePackage(path)
-	if err != nil {
-		return err // package not found
-	}
-	xtestFiles, errs := conf.parsePackageFiles(bp, 'x')
-	if len(errs) > 0 {
-		// TODO(adonovan): fix: parse errors in x_test.go files
-		// cause FromArgs() to fail completely.
-		return errs[0] // I/O or parse error
-	}
-	if len(xtestFiles) > 0 {
-		conf.CreateFromFiles(path+"_test", xtestFiles...)
-	}
-
-	// Mark the non-xtest package for augmentation with
-	// in-package *_test.go files when we import it below.
+	// Mark the package for augmentation with its in-package
+	// *_test.go files, and for creation of its external test
+	// package, during Load.
 	conf.ImportPkgs[path] = true
 	return nil
 }
@@ -574,6 +586,68 @@ func (prog *Program) InitialPackages() []*PackageInfo {
 	return infos
 }
 
+// Imports returns the packages directly imported by the package of
+// info, in the order of Pkg.Imports: for packages loaded from source,
+// the order of their first import declarations. Packages without
+// syntax, such as those loaded from export data, may have no imports.
+//
+func (prog *Program) Imports(info *PackageInfo) []*PackageInfo {
+	var infos []*PackageInfo
+	for _, imp := range info.Pkg.Imports() {
+		if dep := prog.AllPackages[imp]; dep != nil {
+			infos = append(infos, dep)
+		}
+	}
+	return infos
+}
+
+// DependencyOrder returns a new slice containing all packages of prog
+// in an order in which each package appears after the packages it
+// imports, which is the order in which tools such as SSA builders
+// process them. The order is deterministic. Cycles, which arise only
+// through augmented packages, are broken arbitrarily.
+//
+func (prog *Program) DependencyOrder() []*PackageInfo {
+	created := make(map[*PackageInfo]bool)
+	for _, info := range prog.Created {
+		created[info] = true
+	}
+	// Importable packages have unique paths; start from them,
+	// then from the created packages, in order.
+	var roots []*PackageInfo
+	for _, info := range prog.AllPackages {
+		if !created[info] {
+			roots = append(roots, info)
+		}
+	}
+	sort.Sort(byPath(roots))
+	roots = append(roots, prog.Created...)
+
+	order := make([]*PackageInfo, 0, len(prog.AllPackages))
+	seen := make(map[*PackageInfo]bool)
+	var visit func(info *PackageInfo)
+	visit = func(info *PackageInfo) {
+		if seen[info] {
+			return
+		}
+		seen[info] = true
+		for _, dep := range prog.Imports(info) {
+			visit(dep)
+		}
+		order = append(order, info)
+	}
+	for _, info := range roots {
+		visit(info)
+	}
+	return order
+}
+
+type byPath []*PackageInfo
+
+func (p byPath) Len() int           { return len(p) }
+func (p byPath) Less(i, j int) bool { return p[i].Pkg.Path() < p[j].Pkg.Path() }
+func (p byPath) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
 // ---------- Implementation ----------
 
 // importer holds the working state of the algorithm.
@@ -690,6 +764,7 @@ func (conf *Config) Load() (*Program, error) {
 
 	// Augment the initial packages that need it.
 	// Dependencies are loaded in parallel.
+	xtestPkgs := make(map[string]*build.Package)
 	for path, augment := range conf.ImportPkgs {
 		if augment {
 			// Find and create the actual package.
@@ -698,6 +773,9 @@ func (conf *Config) Load() (*Program, error) {
 				// "Can't happen" because of previous loop.
 				return nil, err // package not found
 			}
+			if len(bp.XTestGoFiles) > 0 {
+				xtestPkgs[path] = bp
+			}
 
 			imp.importedMu.Lock()           // (unnecessary, we're sequential here)
 			info := imp.imported[path].info // must be non-nil, see above
@@ -714,17 +792,47 @@ func (conf *Config) Load() (*Program, error) {
 		}
 	}
 
-	// CreatePkgs includes all external test packages,
-	// so they must be processed after augmentation.
+	// CreatePkgs may include external test packages created by
+	// the client, so they must be processed after augmentation.
 	// Dependencies are loaded in parallel.
 	for _, create := range conf.CreatePkgs {
+		files := create.Files
+		var errs []error
+		if len(create.Filenames) > 0 {
+			var parsed []*ast.File
+			parsed, errs = parseFiles(conf.fset(), conf.build(), nil, ".", create.Filenames, conf.ParserMode)
+			files = append(files[:len(files):len(files)], parsed...)
+		}
+
 		path := create.Path
-		if create.Path == "" && len(create.Files) > 0 {
-			path = create.Files[0].Name.Name
+		if create.Path == "" && len(files) > 0 {
+			path = files[0].Name.Name
 		}
 		info := imp.newPackageInfo(path)
+		for _, err := range errs {
+			info.appendError(err)
+		}
 		// Ad-hoc packages are non-importable; no cycle check is needed.
-		imp.addFiles(info, create.Files, false)
+		imp.addFiles(info, files, false)
+		prog.Created = append(prog.Created, info)
+	}
+
+	// Create the external test packages, in order of import path.
+	// Dependencies, including the packages that import the package
+	// under test, are loaded in parallel.
+	var xtests []string
+	for path := range xtestPkgs {
+		xtests = append(xtests, path)
+	}
+	sort.Strings(xtests)
+	for _, path := range xtests {
+		files, errs := conf.parsePackageFiles(xtestPkgs[path], 'x')
+		info := imp.newPackageInfo(path + "_test")
+		for _, err := range errs {
+			info.appendError(err)
+		}
+		// External test packages are non-importable; no cycle check is needed.
+		imp.addFiles(info, files, false)
 		prog.Created = append(prog.Created, info)
 	}
 
@@ -858,7 +966,13 @@ func (conf *Config) parsePackageFiles(bp *build.Package, which rune) ([]*ast.Fil
 
 	// Preprocess CgoFiles and parse the outputs (sequentially).
 	if which == 'g' && bp.CgoFiles != nil {
-		cgofiles, err := processCgoFiles(bp, conf.fset(), conf.DisplayPath, conf.ParserMode)
+		var cgofiles []*ast.File
+		var err error
+		if conf.ProcessCgoFiles != nil {
+			cgofiles, err = conf.ProcessCgoFiles(bp, conf.fset(), conf.ParserMode)
+		} else {
+			cgofiles, err = processCgoFiles(bp, conf.fset(), conf.DisplayPath, conf.ParserMode)
+		}
 		if err != nil {
 			errs = append(errs, err)
 		} else {
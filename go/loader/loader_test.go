@@ -6,7 +6,10 @@ package loader_test
 
 import (
 	"fmt"
+	"go/ast"
 	"go/build"
+	"go/parser"
+	"go/token"
 	"sort"
 	"strings"
 	"sync"
@@ -56,8 +59,8 @@ func TestLoadFromArgs(t *testing.T) {
 	for _, info := range prog.Created {
 		pkgnames = append(pkgnames, info.Pkg.Path())
 	}
-	// All import paths may contribute tests.
-	if got, want := fmt.Sprint(pkgnames), "[fmt_test errors_test]"; got != want {
+	// All import paths may contribute tests, in order of import path.
+	if got, want := fmt.Sprint(pkgnames), "[errors_test fmt_test]"; got != want {
 		t.Errorf("Created: got %s, want %s", got, want)
 	}
 
@@ -122,6 +125,127 @@ func fakeContext(pkgs map[string]string) *build.Context {
 	return buildutil.FakeContext(pkgs2)
 }
 
+func TestCreateFromFilenames(t *testing.T) {
+	// The ad-hoc packages are read through the build context,
+	// e.g. from editor buffers; "scratch" is not imported.
+	conf := loader.Config{
+		AllowErrors: true,
+		Build: buildutil.FakeContext(map[string]map[string]string{
+			"lib": {"x.go": `package lib; const C = 1`},
+			"scratch": {
+				"a.go":   `package main; import "lib"; var x = lib.C`,
+				"b.go":   `package main; var y = x + z`,
+				"bad.go": `package bad; var = 1`,
+			},
+		}),
+		SourceImports: true,
+	}
+	f, err := conf.ParseFile("z.go", `package main; var z = 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreatePkgs = append(conf.CreatePkgs, loader.CreatePkg{
+		Files:     []*ast.File{f},
+		Filenames: []string{"/go/src/scratch/a.go", "/go/src/scratch/b.go"},
+	})
+	conf.CreateFromFilenames("bad", "/go/src/scratch/bad.go", "/go/src/scratch/missing.go")
+	var errs []error
+	conf.TypeChecker.Error = func(err error) { errs = append(errs, err) }
+
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+	if len(prog.Created) != 2 {
+		t.Fatalf("got %d created packages, want 2", len(prog.Created))
+	}
+
+	main := prog.Created[0]
+	if main.Pkg.Path() != "main" || len(main.Files) != 3 || main.Errors != nil {
+		t.Errorf("package main: got path %q, %d files, errors %v; want main, 3 files, no errors",
+			main.Pkg.Path(), len(main.Files), main.Errors)
+	}
+	if obj := main.Pkg.Scope().Lookup("y"); obj == nil || obj.Type().String() != "int" {
+		t.Errorf("package main: y = %v, want var of type int", obj)
+	}
+
+	bad := prog.Created[1]
+	if len(bad.Files) != 1 || !hasError(bad.Errors, "expected 'IDENT'") || !hasError(bad.Errors, "missing.go") {
+		t.Errorf("package bad: got %d files, errors %v; want 1 file, parse and I/O errors", len(bad.Files), bad.Errors)
+	}
+	if len(errs) != len(bad.Errors) {
+		t.Errorf("got %d reported errors, want %d", len(errs), len(bad.Errors))
+	}
+
+	// Without AllowErrors, Load fails.
+	conf.AllowErrors = false
+	conf.TypeChecker.Packages = nil
+	if _, err := conf.Load(); err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("Load: got error %v, want errors in package bad", err)
+	}
+}
+
+func TestImportWithTests(t *testing.T) {
+	// The external test of a imports b, a dependency
+	// of the tests only, which imports a.
+	conf := loader.Config{
+		AllowErrors: true,
+		Build: buildutil.FakeContext(map[string]map[string]string{
+			"a": {
+				"a.go":        `package a; const A = 1`,
+				"a_test.go":   `package a; const internal = A + 1`,
+				"a_x_test.go": `package a_test; import ("a"; "b"); const _ = a.A + b.B`,
+			},
+			"b": {"b.go": `package b; import "a"; const B = a.A`},
+			"c": {
+				"c.go":        `package c`,
+				"c_x_test.go": `package c_test; const = 1`,
+			},
+			"d": {"d.go": `package d`},
+		}),
+		SourceImports: true,
+	}
+	f, err := conf.ParseFile("main.go", `package main`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("main", f)
+	conf.ImportWithTests("c")
+	conf.ImportWithTests("a")
+	conf.ImportWithTests("d")
+	var errs []error
+	conf.TypeChecker.Error = func(err error) { errs = append(errs, err) }
+
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+
+	// CreatePkgs come first; no external test package for d.
+	var created []string
+	for _, info := range prog.Created {
+		created = append(created, info.Pkg.Path())
+	}
+	if got, want := strings.Join(created, " "), "main a_test c_test"; got != want {
+		t.Fatalf("Created = %q, want %q", got, want)
+	}
+
+	a := prog.Imported["a"]
+	if a.Pkg.Scope().Lookup("internal") == nil || len(a.Files) != 2 || a.Errors != nil {
+		t.Errorf("package a: got %d files, errors %v; want augmented package", len(a.Files), a.Errors)
+	}
+	if xtest := prog.Created[1]; xtest.Errors != nil || prog.ImportMap["b"] == nil {
+		t.Errorf("package a_test: errors %v, b loaded: %t; want no errors, b loaded",
+			xtest.Errors, prog.ImportMap["b"] != nil)
+	}
+	if xtest := prog.Created[2]; !hasError(xtest.Errors, "expected 'IDENT'") {
+		t.Errorf("package c_test: errors %v; want parse error", xtest.Errors)
+	}
+	if fmt.Sprint(errs) != fmt.Sprint(prog.Created[2].Errors) {
+		t.Errorf("got reported errors %v, want errors of c_test %v", errs, prog.Created[2].Errors)
+	}
+}
+
 func TestTransitivelyErrorFreeFlag(t *testing.T) {
 	// Create an minimal custom build.Context
 	// that fakes the following packages:
@@ -182,6 +306,62 @@ func TestTransitivelyErrorFreeFlag(t *testing.T) {
 	}
 }
 
+func TestDependencyOrder(t *testing.T) {
+	// a --> b --> c
+	//   \         /
+	//    e --> d
+	pkgs := map[string]string{
+		"a": `package a; import (_ "e"; _ "b")`,
+		"b": `package b; import _ "c"`,
+		"c": `package c; import _ "d"`,
+		"d": `package d`,
+		"e": `package e; import _ "d"`,
+	}
+	conf := loader.Config{
+		SourceImports: true,
+		Build:         fakeContext(pkgs),
+	}
+	conf.Import("a")
+	f, err := conf.ParseFile("main.go", `package main; import _ "b"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CreateFromFiles("main", f)
+
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+
+	paths := func(infos []*loader.PackageInfo) string {
+		var paths []string
+		for _, info := range infos {
+			paths = append(paths, info.Pkg.Path())
+		}
+		return strings.Join(paths, " ")
+	}
+	for _, test := range []struct{ pkg, want string }{
+		{"a", "e b"},
+		{"c", "d"},
+		{"d", ""},
+	} {
+		info := prog.Imported[test.pkg]
+		if info == nil {
+			info = prog.AllPackages[prog.ImportMap[test.pkg]]
+		}
+		if got := paths(prog.Imports(info)); got != test.want {
+			t.Errorf("Imports(%s) = %q, want %q", test.pkg, got, test.want)
+		}
+	}
+	if got, want := paths(prog.Imports(prog.Created[0])), "b"; got != want {
+		t.Errorf("Imports(main) = %q, want %q", got, want)
+	}
+
+	if got, want := paths(prog.DependencyOrder()), "d e c b a main"; got != want {
+		t.Errorf("DependencyOrder() = %q, want %q", got, want)
+	}
+}
+
 func hasError(errors []error, substr string) bool {
 	for _, err := range errors {
 		if strings.Contains(err.Error(), substr) {
@@ -337,3 +517,86 @@ func TestCycles(t *testing.T) {
 	// - Test that in a legal test cycle, none of the symbols
 	//   defined by augmentation are visible via import.
 }
+
+func TestCheckPlatforms(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"a": {
+			"a.go":         `package a; func f() int { return g() }; var _ int = false`,
+			"a_linux.go":   `package a; func g() int { return 0 }`,
+			"a_windows.go": `package a; func g() int { return "s" }`,
+			"tag.go":       "// +build foo\n\npackage a; var _ = undeclared",
+		},
+	})
+	conf := loader.Config{Build: ctxt}
+	conf.Import("a")
+
+	errs, err := conf.CheckPlatforms([]loader.Platform{
+		{GOOS: "linux", GOARCH: "amd64"},
+		{GOOS: "windows", GOARCH: "amd64"},
+		{GOOS: "linux", GOARCH: "arm", BuildTags: []string{"foo"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		`/go/src/a/a.go:1:53: cannot convert false (untyped bool constant) to int`,
+		`/go/src/a/a_windows.go:1:34: cannot convert "s" (untyped string constant) to int [windows/amd64]`,
+		`/go/src/a/tag.go:3:20: undeclared name: undeclared [linux/arm,foo]`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// the configuration is not modified
+	if conf.Build != ctxt || ctxt.GOOS != build.Default.GOOS || conf.TypeChecker.Packages != nil || conf.Fset != nil {
+		t.Errorf("CheckPlatforms modified the configuration")
+	}
+}
+
+func TestProcessCgoFiles(t *testing.T) {
+	ctxt := buildutil.FakeContext(map[string]map[string]string{
+		"a": {
+			"a.go": `package a; func f() int { return h() }`,
+			"c.go": `package a; import "C"; func h() int { return int(C.g()) }`,
+		},
+	})
+	ctxt.CgoEnabled = true
+
+	var cgoFiles []string
+	conf := loader.Config{
+		Build: ctxt,
+		// Process the cgo files without running cgo: parse them
+		// as they are and leave the references to "C" unchecked.
+		ProcessCgoFiles: func(bp *build.Package, fset *token.FileSet, mode parser.Mode) ([]*ast.File, error) {
+			var files []*ast.File
+			for _, name := range bp.CgoFiles {
+				cgoFiles = append(cgoFiles, name)
+				f, err := buildutil.ParseFile(fset, ctxt, nil, bp.Dir, name, mode)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, f)
+			}
+			return files, nil
+		},
+	}
+	conf.TypeChecker.FakeImportC = true
+	conf.Import("a")
+
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := fmt.Sprint(cgoFiles); got != "[c.go]" {
+		t.Errorf("ProcessCgoFiles called for %s; want [c.go]", got)
+	}
+	info := prog.Imported["a"]
+	if len(info.Files) != 2 || info.Pkg.Scope().Lookup("h") == nil {
+		t.Errorf("package a has %d files, scope %s; want 2 files declaring h", len(info.Files), info.Pkg.Scope())
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package loader
+
+// This file defines CheckPlatforms, which type-checks the initial
+// packages under several build configurations.
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A Platform specifies a build configuration: a target operating
+// system and architecture, plus additional build tags.
+type Platform struct {
+	GOOS, GOARCH string
+	BuildTags    []string
+}
+
+// String returns the platform in the form "goos/goarch" followed by
+// its build tags, if any, e.g. "linux/arm,netgo".
+func (p Platform) String() string {
+	s := p.GOOS + "/" + p.GOARCH
+	if len(p.BuildTags) > 0 {
+		s += "," + strings.Join(p.BuildTags, ",")
+	}
+	return s
+}
+
+// A PlatformError is an error reported by CheckPlatforms.
+type PlatformError struct {
+	Posn token.Position // error position; invalid for errors without position
+	Msg  string         // error message
+	Soft bool           // if set, error is "soft" (see types.Error)
+
+	// Platforms lists the platforms under which the error was
+	// reported, in the order they were given to CheckPlatforms.
+	// It is nil if the error was reported under all platforms.
+	Platforms []Platform
+}
+
+func (e *PlatformError) Error() string {
+	s := e.Msg
+	if e.Posn.IsValid() {
+		s = e.Posn.String() + ": " + s
+	}
+	if e.Platforms != nil {
+		var list []string
+		for _, p := range e.Platforms {
+			list = append(list, p.String())
+		}
+		s += " [" + strings.Join(list, " ") + "]"
+	}
+	return s
+}
+
+// CheckPlatforms loads the initial packages specified by conf once
+// for each of the given platforms and returns the union of the parse
+// and type errors reported, ordered by position. Errors reported under
+// all platforms (the intersection) have no Platforms list; the others
+// record the platforms under which they were reported. Thus, a file
+// that is excluded by build constraints on the host platform is
+// checked nonetheless if some platform includes it.
+//
+// Each load uses a copy of conf whose build context is conf.Build (or
+// build.Default) with GOOS, GOARCH and BuildTags set from the platform;
+// conf itself is not modified. AllowErrors is implied and the
+// TypeChecker.Error function, if any, is not called.
+//
+// An error is returned if a load fails under some platform.
+//
+func (conf *Config) CheckPlatforms(platforms []Platform) ([]*PlatformError, error) {
+	type key struct {
+		posn token.Position
+		msg  string
+	}
+	errs := make(map[key]*PlatformError)
+	count := make(map[key]int) // number of platforms reporting each error
+
+	fset := conf.Fset
+	if fset == nil {
+		fset = token.NewFileSet() // shared by all loads
+	}
+	for _, p := range platforms {
+		ctxt := *conf.build() // copy
+		ctxt.GOOS = p.GOOS
+		ctxt.GOARCH = p.GOARCH
+		ctxt.BuildTags = append(ctxt.BuildTags[:len(ctxt.BuildTags):len(ctxt.BuildTags)], p.BuildTags...)
+
+		var mu sync.Mutex // errors may be reported concurrently
+		seen := make(map[key]bool)
+		report := func(posn token.Position, msg string, soft bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			k := key{posn, msg}
+			if seen[k] {
+				return // e.g. a file parsed for the package and its tests
+			}
+			seen[k] = true
+			if errs[k] == nil {
+				errs[k] = &PlatformError{Posn: posn, Msg: msg, Soft: soft}
+			}
+			errs[k].Platforms = append(errs[k].Platforms, p)
+			count[k]++
+		}
+
+		c := *conf // copy
+		c.Fset = fset
+		c.Build = &ctxt
+		c.AllowErrors = true
+		c.TypeChecker.Packages = nil // packages differ across platforms
+		c.TypeChecker.Error = func(err error) {
+			switch err := err.(type) {
+			case types.Error:
+				report(err.Fset.Position(err.Pos), err.Msg, err.Soft)
+			case scanner.ErrorList:
+				for _, e := range err {
+					report(e.Pos, e.Msg, false)
+				}
+			case *scanner.Error:
+				report(err.Pos, err.Msg, false)
+			default:
+				report(token.Position{}, err.Error(), false)
+			}
+		}
+		if _, err := c.Load(); err != nil {
+			return nil, fmt.Errorf("%s: %v", p, err)
+		}
+	}
+
+	var list []*PlatformError
+	for k, e := range errs {
+		if count[k] == len(platforms) {
+			e.Platforms = nil // reported under all platforms
+		}
+		list = append(list, e)
+	}
+	sort.Sort(byPosn(list))
+	return list, nil
+}
+
+// byPosn orders errors by position, then message.
+type byPosn []*PlatformError
+
+func (a byPosn) Len() int      { return len(a) }
+func (a byPosn) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byPosn) Less(i, j int) bool {
+	x, y := a[i].Posn, a[j].Posn
+	if x.Filename != y.Filename {
+		return x.Filename < y.Filename
+	}
+	if x.Line != y.Line {
+		return x.Line < y.Line
+	}
+	if x.Column != y.Column {
+		return x.Column < y.Column
+	}
+	return a[i].Msg < a[j].Msg
+}
@@ -66,7 +66,7 @@ func TestStdlib(t *testing.T) {
 			scope := pkg.Scope()
 			for _, name := range scope.Names() {
 				if ast.IsExported(name) {
-					fmt.Printf("\t%s\n", types.ObjectString(pkg, scope.Lookup(name)))
+					fmt.Printf("\t%s\n", types.ObjectString(scope.Lookup(name), types.RelativeTo(pkg)))
 				}
 			}
 			fmt.Println()
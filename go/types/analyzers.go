@@ -0,0 +1,47 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements support for Config.Analyzers.
+
+package types
+
+import (
+	"go/token"
+	"sort"
+)
+
+// A diagnostic is a message reported by an Analyzer.
+type diagnostic struct {
+	pos token.Pos
+	msg string
+}
+
+// runAnalyzers invokes the analyzers specified by the configuration
+// and reports the collected diagnostics as soft errors, in source order.
+func (check *Checker) runAnalyzers() {
+	if len(check.conf.Analyzers) == 0 {
+		return
+	}
+
+	var list []diagnostic
+	report := func(pos token.Pos, msg string) {
+		list = append(list, diagnostic{pos, msg})
+	}
+	for _, f := range check.conf.Analyzers {
+		f(check.pkg, check.Info, check.files, report)
+	}
+
+	// diagnostics at the same position retain the order of reporting
+	sort.Stable(byPos(list))
+	for _, d := range list {
+		check.err(d.pos, AnalyzerDiagnostic, d.msg, true)
+	}
+}
+
+// byPos implements the sort.Sort interface.
+type byPos []diagnostic
+
+func (a byPos) Len() int           { return len(a) }
+func (a byPos) Less(i, j int) bool { return a[i].pos < a[j].pos }
+func (a byPos) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -0,0 +1,94 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestAnalyzers(t *testing.T) {
+	const src = `package p
+
+type Xfoo int
+
+func forbidden() {}
+
+func f() {
+	forbidden()
+}
+
+type Xbar struct{}
+
+func g() { forbidden() }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// naming reports type names starting with X
+	naming := func(pkg *Package, info *Info, files []*ast.File, report func(token.Pos, string)) {
+		for id, obj := range info.Defs {
+			if _, ok := obj.(*TypeName); ok && strings.HasPrefix(id.Name, "X") {
+				report(id.Pos(), "bad type name "+id.Name)
+			}
+		}
+	}
+
+	// calls reports uses of function forbidden
+	calls := func(pkg *Package, info *Info, files []*ast.File, report func(token.Pos, string)) {
+		for _, file := range files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					if id, ok := call.Fun.(*ast.Ident); ok && info.Uses[id] == pkg.Scope().Lookup("forbidden") {
+						report(call.Pos(), "call of forbidden")
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	var got []string
+	conf := Config{
+		Error: func(err error) {
+			e := err.(Error)
+			if !e.Soft {
+				t.Errorf("%s: not a soft error", e)
+			}
+			got = append(got, e.Error())
+		},
+		Analyzers: []Analyzer{naming, calls},
+	}
+	info := Info{Defs: make(map[*ast.Ident]Object), Uses: make(map[*ast.Ident]Object)}
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, &info); err == nil {
+		t.Fatal("no error reported")
+	}
+
+	want := []string{
+		"p.go:3:6: bad type name Xfoo",
+		"p.go:8:2: call of forbidden",
+		"p.go:11:6: bad type name Xbar",
+		"p.go:13:12: call of forbidden",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	// without an error handler, only the first diagnostic is returned
+	conf.Error = nil
+	_, err = conf.Check(f.Name.Name, fset, []*ast.File{f}, &info)
+	if err == nil || err.Error() != want[0] {
+		t.Errorf("got error %v; want %s", err, want[0])
+	}
+}
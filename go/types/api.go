@@ -24,6 +24,7 @@ package types // import "golang.org/x/tools/go/types"
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/token"
@@ -49,12 +50,14 @@ func Check(path string, fset *token.FileSet, files []*ast.File) (*Package, error
 // An Error describes a type-checking error; it implements the error interface.
 // A "soft" error is an error that still permits a valid interpretation of a
 // package (such as "unused variable"); "hard" errors may lead to unpredictable
-// behavior if ignored.
+// behavior if ignored. The Code classifies the error independently of the
+// wording of its message.
 type Error struct {
 	Fset *token.FileSet // file set for interpretation of Pos
 	Pos  token.Pos      // error position
 	Msg  string         // error message
 	Soft bool           // if set, error is "soft"
+	Code ErrorCode      // kind of error; never UnknownError
 }
 
 // Error returns an error string formatted as follows:
@@ -63,6 +66,10 @@ func (err Error) Error() string {
 	return fmt.Sprintf("%s: %s", err.Fset.Position(err.Pos), err.Msg)
 }
 
+// ErrCanceled is the error returned when type-checking is abandoned
+// because Config.Cancel was closed.
+var ErrCanceled = errors.New("type-checking canceled")
+
 // An importer resolves import paths to Packages.
 // The imports map records packages already known,
 // indexed by package path. The type-checker
@@ -76,16 +83,74 @@ func (err Error) Error() string {
 // TODO(gri) Need to be clearer about requirements of completeness.
 type Importer func(map[string]*Package, string) (*Package, error)
 
+// An ImportMode controls the behavior of ImportFrom.
+// No modes are defined yet; the mode must be 0.
+type ImportMode int
+
+// An ImporterFrom resolves import paths to packages like an Importer
+// but also takes into account the directory of the importing package,
+// which is needed for vendor directories and other schemes where the
+// same import path denotes different packages in different places.
+type ImporterFrom interface {
+	// ImportFrom returns the package with the given import path as
+	// imported by a package in directory dir. The path of the returned
+	// package is its canonical path, which may differ from the import
+	// path: for instance, a vendored package "x" imported from "a"
+	// may have the path "a/vendor/x". An ImporterFrom must return the
+	// same package for the same canonical path.
+	ImportFrom(path, dir string, mode ImportMode) (*Package, error)
+}
+
 // A Config specifies the configuration for type checking.
 // The zero value for Config is a ready-to-use default configuration.
 type Config struct {
-	// If IgnoreFuncBodies is set, function bodies are not
-	// type-checked.
+	// If IgnoreFuncBodies is set, function bodies, including those
+	// of function literals, are not type-checked. Package-level
+	// declarations are checked as usual, but unused imports are not
+	// reported and the Info maps have no entries for the bodies.
 	IgnoreFuncBodies bool
 
+	// If ReleaseFuncBodies is set, the bodies of function declarations
+	// are released once the package files are type-checked and any
+	// Analyzers have run: the checker sets the Body field of each
+	// ast.FuncDecl to nil and drops its own references into the bodies.
+	// This permits long-running clients to keep type information for
+	// many packages without keeping their syntax trees; syntax nodes
+	// recorded in Info maps remain reachable, so such clients should
+	// request only the Info maps they need. Clients that process the
+	// function bodies after type-checking must not set this flag.
+	ReleaseFuncBodies bool
+
+	// If FuncBodyWorkers > 1, function bodies are checked concurrently
+	// by up to FuncBodyWorkers goroutines once the package-level
+	// declarations are checked. The results are the same as for
+	// sequential checking, and errors are reported in the same order,
+	// except that invalid map key types in function bodies may be
+	// reported earlier. The Error function and the other Config hooks
+	// are not called concurrently.
+	FuncBodyWorkers int
+
+	// If Context is set, the types of the package share their
+	// representation with identical types of other packages checked
+	// with the same Context (see Context).
+	Context *Context
+
+	// If Cancel is non-nil, closing it abandons type-checking: the
+	// checker polls Cancel before each file's imports, each package-level
+	// declaration, and each function body, and returns ErrCanceled if it
+	// is closed. The package, and the Info maps, are then incomplete.
+	Cancel <-chan struct{}
+
+	// If Stats != nil, the checker adds its statistics to it.
+	Stats *Stats
+
 	// If FakeImportC is set, `import "C"` (for packages requiring Cgo)
 	// declares an empty "C" package and errors are omitted for qualified
 	// identifiers referring to package C (which won't find an object).
+	// All imports of "C" in a package denote the same fake package; it
+	// is not listed by Package.Imports. The rest of the package is
+	// type-checked as usual, and the identifiers not involving package
+	// C are recorded in Info.
 	// This feature is intended for the standard library cmd/api tool.
 	//
 	// Caution: Effects may be unpredictable due to follow-up errors.
@@ -104,21 +169,91 @@ type Config struct {
 	// error strings that start with a '\t' character.
 	// If Error == nil, type-checking stops with the first
 	// error found.
+	//
+	// For a given set of package files and configuration, errors
+	// are reported in the same order in every run; in particular,
+	// the order does not depend on the iteration order of maps.
+	//
+	// If the checker detects an internal inconsistency, typically
+	// because an imported package is malformed, it stops and reports
+	// an error with code InternalError rather than panicking.
 	Error func(err error)
 
+	// If Declared != nil, it is called for each package-level object
+	// as soon as its declaration has been type-checked, in the order
+	// in which the declarations are checked, with the number of errors
+	// reported so far. This permits clients to process objects before
+	// the entire package is checked. The types of the objects are valid
+	// when Declared is called, but the method sets of named types may be
+	// incomplete, and function bodies are checked only after the last
+	// call of Declared.
+	Declared func(obj Object, nerrors int)
+
 	// If Import != nil, it is called for each imported package.
 	// Otherwise, DefaultImport is called.
 	Import Importer
 
+	// If ImporterFrom != nil, it is used instead of Import and
+	// DefaultImport: ImportFrom is called for each imported package
+	// with the directory of the importing file, as recorded in the
+	// file set, and mode 0. The packages returned are added to
+	// Packages under their canonical paths if not already present.
+	ImporterFrom ImporterFrom
+
 	// If Sizes != nil, it provides the sizing functions for package unsafe.
 	// Otherwise &StdSizes{WordSize: 8, MaxAlign: 8} is used instead.
+	// SizesFor returns the sizes used by a particular compiler and
+	// architecture.
+	//
+	// The sizes of int, uint, and uintptr also determine which constants
+	// are representable by values of these types, including untyped
+	// integer constants converted to their default type int; overflows
+	// are reported accordingly, independently of the host platform.
 	Sizes Sizes
 
 	// If DisableUnusedImportCheck is set, packages are not checked
 	// for unused imports.
 	DisableUnusedImportCheck bool
+
+	// MaxDepth limits the nesting depth of expressions and type
+	// expressions. If it is exceeded, an "implementation limit
+	// exceeded" error is reported and type-checking stops. If
+	// MaxDepth <= 0, a default limit is used which is high
+	// enough to never be exceeded by real code.
+	MaxDepth int
+
+	// MaxConstBits limits the size (in bits) of the representation
+	// of numeric constant values. Constant expressions exceeding it
+	// are reported as errors. If MaxConstBits <= 0, a default limit
+	// is used which is high enough to never be exceeded by real code.
+	MaxConstBits int
+
+	// If MaxErrors > 0 and Error != nil, type-checking stops
+	// after MaxErrors errors have been reported. By default,
+	// the number of errors reported to Error is not limited;
+	// without an Error function, type-checking stops with the
+	// first error in any case.
+	MaxErrors int
+
+	// If CheckStructTags is set, struct field tags are checked for
+	// conformance to the reflect.StructTag convention, which is a
+	// space-separated list of key:"value" pairs. Malformed tags are
+	// reported as soft errors.
+	CheckStructTags bool
+
+	// Analyzers are invoked, in order, after the package has been
+	// type-checked without errors, or if Error != nil (in which case
+	// type-checking continues after errors). The diagnostics they
+	// report are sorted by position and reported as soft errors.
+	Analyzers []Analyzer
 }
 
+// An Analyzer is a user-defined check invoked by the type-checker once
+// type-checking of a package is complete. It is called with the package,
+// the Info provided to the type-checker, and the package files; it calls
+// report for each diagnostic it finds.
+type Analyzer func(pkg *Package, info *Info, files []*ast.File, report func(pos token.Pos, msg string))
+
 // DefaultImport is the default importer invoked if Config.Import == nil.
 // The declaration:
 //
@@ -169,9 +304,11 @@ type Info struct {
 	//
 	//	node               declared object
 	//
-	//	*ast.ImportSpec    *PkgName for dot-imports and imports without renames
+	//	*ast.ImportSpec    *PkgName for imports without renames
 	//	*ast.CaseClause    type-specific *Var for each type switch case clause (incl. default)
-	//      *ast.Field         anonymous struct field or parameter *Var
+	//	*ast.Field         anonymous parameter *Var
+	//
+	// The *PkgName of a dot-import is recorded in Defs, for the "." identifier.
 	//
 	Implicits map[ast.Node]Object
 
@@ -179,6 +316,26 @@ type Info struct {
 	// to their corresponding selections.
 	Selections map[*ast.SelectorExpr]*Selection
 
+	// Docs maps declared objects to the doc comments of their
+	// declarations. For constants, variables, and types declared
+	// in a group, the doc comment of the respective spec is used,
+	// or the doc comment of the group if the spec has none. Struct
+	// fields and interface methods map to the doc comment of their
+	// field, and functions and methods to the doc comment of their
+	// function declaration. Objects without doc comment are omitted.
+	Docs map[Object]*ast.CommentGroup
+
+	// Conversions maps conversion expressions T(x) to the kind of
+	// the conversion, which identifies the rule of the spec that
+	// permits the conversion. Invalid conversions are omitted.
+	Conversions map[*ast.CallExpr]ConversionKind
+
+	// Iotas maps the specs of constant declarations, including those
+	// without initialization expressions, to the value of iota in
+	// effect for them, that is, their index in the list of specs of
+	// their declaration.
+	Iotas map[*ast.ValueSpec]int
+
 	// Scopes maps ast.Nodes to the scopes they define. Package scopes are not
 	// associated with a specific node but with all files belonging to a package.
 	// Thus, the package scope can be found in the type-checked Package object.
@@ -204,6 +361,11 @@ type Info struct {
 	//
 	Scopes map[ast.Node]*Scope
 
+	// StructTags maps struct fields to the key:"value" pairs of their
+	// tags, in source order; it is only populated if Config.CheckStructTags
+	// is set. Fields without tags or with malformed tags are omitted.
+	StructTags map[*Var][]TagPair
+
 	// InitOrder is the list of package-level initializers in the order in which
 	// they must be executed. Initializers referring to variables related by an
 	// initialization dependency appear in topological order, the others appear
@@ -341,6 +503,8 @@ func (conf *Config) Check(path string, fset *token.FileSet, files []*ast.File, i
 }
 
 // AssertableTo reports whether a value of type V can be asserted to have type T.
+// If T is not an interface, the assertion is only possible if T implements V;
+// otherwise the assertion x.(T) for an x of type V always fails at run time.
 func AssertableTo(V *Interface, T Type) bool {
 	m, _ := assertableTo(V, T)
 	return m == nil
@@ -359,6 +523,7 @@ func ConvertibleTo(V, T Type) bool {
 }
 
 // Implements reports whether type V implements interface T.
+// MissingMethod may be used to determine why V doesn't implement T.
 func Implements(V Type, T *Interface) bool {
 	f, _ := MissingMethod(V, T, true)
 	return f == nil
@@ -10,6 +10,7 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"sort"
 	"strings"
 	"testing"
 
@@ -28,6 +29,22 @@ func pkgFor(path, source string, info *Info) (*Package, error) {
 	return conf.Check(f.Name.Name, fset, []*ast.File{f}, info)
 }
 
+// typesFor type-checks source and returns a function that returns
+// the type of the package-level object with the given name.
+func typesFor(t *testing.T, source string) func(name string) Type {
+	pkg, err := pkgFor("p", source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(name string) Type {
+		obj := pkg.Scope().Lookup(name)
+		if obj == nil {
+			t.Fatalf("%s not declared", name)
+		}
+		return obj.Type()
+	}
+}
+
 func mustTypecheck(t *testing.T, path, source string, info *Info) string {
 	pkg, err := pkgFor(path, source, info)
 	if err != nil {
@@ -367,6 +384,70 @@ func TestPredicatesInfo(t *testing.T) {
 	}
 }
 
+func TestDefsUsesInfo(t *testing.T) {
+	const src = `package p
+type T struct{ f int; E }
+type E struct{}
+func (E) m() {}
+var x T
+func g(a int) int {
+	b := a + x.f
+	x.m()
+	return b
+}
+`
+	info := Info{
+		Defs: make(map[*ast.Ident]Object),
+		Uses: make(map[*ast.Ident]Object),
+	}
+	mustTypecheck(t, "DefsUsesInfo", src, &info)
+
+	str := func(m map[*ast.Ident]Object) string {
+		var list []string
+		for id, obj := range m {
+			s := id.Name + " -> <nil>"
+			if obj != nil {
+				s = id.Name + " -> " + obj.String()
+			}
+			list = append(list, s)
+		}
+		sort.Strings(list)
+		return strings.Join(list, "\n")
+	}
+
+	// the package name is recorded with a nil object, and the
+	// anonymous field E defines a field (and uses the type E)
+	wantDefs := `E -> field E p.E
+E -> type p.E struct{}
+T -> type p.T struct{f int; p.E}
+a -> var a int
+b -> var b int
+f -> field f int
+g -> func p.g(a int) int
+m -> func (p.E).m()
+p -> <nil>
+x -> var p.x p.T`
+	if got := str(info.Defs); got != wantDefs {
+		t.Errorf("got Defs\n%s\nwant\n%s", got, wantDefs)
+	}
+
+	wantUses := `E -> type p.E struct{}
+E -> type p.E struct{}
+T -> type p.T struct{f int; p.E}
+a -> var a int
+b -> var b int
+f -> field f int
+int -> type int int
+int -> type int int
+int -> type int int
+m -> func (p.E).m()
+x -> var p.x p.T
+x -> var p.x p.T`
+	if got := str(info.Uses); got != wantUses {
+		t.Errorf("got Uses\n%s\nwant\n%s", got, wantUses)
+	}
+}
+
 func TestScopesInfo(t *testing.T) {
 	var tests = []struct {
 		src    string
@@ -534,6 +615,14 @@ func TestInitOrderInfo(t *testing.T) {
 		{`package p12; var (a = x; b = 0; x, y = m[0]; m map[int]int)`, []string{
 			"b = 0", "x, y = m[0]", "a = x",
 		}},
+		// n:1 initializations depending on each other via function bodies
+		{`package p13; var (x, y = f(); a, b = g()); func f() (int, int) { return a, b }; func g() (int, int) { return 1, 2 }`, []string{
+			"a, b = g()", "x, y = f()",
+		}},
+		// dependencies via method expressions on pointer receivers
+		{`package p14; type T int; func (*T) m() int { return z }; var (v = (*T).m; z = 0)`, []string{
+			"z = 0", "v = (*T).m",
+		}},
 		// test case from spec section on package initialization
 		{`package p12
 
@@ -710,6 +799,14 @@ type C struct {
 func (C) g()
 func (*C) h()
 
+type D struct {
+	A
+}
+
+type I interface {
+	m()
+}
+
 func main() {
 	// qualified identifiers
 	var _ lib.T
@@ -750,6 +847,15 @@ func main() {
         _ = (*A).f
         _ = B.f
         _ = (*B).f
+
+	// promoted through several embedding levels
+	_ = D{}.C
+	_ = D{}.c
+	_ = D{}.g
+
+	// interface methods
+	_ = I(nil).m
+	_ = I.m
 }`
 
 	wantOut := map[string][2]string{
@@ -779,6 +885,13 @@ func main() {
 		"(*A).f": {"method expr (*main.A) f(*main.A, int)", "->[0 0]"},
 		"B.f":    {"method expr (main.B) f(main.B, int)", ".[0]"},
 		"(*B).f": {"method expr (*main.B) f(*main.B, int)", "->[0]"},
+
+		"D{}.C": {"field (main.D) C main.C", ".[0 1]"},
+		"D{}.c": {"field (main.D) c int", ".[0 1 0]"},
+		"D{}.g": {"method (main.D) g()", ".[0 1 0]"},
+
+		"I(nil).m": {"method (main.I) m()", ".[0]"},
+		"I.m":      {"method expr (main.I) m(main.I)", ".[0]"},
 	}
 
 	makePkg("lib", libSrc)
@@ -882,6 +995,14 @@ func TestLookupFieldOrMethod(t *testing.T) {
 		{"var a T; type T struct{}; func (*T) f() {}", true, []int{0}, false},
 		{"var a *T; type T struct{}; func (*T) f() {}", true, []int{0}, true}, // TODO(gri) should this report indirect = false?
 
+		// embedding paths
+		{"var x T; type ( E struct{ a, f int }; T struct{ b int; E } )", true, []int{1, 1}, false},
+		{"var x T; type ( E struct{ a, f int }; T struct{ b int; *E } )", true, []int{1, 1}, true},
+		{"var x T; type ( E1 struct{ f int }; E2 struct{ E1 }; T struct{ b int; E2 } )", true, []int{1, 0, 0}, false},
+		{"var x T; type ( E struct{}; T struct{ b int; E } ); func (E) g() {}; func (E) f() {}", true, []int{1, 1}, false},
+		{"var x T; type ( E struct{}; T struct{ b int; *E } ); func (*E) f() {}", true, []int{1, 0}, true},
+		{"var x T; type ( E1 struct{ f int }; E2 struct{ E1 }; T struct{ E1; E2 } )", true, []int{0, 0}, false}, // shallowest depth wins
+
 		// collisions
 		{"type ( E1 struct{ f int }; E2 struct{ f int }; x struct{ E1; *E2 })", false, []int{1, 0}, false},
 		{"type ( E1 struct{ f int }; E2 struct{}; x struct{ E1; *E2 }); func (E2) f() {}", false, []int{1, 0}, false},
@@ -934,3 +1055,440 @@ func sameSlice(a, b []int) bool {
 	}
 	return true
 }
+
+func TestConversionsInfo(t *testing.T) {
+	var tests = []struct {
+		src  string
+		conv string // conversion expression
+		kind ConversionKind
+	}{
+		{`package c0; const _ = int8(1)`, `int8(1)`, ConstConversion},
+		{`package c1; const _ = string(65)`, `string(65)`, IntToStringConversion},
+		{`package a0; var x int; var _ = interface{}(x)`, `interface{}(x)`, AssignableConversion},
+		{`package u0; type T int; var x int; var _ = T(x)`, `T(x)`, UnderlyingConversion},
+		{`package p0; type T int; var x *int; var _ = (*T)(x)`, `(*T)(x)`, PointerConversion},
+		{`package n0; var x int64; var _ = int8(x)`, `int8(x)`, NumericConversion},
+		{`package n1; var x float64; var _ = int(x)`, `int(x)`, NumericConversion},
+		{`package x0; var x complex64; var _ = complex128(x)`, `complex128(x)`, ComplexConversion},
+		{`package s0; var x rune; var _ = string(x)`, `string(x)`, IntToStringConversion},
+		{`package s1; var x []byte; var _ = string(x)`, `string(x)`, SliceToStringConversion},
+		{`package s2; var x []rune; var _ = string(x)`, `string(x)`, SliceToStringConversion},
+		{`package s3; var x string; var _ = []byte(x)`, `[]byte(x)`, StringToSliceConversion},
+		{`package s4; var _ = []rune("foo")`, `[]rune("foo")`, StringToSliceConversion},
+		{`package z0; import "unsafe"; var x *int; var _ = unsafe.Pointer(x)`, `unsafe.Pointer(x)`, UnsafePointerConversion},
+		{`package z1; import "unsafe"; var x unsafe.Pointer; var _ = uintptr(x)`, `uintptr(x)`, UnsafePointerConversion},
+	}
+
+	for _, test := range tests {
+		info := Info{
+			Conversions: make(map[*ast.CallExpr]ConversionKind),
+		}
+		name := mustTypecheck(t, "ConversionsInfo", test.src, &info)
+
+		// look for conversion
+		var kind ConversionKind
+		found := false
+		for call, k := range info.Conversions {
+			if ExprString(call) == test.conv {
+				kind = k
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("package %s: no conversion found for %s", name, test.conv)
+			continue
+		}
+
+		// check that kind is correct
+		if kind != test.kind {
+			t.Errorf("package %s: got conversion kind %d for %s; want %d", name, kind, test.conv, test.kind)
+		}
+	}
+}
+
+func TestIotasInfo(t *testing.T) {
+	var tests = []struct {
+		src   string
+		iotas string // iota for the names declared by each const spec
+	}{
+		{`package i0; const a = iota`, `a:0`},
+		{`package i1; const (a = iota; b; c)`, `a:0 b:1 c:2`},
+		{`package i2; const (a, b = iota, iota; _, _; c = 1 << iota; d)`, `_,_:1 a,b:0 c:2 d:3`},
+		{`package i3; var x int; const (a = 1; b); type T int`, `a:0 b:1`},
+		{`package i4; func f() { const (a = iota + 1; b); _, _ = a, b }`, `a:0 b:1`},
+		{`package i5; func f() { const _ = 42 }; const (a = iota; b)`, `_:0 a:0 b:1`},
+	}
+
+	for _, test := range tests {
+		info := Info{
+			Iotas: make(map[*ast.ValueSpec]int),
+		}
+		name := mustTypecheck(t, "IotasInfo", test.src, &info)
+
+		var specs []string
+		for spec, iota := range info.Iotas {
+			var names []string
+			for _, name := range spec.Names {
+				names = append(names, name.Name)
+			}
+			specs = append(specs, fmt.Sprintf("%s:%d", strings.Join(names, ","), iota))
+		}
+		sort.Strings(specs)
+		if got := strings.Join(specs, " "); got != test.iotas {
+			t.Errorf("package %s: got %s; want %s", name, got, test.iotas)
+		}
+	}
+}
+
+func TestDocsInfo(t *testing.T) {
+	const src = `package p
+
+// Group doc.
+const (
+	// A doc.
+	A = iota
+	B // not a doc comment
+)
+
+// C doc.
+const C = 0
+
+// Vars doc.
+var x, y int
+
+type (
+	// T doc.
+	T struct {
+		// f doc.
+		f int
+		g, h string // not a doc comment
+
+		// Embedded doc.
+		Embedded
+	}
+
+	Embedded struct{}
+)
+
+// I doc.
+type I interface {
+	// m doc.
+	m()
+	n()
+}
+
+// f doc.
+func f() {
+	// local doc.
+	const local = 0
+	_ = local
+}
+
+// T.m doc.
+func (T) m() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Docs: make(map[Object]*ast.CommentGroup)}
+	var conf Config
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	// collect doc comments by object line and name
+	var got []string
+	for obj, doc := range info.Docs {
+		got = append(got, fmt.Sprintf("%d %s: %s", fset.Position(obj.Pos()).Line, obj.Name(), strings.TrimSpace(doc.Text())))
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"11 C: C doc.",
+		"14 x: Vars doc.",
+		"14 y: Vars doc.",
+		"18 T: T doc.",
+		"20 f: f doc.",
+		"24 Embedded: Embedded doc.",
+		"31 I: I doc.",
+		"33 m: m doc.",
+		"38 f: f doc.",
+		"40 local: local doc.",
+		"45 m: T.m doc.",
+		"6 A: A doc.",
+		"7 B: Group doc.",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got docs\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAssignableTo(t *testing.T) {
+	const src = `package p
+type (
+	MyInt   int
+	IntPtr  *int
+	IntChan chan int
+	Stringer interface{ String() string }
+	T struct{}
+)
+func (T) String() string { return "" }
+`
+	lookup := typesFor(t, src)
+
+	for _, test := range []struct {
+		V, T Type
+		want bool
+	}{
+		{Typ[Int], Typ[Int], true},
+		{Typ[Int], Typ[Int64], false},
+		{Typ[Int], lookup("MyInt"), false},             // both named, different
+		{lookup("MyInt"), Typ[Int], false},             // both named, different
+		{NewPointer(Typ[Int]), lookup("IntPtr"), true}, // identical underlying, one unnamed
+		{lookup("IntPtr"), NewPointer(Typ[Int]), true}, // identical underlying, one unnamed
+		{NewChan(SendRecv, Typ[Int]), NewChan(RecvOnly, Typ[Int]), true},
+		{NewChan(SendRecv, Typ[Int]), lookup("IntChan"), true},
+		{NewChan(RecvOnly, Typ[Int]), NewChan(SendRecv, Typ[Int]), false},
+		{lookup("T"), lookup("Stringer"), true}, // implements interface
+		{NewPointer(lookup("T")), lookup("Stringer"), true},
+		{Typ[Int], lookup("Stringer"), false},
+		{Typ[UntypedNil], NewSlice(Typ[Int]), true},
+		{Typ[UntypedNil], Typ[Int], false},
+		{Typ[UntypedNil], lookup("Stringer"), true},
+	} {
+		if got := AssignableTo(test.V, test.T); got != test.want {
+			t.Errorf("AssignableTo(%s, %s) = %v; want %v", test.V, test.T, got, test.want)
+		}
+	}
+}
+
+func TestConvertibleTo(t *testing.T) {
+	const src = `package p
+type (
+	MyString string
+	Bytes    []byte
+	S1       struct{ x int }
+	S2       struct{ x int }
+	S3       struct{ y int }
+)
+`
+	lookup := typesFor(t, src)
+
+	for _, test := range []struct {
+		V, T Type
+		want bool
+	}{
+		// numeric types
+		{Typ[Int], Typ[Float64], true},
+		{Typ[Float32], Typ[Uint8], true},
+		{Typ[Complex64], Typ[Complex128], true},
+		{Typ[Complex64], Typ[Float64], false},
+
+		// strings, byte and rune slices
+		{Typ[Int], Typ[String], true},
+		{Typ[Float64], Typ[String], false},
+		{Typ[String], NewSlice(Typ[Byte]), true},
+		{Typ[String], lookup("Bytes"), true},
+		{lookup("Bytes"), lookup("MyString"), true},
+		{NewSlice(UniverseRune), Typ[String], true},
+		{NewSlice(Typ[Int]), Typ[String], false},
+		{Typ[String], Typ[Int], false},
+
+		// identical underlying types and pointer base types
+		{lookup("S1"), lookup("S2"), true},
+		{lookup("S1"), lookup("S3"), false},
+		{NewPointer(lookup("S1")), NewPointer(lookup("S2")), true},
+		{NewPointer(lookup("S1")), NewPointer(lookup("S3")), false},
+
+		// unsafe.Pointer
+		{Typ[UnsafePointer], Typ[Uintptr], true},
+		{Typ[Uintptr], Typ[UnsafePointer], true},
+		{NewPointer(Typ[Int]), Typ[UnsafePointer], true},
+		{Typ[UnsafePointer], NewPointer(Typ[Float64]), true},
+		{Typ[Int], Typ[UnsafePointer], false},
+	} {
+		if got := ConvertibleTo(test.V, test.T); got != test.want {
+			t.Errorf("ConvertibleTo(%s, %s) = %v; want %v", test.V, test.T, got, test.want)
+		}
+	}
+}
+
+func TestImplements(t *testing.T) {
+	const src = `package p
+type I interface {
+	m()
+	n(int) string
+}
+type J interface {
+	I
+	o()
+}
+type T1 struct{}
+func (T1) m() {}
+func (T1) n(int) string { return "" }
+type T2 struct{}
+func (*T2) m() {}
+func (*T2) n(int) string { return "" }
+type T3 struct{}
+func (T3) m() {}
+type T4 struct{}
+func (T4) m() {}
+func (T4) n(string) string { return "" }
+`
+	lookup := typesFor(t, src)
+	iface := lookup("I").Underlying().(*Interface)
+
+	for _, test := range []struct {
+		V         Type
+		method    string // missing method, if any
+		wrongType bool
+	}{
+		{lookup("T1"), "", false},
+		{NewPointer(lookup("T1")), "", false},
+		{lookup("T2"), "m", false}, // methods have pointer receivers
+		{NewPointer(lookup("T2")), "", false},
+		{lookup("T3"), "n", false},
+		{lookup("T4"), "n", true},
+		{lookup("I"), "", false},
+		{lookup("J"), "", false},
+		{NewInterface(nil, nil).Complete(), "m", false},
+	} {
+		m, wrongType := MissingMethod(test.V, iface, true)
+		var name string
+		if m != nil {
+			name = m.Name()
+		}
+		if name != test.method || wrongType != test.wrongType {
+			t.Errorf("MissingMethod(%s, I) = %q, %v; want %q, %v", test.V, name, wrongType, test.method, test.wrongType)
+		}
+		if got := Implements(test.V, iface); got != (m == nil) {
+			t.Errorf("Implements(%s, I) = %v; want %v", test.V, got, m == nil)
+		}
+	}
+}
+
+func TestAssertableTo(t *testing.T) {
+	const src = `package p
+type I interface {
+	m()
+	n(int) string
+}
+type J interface {
+	n(string) string
+}
+type T1 struct{}
+func (T1) m() {}
+func (T1) n(int) string { return "" }
+type T2 struct{}
+func (*T2) m() {}
+func (*T2) n(int) string { return "" }
+type T3 struct{}
+func (T3) m() {}
+type T4 struct{}
+func (T4) m() {}
+func (T4) n(string) string { return "" }
+`
+	lookup := typesFor(t, src)
+	iface := lookup("I").Underlying().(*Interface)
+
+	for _, test := range []struct {
+		T    Type
+		want bool
+	}{
+		{lookup("T1"), true},
+		{NewPointer(lookup("T1")), true},
+		{lookup("T2"), false}, // methods have pointer receivers
+		{NewPointer(lookup("T2")), true},
+		{lookup("T3"), false},
+		{lookup("T4"), false},
+		{Typ[Int], false},
+		// x.(T) is permitted for interface types T
+		{lookup("I"), true},
+		{lookup("J"), true},
+		{NewInterface(nil, nil).Complete(), true},
+	} {
+		if got := AssertableTo(iface, test.T); got != test.want {
+			t.Errorf("AssertableTo(I, %s) = %v; want %v", test.T, got, test.want)
+		}
+	}
+}
+
+func TestImplicitsInfo(t *testing.T) {
+	var tests = []struct {
+		src  string
+		want string
+	}{
+		{`package p0; import "unsafe"; var _ = unsafe.Sizeof(0)`, "importSpec: package unsafe"},
+		{`package p1; import u "unsafe"; var _ = u.Sizeof(0)`, ""}, // renamed import: in Defs
+		{`package p2; import . "unsafe"; var _ = Sizeof(0)`, ""},   // dot-import: in Defs
+
+		// implicit objects are recorded per case clause; there are none here
+		{`package p3; func f(x interface{}) { switch x.(type) {} }`, ""},
+		{`package p4; func f(x interface{}) { switch t := x.(type) { case int: _ = t } }`, "caseClause: var t int"},
+		{`package p5; func f(x interface{}) { switch t := x.(type) { case int, uint: _ = t } }`, "caseClause: var t interface{}"},
+		{`package p6; func f(x interface{}) { switch t := x.(type) { default: _ = t } }`, "caseClause: var t interface{}"},
+
+		{`package p7; func f(int) {}`, "field: var  int"},
+		{`package p8; func f() (int) { return 0 }`, "field: var  int"},
+		{`package p9; var _ = func(int) {}`, "field: var  int"},
+	}
+
+	for _, test := range tests {
+		info := Info{
+			Implicits: make(map[ast.Node]Object),
+		}
+		name := mustTypecheck(t, "ImplicitsInfo", test.src, &info)
+
+		// the test cases expect at most one Implicits entry
+		if len(info.Implicits) > 1 {
+			t.Errorf("package %s: %d Implicits entries found", name, len(info.Implicits))
+			continue
+		}
+
+		// extract Implicits entry, if any
+		var got string
+		for n, obj := range info.Implicits {
+			switch x := n.(type) {
+			case *ast.ImportSpec:
+				got = "importSpec"
+			case *ast.CaseClause:
+				got = "caseClause"
+			case *ast.Field:
+				got = "field"
+			default:
+				t.Fatalf("package %s: unexpected %T", name, x)
+			}
+			got += ": " + obj.String()
+		}
+
+		// verify entry
+		if got != test.want {
+			t.Errorf("package %s: got %q; want %q", name, got, test.want)
+		}
+	}
+}
+
+func TestFuncLitScopes(t *testing.T) {
+	// function literals of identical types have distinct scopes
+	const src = `package p
+
+func a() { x := 1; _ = func() int { return x }() }
+func b() { y := 2; _ = func() int { return y }() }
+`
+	info := Info{Scopes: make(map[ast.Node]*Scope)}
+	if _, err := pkgFor("p", src, &info); err != nil {
+		t.Fatal(err)
+	}
+	scopes := make(map[*Scope]bool)
+	for node, scope := range info.Scopes {
+		if _, ok := node.(*ast.FuncType); ok {
+			scopes[scope] = true
+		}
+	}
+	if len(scopes) != 4 {
+		t.Errorf("got %d distinct function scopes; want 4", len(scopes))
+	}
+}
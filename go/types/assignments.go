@@ -33,7 +33,7 @@ func (check *Checker) assignment(x *operand, T Type) bool {
 	// (tuple types are never named - no need for underlying type)
 	if t, _ := x.typ.(*Tuple); t != nil {
 		assert(t.Len() > 1)
-		check.errorf(x.pos(), "%d-valued expression %s used as single value", t.Len(), x)
+		check.errorf(x.pos(), TooManyValues, "%d-valued expression %s used as single value", t.Len(), x)
 		x.mode = invalid
 		return false
 	}
@@ -45,13 +45,13 @@ func (check *Checker) assignment(x *operand, T Type) bool {
 		// bool, rune, int, float64, complex128 or string respectively, depending
 		// on whether the value is a boolean, rune, integer, floating-point, complex,
 		// or string constant."
-		if T == nil || isInterface(T) {
+		if T == nil || IsInterface(T) {
 			if T == nil && x.typ == Typ[UntypedNil] {
-				check.errorf(x.pos(), "use of untyped nil")
+				check.errorf(x.pos(), UntypedNilUse, "use of untyped nil")
 				x.mode = invalid
 				return false
 			}
-			target = defaultType(x.typ)
+			target = Default(x.typ)
 		}
 		check.convertUntyped(x, target)
 		if x.mode == invalid {
@@ -75,7 +75,7 @@ func (check *Checker) initConst(lhs *Const, x *operand) {
 
 	// rhs must be a constant
 	if x.mode != constant {
-		check.errorf(x.pos(), "%s is not constant", x)
+		check.errorf(x.pos(), InvalidConstInit, "%s is not constant", x)
 		if lhs.typ == nil {
 			lhs.typ = Typ[Invalid]
 		}
@@ -90,7 +90,7 @@ func (check *Checker) initConst(lhs *Const, x *operand) {
 
 	if !check.assignment(x, lhs.typ) {
 		if x.mode != invalid {
-			check.errorf(x.pos(), "cannot define constant %s (type %s) as %s", lhs.Name(), lhs.typ, x)
+			check.errorf(x.pos(), InvalidConstInit, "cannot define constant %s (type %s) as %s", lhs.Name(), lhs.typ, x)
 		}
 		return
 	}
@@ -113,11 +113,11 @@ func (check *Checker) initVar(lhs *Var, x *operand, result bool) Type {
 		if isUntyped(typ) {
 			// convert untyped types to default types
 			if typ == Typ[UntypedNil] {
-				check.errorf(x.pos(), "use of untyped nil")
+				check.errorf(x.pos(), UntypedNilUse, "use of untyped nil")
 				lhs.typ = Typ[Invalid]
 				return nil
 			}
-			typ = defaultType(typ)
+			typ = Default(typ)
 		}
 		lhs.typ = typ
 	}
@@ -126,9 +126,9 @@ func (check *Checker) initVar(lhs *Var, x *operand, result bool) Type {
 		if x.mode != invalid {
 			if result {
 				// don't refer to lhs.name because it may be an anonymous result parameter
-				check.errorf(x.pos(), "cannot return %s as value of type %s", x, lhs.typ)
+				check.errorf(x.pos(), IncompatibleAssign, "cannot return %s as value of type %s", x, lhs.typ)
 			} else {
-				check.errorf(x.pos(), "cannot initialize %s with %s", lhs, x)
+				check.errorf(x.pos(), IncompatibleAssign, "cannot initialize %s with %s", lhs, x)
 			}
 		}
 		return nil
@@ -161,9 +161,12 @@ func (check *Checker) assignVar(lhs ast.Expr, x *operand) Type {
 	var v *Var
 	var v_used bool
 	if ident != nil {
-		if _, obj := check.scope.LookupParent(ident.Name); obj != nil {
-			v, _ = obj.(*Var)
-			if v != nil {
+		if _, obj := check.scope.LookupParent(ident.Name, check.pos); obj != nil {
+			// Only local variables are marked as used; variables of
+			// other (possibly concurrently checked) packages and
+			// package-level variables are not.
+			if w, _ := obj.(*Var); w != nil && w.pkg == check.pkg && w.parent != check.pkg.scope {
+				v = w
 				v_used = v.used
 			}
 		}
@@ -187,13 +190,13 @@ func (check *Checker) assignVar(lhs ast.Expr, x *operand) Type {
 	case variable, mapindex:
 		// ok
 	default:
-		check.errorf(z.pos(), "cannot assign to %s", &z)
+		check.errorf(z.pos(), UnassignableOperand, "cannot assign to %s", &z)
 		return nil
 	}
 
 	if !check.assignment(x, z.typ) {
 		if x.mode != invalid {
-			check.errorf(x.pos(), "cannot assign %s to %s", x, &z)
+			check.errorf(x.pos(), IncompatibleAssign, "cannot assign %s to %s", x, &z)
 		}
 		return nil
 	}
@@ -218,10 +221,10 @@ func (check *Checker) initVars(lhs []*Var, rhs []ast.Expr, returnPos token.Pos)
 		}
 		check.useGetter(get, r)
 		if returnPos.IsValid() {
-			check.errorf(returnPos, "wrong number of return values (want %d, got %d)", l, r)
+			check.errorf(returnPos, WrongResultCount, "wrong number of return values (want %d, got %d)", l, r)
 			return
 		}
-		check.errorf(rhs[0].Pos(), "assignment count mismatch (%d vs %d)", l, r)
+		check.errorf(rhs[0].Pos(), WrongAssignCount, "assignment count mismatch (%d vs %d)", l, r)
 		return
 	}
 
@@ -250,7 +253,7 @@ func (check *Checker) assignVars(lhs, rhs []ast.Expr) {
 	}
 	if l != r {
 		check.useGetter(get, r)
-		check.errorf(rhs[0].Pos(), "assignment count mismatch (%d vs %d)", l, r)
+		check.errorf(rhs[0].Pos(), WrongAssignCount, "assignment count mismatch (%d vs %d)", l, r)
 		return
 	}
 
@@ -290,7 +293,7 @@ func (check *Checker) shortVarDecl(pos token.Pos, lhs, rhs []ast.Expr) {
 				if alt, _ := alt.(*Var); alt != nil {
 					obj = alt
 				} else {
-					check.errorf(lhs.Pos(), "cannot assign to %s", lhs)
+					check.errorf(lhs.Pos(), UnassignableOperand, "cannot assign to %s", lhs)
 				}
 				check.recordUse(ident, alt)
 			} else {
@@ -302,7 +305,7 @@ func (check *Checker) shortVarDecl(pos token.Pos, lhs, rhs []ast.Expr) {
 				check.recordDef(ident, obj)
 			}
 		} else {
-			check.errorf(lhs.Pos(), "cannot declare %s", lhs)
+			check.errorf(lhs.Pos(), InvalidSyntaxTree, "cannot declare %s", lhs)
 		}
 		if obj == nil {
 			obj = NewVar(lhs.Pos(), check.pkg, "_", nil) // dummy variable
@@ -314,10 +317,11 @@ func (check *Checker) shortVarDecl(pos token.Pos, lhs, rhs []ast.Expr) {
 
 	// declare new variables
 	if len(newVars) > 0 {
+		scopePos := rhs[len(rhs)-1].End()
 		for _, obj := range newVars {
-			check.declare(scope, nil, obj) // recordObject already called
+			check.declare(scope, nil, obj, scopePos) // recordObject already called
 		}
 	} else {
-		check.softErrorf(pos, "no new variables on left side of :=")
+		check.softErrorf(pos, NoNewVar, "no new variables on left side of :=")
 	}
 }
@@ -23,7 +23,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 	// append is the only built-in that permits the use of ... for the last argument
 	bin := predeclaredFuncs[id]
 	if call.Ellipsis.IsValid() && id != _Append {
-		check.invalidOp(call.Ellipsis, "invalid use of ... with built-in %s", bin.name)
+		check.invalidOp(call.Ellipsis, InvalidDotDotDot, "invalid use of ... with built-in %s", bin.name)
 		check.use(call.Args...)
 		return
 	}
@@ -54,6 +54,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		if nargs > 0 {
 			arg(x, 0)
 			if x.mode == invalid {
+				useRest(arg, nargs)
 				return
 			}
 		}
@@ -70,7 +71,12 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			msg = "too many"
 		}
 		if msg != "" {
-			check.invalidOp(call.Rparen, "%s arguments for %s (expected %d, found %d)", msg, call, bin.nargs, nargs)
+			check.invalidOp(call.Rparen, WrongArgCount, "%s arguments for %s (expected %d, found %d)", msg, call, bin.nargs, nargs)
+			if arg != nil {
+				useRest(arg, nargs)
+			} else {
+				check.use(call.Args...)
+			}
 			return
 		}
 	}
@@ -87,7 +93,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		if s, _ := S.Underlying().(*Slice); s != nil {
 			T = s.elem
 		} else {
-			check.invalidArg(x.pos(), "%s is not a slice", x)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "%s is not a slice", x)
 			return
 		}
 
@@ -173,7 +179,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		if mode == invalid {
-			check.invalidArg(x.pos(), "%s for %s", x, bin.name)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "%s for %s", x, bin.name)
 			return
 		}
 
@@ -188,11 +194,11 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// close(c)
 		c, _ := x.typ.Underlying().(*Chan)
 		if c == nil {
-			check.invalidArg(x.pos(), "%s is not a channel", x)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "%s is not a channel", x)
 			return
 		}
 		if c.dir == RecvOnly {
-			check.invalidArg(x.pos(), "%s must not be a receive-only channel", x)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "%s must not be a receive-only channel", x)
 			return
 		}
 
@@ -226,7 +232,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		if !Identical(x.typ, y.typ) {
-			check.invalidArg(x.pos(), "mismatched types %s and %s", x.typ, y.typ)
+			check.invalidArg(x.pos(), MismatchedTypes, "mismatched types %s and %s", x.typ, y.typ)
 			return
 		}
 
@@ -245,7 +251,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			complexT = Typ[Complex128]
 		case UntypedInt, UntypedRune, UntypedFloat:
 			if x.mode == constant {
-				realT = defaultType(realT).(*Basic)
+				realT = Default(realT).(*Basic)
 				complexT = Typ[UntypedComplex]
 			} else {
 				// untyped but not constant; probably because one
@@ -254,7 +260,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 				complexT = Typ[Complex128]
 			}
 		default:
-			check.invalidArg(x.pos(), "float32 or float64 arguments expected")
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "float32 or float64 arguments expected")
 			return
 		}
 
@@ -297,12 +303,12 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		if dst == nil || src == nil {
-			check.invalidArg(x.pos(), "copy expects slice arguments; found %s and %s", x, &y)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "copy expects slice arguments; found %s and %s", x, &y)
 			return
 		}
 
 		if !Identical(dst, src) {
-			check.invalidArg(x.pos(), "arguments to copy %s and %s have different element types %s and %s", x, &y, dst, src)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "arguments to copy %s and %s have different element types %s and %s", x, &y, dst, src)
 			return
 		}
 
@@ -316,7 +322,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// delete(m, k)
 		m, _ := x.typ.Underlying().(*Map)
 		if m == nil {
-			check.invalidArg(x.pos(), "%s is not a map", x)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "%s is not a map", x)
 			return
 		}
 		arg(x, 1) // k
@@ -325,7 +331,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		if !x.assignableTo(check.conf, m.key) {
-			check.invalidArg(x.pos(), "%s is not assignable to %s", x, m.key)
+			check.invalidArg(x.pos(), IncompatibleAssign, "%s is not assignable to %s", x, m.key)
 			return
 		}
 
@@ -338,7 +344,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// imag(complexT) realT
 		// real(complexT) realT
 		if !isComplex(x.typ) {
-			check.invalidArg(x.pos(), "%s must be a complex number", x)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "%s must be a complex number", x)
 			return
 		}
 		if x.mode == constant {
@@ -384,11 +390,11 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		case *Map, *Chan:
 			min = 1
 		default:
-			check.invalidArg(arg0.Pos(), "cannot make %s; type must be slice, map, or channel", arg0)
+			check.invalidArg(arg0.Pos(), InvalidBuiltinArg, "cannot make %s; type must be slice, map, or channel", arg0)
 			return
 		}
 		if nargs < min || min+1 < nargs {
-			check.errorf(call.Pos(), "%s expects %d or %d arguments; found %d", call, min, min+1, nargs)
+			check.errorf(call.Pos(), WrongArgCount, "%s expects %d or %d arguments; found %d", call, min, min+1, nargs)
 			return
 		}
 		var sizes []int64 // constant integer arguments, if any
@@ -398,7 +404,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			}
 		}
 		if len(sizes) == 2 && sizes[0] > sizes[1] {
-			check.invalidArg(call.Args[1].Pos(), "length and capacity swapped")
+			check.invalidArg(call.Args[1].Pos(), InvalidBuiltinArg, "length and capacity swapped")
 			// safe to continue
 		}
 		x.mode = value
@@ -417,7 +423,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		x.mode = value
-		x.typ = &Pointer{base: T}
+		x.typ = check.canonical(&Pointer{base: T})
 		if check.Types != nil {
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, T))
 		}
@@ -484,7 +490,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		arg0 := call.Args[0]
 		selx, _ := unparen(arg0).(*ast.SelectorExpr)
 		if selx == nil {
-			check.invalidArg(arg0.Pos(), "%s is not a selector expression", arg0)
+			check.invalidArg(arg0.Pos(), InvalidBuiltinArg, "%s is not a selector expression", arg0)
 			check.use(arg0)
 			return
 		}
@@ -499,18 +505,18 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		obj, index, indirect := LookupFieldOrMethod(base, false, check.pkg, sel)
 		switch obj.(type) {
 		case nil:
-			check.invalidArg(x.pos(), "%s has no single field %s", base, sel)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "%s has no single field %s", base, sel)
 			return
 		case *Func:
 			// TODO(gri) Using derefStructPtr may result in methods being found
 			// that don't actually exist. An error either way, but the error
 			// message is confusing. See: http://play.golang.org/p/al75v23kUy ,
 			// but go/types reports: "invalid argument: x.m is a method value".
-			check.invalidArg(arg0.Pos(), "%s is a method value", arg0)
+			check.invalidArg(arg0.Pos(), InvalidBuiltinArg, "%s is a method value", arg0)
 			return
 		}
 		if indirect {
-			check.invalidArg(x.pos(), "field %s is embedded via a pointer in %s", sel, base)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "field %s is embedded via a pointer in %s", sel, base)
 			return
 		}
 
@@ -540,15 +546,15 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// The result of assert is the value of pred if there is no error.
 		// Note: assert is only available in self-test mode.
 		if x.mode != constant || !isBoolean(x.typ) {
-			check.invalidArg(x.pos(), "%s is not a boolean constant", x)
+			check.invalidArg(x.pos(), InvalidBuiltinArg, "%s is not a boolean constant", x)
 			return
 		}
 		if x.val.Kind() != exact.Bool {
-			check.errorf(x.pos(), "internal error: value of %s should be a boolean constant", x)
+			check.errorf(x.pos(), AssertionFailed, "internal error: value of %s should be a boolean constant", x)
 			return
 		}
 		if !exact.BoolVal(x.val) {
-			check.errorf(call.Pos(), "%s failed", call)
+			check.errorf(call.Pos(), AssertionFailed, "%s failed", call)
 			// compile-time assertion failure - safe to continue
 		}
 		// result is constant - no need to record signature
@@ -585,7 +591,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 func makeSig(res Type, args ...Type) *Signature {
 	list := make([]*Var, len(args))
 	for i, param := range args {
-		list[i] = NewVar(token.NoPos, nil, "", defaultType(param))
+		list[i] = NewVar(token.NoPos, nil, "", Default(param))
 	}
 	params := NewTuple(list...)
 	var result *Tuple
@@ -615,6 +621,16 @@ func (check *Checker) complexArg(x *operand) bool {
 	if t != nil && (t.info&IsFloat != 0 || t.kind == UntypedInt || t.kind == UntypedRune) {
 		return true
 	}
-	check.invalidArg(x.pos(), "%s must be a float32, float64, or an untyped non-complex numeric constant", x)
+	check.invalidArg(x.pos(), InvalidBuiltinArg, "%s must be a float32, float64, or an untyped non-complex numeric constant", x)
 	return false
 }
+
+// useRest evaluates the arguments 1 <= i < n provided by the getter arg.
+// It is called when a built-in call is invalid after arg(x, 0) was
+// evaluated, so that the remaining arguments are still type-checked.
+func useRest(arg getter, n int) {
+	var x operand
+	for i := 1; i < n; i++ {
+		arg(&x, i)
+	}
+}
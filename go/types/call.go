@@ -27,14 +27,16 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 		x.mode = invalid
 		switch n := len(e.Args); n {
 		case 0:
-			check.errorf(e.Rparen, "missing argument in conversion to %s", T)
+			check.errorf(e.Rparen, WrongArgCount, "missing argument in conversion to %s", T)
 		case 1:
 			check.expr(x, e.Args[0])
 			if x.mode != invalid {
-				check.conversion(x, T)
+				if kind := check.conversion(x, T); kind != InvalidConversion {
+					check.recordConversion(e, kind)
+				}
 			}
 		default:
-			check.errorf(e.Args[n-1].Pos(), "too many arguments in conversion to %s", T)
+			check.errorf(e.Args[n-1].Pos(), WrongArgCount, "too many arguments in conversion to %s", T)
 		}
 		x.expr = e
 		return conversion
@@ -55,7 +57,8 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 		// function/method call
 		sig, _ := x.typ.Underlying().(*Signature)
 		if sig == nil {
-			check.invalidOp(x.pos(), "cannot call non-function %s", x)
+			check.invalidOp(x.pos(), InvalidCall, "cannot call non-function %s", x)
+			check.use(e.Args...)
 			x.mode = invalid
 			x.expr = e
 			return statement
@@ -91,10 +94,31 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 // use type-checks each argument.
 // Useful to make sure expressions are evaluated
 // (and variables are "used") in the presence of other errors.
+// Nil arguments are ignored (certain AST fields such as the indices
+// of an ast.SliceExpr may legally be nil).
 func (check *Checker) use(arg ...ast.Expr) {
 	var x operand
 	for _, e := range arg {
-		check.rawExpr(&x, e, nil)
+		if e != nil {
+			check.rawExpr(&x, e, nil)
+		}
+	}
+}
+
+// useElts is like use, but for the elements of a composite literal of
+// invalid type: for key:value pairs, only the value is used since the key
+// may be a field name we cannot resolve, and for composite literals with
+// elided type, only their elements are used since the type is unknown.
+func (check *Checker) useElts(elts []ast.Expr) {
+	for _, e := range elts {
+		if kv, _ := e.(*ast.KeyValueExpr); kv != nil {
+			e = kv.Value
+		}
+		if lit, _ := e.(*ast.CompositeLit); lit != nil && lit.Type == nil {
+			check.useElts(lit.Elts)
+			continue
+		}
+		check.use(e)
 	}
 }
 
@@ -183,12 +207,12 @@ func (check *Checker) arguments(x *operand, call *ast.CallExpr, sig *Signature,
 		// last argument is of the form x...
 		if len(call.Args) == 1 && n > 1 {
 			// f()... is not permitted if f() is multi-valued
-			check.errorf(call.Ellipsis, "cannot use ... with %d-valued expression %s", n, call.Args[0])
+			check.errorf(call.Ellipsis, InvalidDotDotDot, "cannot use ... with %d-valued expression %s", n, call.Args[0])
 			check.useGetter(arg, n)
 			return
 		}
 		if !sig.variadic {
-			check.errorf(call.Ellipsis, "cannot use ... in call to non-variadic %s", call.Fun)
+			check.errorf(call.Ellipsis, InvalidDotDotDot, "cannot use ... in call to non-variadic %s", call.Fun)
 			check.useGetter(arg, n)
 			return
 		}
@@ -213,7 +237,7 @@ func (check *Checker) arguments(x *operand, call *ast.CallExpr, sig *Signature,
 		n++
 	}
 	if n < sig.params.Len() {
-		check.errorf(call.Rparen, "too few arguments in call to %s", call.Fun)
+		check.errorf(call.Rparen, WrongArgCount, "too few arguments in call to %s", call.Fun)
 		// ok to continue
 	}
 }
@@ -236,24 +260,24 @@ func (check *Checker) argument(sig *Signature, i int, x *operand, ellipsis token
 			}
 		}
 	default:
-		check.errorf(x.pos(), "too many arguments")
+		check.errorf(x.pos(), WrongArgCount, "too many arguments")
 		return
 	}
 
 	if ellipsis.IsValid() {
 		// argument is of the form x...
 		if i != n-1 {
-			check.errorf(ellipsis, "can only use ... with matching parameter")
+			check.errorf(ellipsis, InvalidDotDotDot, "can only use ... with matching parameter")
 			return
 		}
 		switch t := x.typ.Underlying().(type) {
 		case *Slice:
 			// ok
 		case *Tuple:
-			check.errorf(ellipsis, "cannot use ... with %d-valued expression %s", t.Len(), x)
+			check.errorf(ellipsis, InvalidDotDotDot, "cannot use ... with %d-valued expression %s", t.Len(), x)
 			return
 		default:
-			check.errorf(x.pos(), "cannot use %s as parameter of type %s", x, typ)
+			check.errorf(x.pos(), IncompatibleAssign, "cannot use %s as parameter of type %s", x, typ)
 			return
 		}
 	} else if sig.variadic && i >= n-1 {
@@ -262,7 +286,7 @@ func (check *Checker) argument(sig *Signature, i int, x *operand, ellipsis token
 	}
 
 	if !check.assignment(x, typ) && x.mode != invalid {
-		check.errorf(x.pos(), "cannot pass argument %s to parameter of type %s", x, typ)
+		check.errorf(x.pos(), IncompatibleAssign, "cannot pass argument %s to parameter of type %s", x, typ)
 	}
 }
 
@@ -280,25 +304,31 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 	// can only appear in qualified identifiers which are mapped to
 	// selector expressions.
 	if ident, ok := e.X.(*ast.Ident); ok {
-		_, obj := check.scope.LookupParent(ident.Name)
+		_, obj := check.scope.LookupParent(ident.Name, check.pos)
 		if pkg, _ := obj.(*PkgName); pkg != nil {
 			assert(pkg.pkg == check.pkg)
 			check.recordUse(ident, pkg)
+			check.lock()
 			pkg.used = true
+			check.unlock()
 			exp := pkg.imported.scope.Lookup(sel)
 			if exp == nil {
 				if !pkg.imported.fake {
-					check.errorf(e.Pos(), "%s not declared by package %s", sel, ident)
+					check.errorf(e.Pos(), UndeclaredImportedName, "%s not declared by package %s", sel, ident)
 				}
 				goto Error
 			}
 			if !exp.Exported() {
-				check.errorf(e.Pos(), "%s not exported by package %s", sel, ident)
+				check.errorf(e.Pos(), UnexportedName, "%s not exported by package %s", sel, ident)
 				// ok to continue
 			}
 			check.recordUse(e.Sel, exp)
 			// Simplified version of the code for *ast.Idents:
 			// - imported objects are always fully initialized
+			if exp.Type() == nil {
+				// malformed imported package
+				panic(internalError("imported object " + pkg.imported.path + "." + sel + " has no type"))
+			}
 			switch exp := exp.(type) {
 			case *Const:
 				assert(exp.Val() != nil)
@@ -336,11 +366,11 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 		switch {
 		case index != nil:
 			// TODO(gri) should provide actual type where the conflict happens
-			check.invalidOp(e.Pos(), "ambiguous selector %s", sel)
+			check.invalidOp(e.Pos(), AmbiguousSelector, "ambiguous selector %s", sel)
 		case indirect:
-			check.invalidOp(e.Pos(), "%s is not in method set of %s", sel, x.typ)
+			check.invalidOp(e.Pos(), InvalidMethodExpr, "%s is not in method set of %s", sel, x.typ)
 		default:
-			check.invalidOp(e.Pos(), "%s has no field or method %s", x, sel)
+			check.invalidOp(e.Pos(), MissingFieldOrMethod, "%s has no field or method %s", x, sel)
 		}
 		goto Error
 	}
@@ -349,7 +379,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 		// method expression
 		m, _ := obj.(*Func)
 		if m == nil {
-			check.invalidOp(e.Pos(), "%s has no method %s", x, sel)
+			check.invalidOp(e.Pos(), MissingFieldOrMethod, "%s has no method %s", x, sel)
 			goto Error
 		}
 
@@ -397,7 +427,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 					// includes the methods of typ.
 					// Variables are addressable, so we can always take their
 					// address.
-					if _, ok := typ.(*Pointer); !ok && !isInterface(typ) {
+					if _, ok := typ.(*Pointer); !ok && !IsInterface(typ) {
 						typ = &Pointer{base: typ}
 					}
 				}
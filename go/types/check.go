@@ -7,8 +7,11 @@
 package types
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
+	"sync"
+	"time"
 
 	"golang.org/x/tools/go/exact"
 )
@@ -52,6 +55,7 @@ type funcInfo struct {
 type context struct {
 	decl          *declInfo   // package-level declaration whose init expression/function body is checked
 	scope         *Scope      // top-most scope for lookups
+	pos           token.Pos   // if valid, identifiers are looked up as if at position pos (used by Eval)
 	iota          exact.Value // value of iota in a constant declaration; nil otherwise
 	sig           *Signature  // function signature if inside a function; nil otherwise
 	hasLabel      bool        // set if a function makes use of labels (only ~1% of functions); unused outside functions
@@ -68,23 +72,41 @@ type Checker struct {
 	pkg  *Package
 	*Info
 	objMap map[Object]*declInfo // maps package-level object to declaration info
+	fakeC  *Package             // fake package "C" if conf.FakeImportC is set; allocated on demand
+	ctxt   *Context             // conf.Context, or a context private to the checker
+	stats  *Stats               // conf.Stats, or statistics private to the checker
 
 	// information collected during type-checking of a set of package files
 	// (initialized by Files, valid only for the duration of check.Files;
 	// maps and lists are allocated on demand)
 	files            []*ast.File                       // package files
+	incomplete       bool                              // some package files are missing or have syntax errors
 	unusedDotImports map[*Scope]map[*Package]token.Pos // positions of unused dot-imported packages for each file scope
 
 	firstErr error                 // first error encountered
+	buffered bool                  // if set, errors are collected in buffer rather than reported
+	buffer   []Error               // errors collected while checking a function body concurrently
+	mu       *sync.Mutex           // if set, guards package data shared by concurrently checked function bodies
 	methods  map[string][]*Func    // maps type names to associated methods
 	untyped  map[ast.Expr]exprInfo // map of expressions without final type
 	funcs    []funcInfo            // list of functions to type-check
 	delayed  []func()              // delayed checks requiring fully setup types
 
+	// package-level objects of a modified file reused by RecheckFile
+	// (valid only for the duration of check.RecheckFile)
+	recycled []Object             // objects not yet reused, in source order
+	named    map[*TypeName]*Named // types of reused type names
+	oldTypes map[Object]Type      // previous (underlying) types of reused objects
+
+	snippet *snippetExpr // statement of an expression checked by CheckSnippet, or nil
+
 	// context within which the current object is type-checked
 	// (valid only for the duration of type-checking a specific object)
 	context
 
+	depth  int // nesting depth of expressions and type expressions being checked
+	errors int // number of errors reported
+
 	// debugging
 	indent int // indentation for tracing
 }
@@ -145,6 +167,11 @@ func (check *Checker) delay(f func()) {
 
 // NewChecker returns a new Checker instance for a given package.
 // Package files may be added incrementally via checker.Files.
+//
+// Checkers for different packages may run concurrently, even if the
+// packages import the same packages, provided the imported packages
+// are not modified while being used and the Config.Import function
+// is safe for concurrent use.
 func NewChecker(conf *Config, fset *token.FileSet, pkg *Package, info *Info) *Checker {
 	// make sure we have a configuration
 	if conf == nil {
@@ -161,12 +188,26 @@ func NewChecker(conf *Config, fset *token.FileSet, pkg *Package, info *Info) *Ch
 		info = new(Info)
 	}
 
+	// make sure we have a context for canonical types
+	ctxt := conf.Context
+	if ctxt == nil {
+		ctxt = new(Context)
+	}
+
+	// make sure we have a place for statistics
+	stats := conf.Stats
+	if stats == nil {
+		stats = new(Stats)
+	}
+
 	return &Checker{
 		conf:   conf,
 		fset:   fset,
 		pkg:    pkg,
 		Info:   info,
 		objMap: make(map[Object]*declInfo),
+		ctxt:   ctxt,
+		stats:  stats,
 	}
 }
 
@@ -175,9 +216,12 @@ func NewChecker(conf *Config, fset *token.FileSet, pkg *Package, info *Info) *Ch
 func (check *Checker) initFiles(files []*ast.File) {
 	// start with a clean slate (check.Files may be called multiple times)
 	check.files = nil
+	check.incomplete = false
 	check.unusedDotImports = nil
 
 	check.firstErr = nil
+	check.depth = 0
+	check.errors = 0
 	check.methods = nil
 	check.untyped = nil
 	check.funcs = nil
@@ -186,12 +230,20 @@ func (check *Checker) initFiles(files []*ast.File) {
 	// determine package name and collect valid files
 	pkg := check.pkg
 	for _, file := range files {
+		if file == nil || file.Name == nil {
+			// the file could not be parsed
+			check.incomplete = true
+			continue
+		}
+		if hasBadDecl(file) {
+			check.incomplete = true
+		}
 		switch name := file.Name.Name; pkg.name {
 		case "":
 			if name != "_" {
 				pkg.name = name
 			} else {
-				check.errorf(file.Name.Pos(), "invalid package name _")
+				check.errorf(file.Name.Pos(), MismatchedPkgName, "invalid package name _")
 			}
 			fallthrough
 
@@ -199,12 +251,23 @@ func (check *Checker) initFiles(files []*ast.File) {
 			check.files = append(check.files, file)
 
 		default:
-			check.errorf(file.Package, "package %s; expected %s", name, pkg.name)
+			check.errorf(file.Package, MismatchedPkgName, "package %s; expected %s", name, pkg.name)
 			// ignore this file
 		}
 	}
 }
 
+// hasBadDecl reports whether file contains declarations
+// that could not be parsed.
+func hasBadDecl(file *ast.File) bool {
+	for _, decl := range file.Decls {
+		if _, ok := decl.(*ast.BadDecl); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // A bailout panic is used for early termination.
 type bailout struct{}
 
@@ -213,28 +276,61 @@ func (check *Checker) handleBailout(err *error) {
 	case nil, bailout:
 		// normal return or early exit
 		*err = check.firstErr
+	case internalError:
+		if debug {
+			panic(p) // keep the stack trace during development
+		}
+		// Report the failure instead of crashing the client: it is
+		// usually caused by malformed input, such as inconsistent
+		// imported packages. Other runtime panics are bugs of the
+		// checker and are not recovered.
+		e := Error{Fset: check.fset, Msg: fmt.Sprintf("internal error: %v", p), Code: InternalError}
+		if f := check.conf.Error; f != nil {
+			f(e)
+		}
+		*err = e
 	default:
 		// re-panic
 		panic(p)
 	}
 }
 
+// checkCanceled abandons type-checking if conf.Cancel is closed.
+func (check *Checker) checkCanceled() {
+	select {
+	case <-check.conf.Cancel:
+		check.firstErr = ErrCanceled
+		panic(bailout{})
+	default:
+	}
+}
+
 // Files checks the provided files as part of the checker's package.
+//
+// Files that could not be parsed may be passed as nil entries, and files
+// with syntax errors may be passed as the partial syntax trees returned
+// by the parser: the remaining files and declarations are checked
+// nonetheless. Since the names declared by the missing parts are unknown,
+// undeclared names are then reported as soft errors and have invalid type.
 func (check *Checker) Files(files []*ast.File) (err error) {
 	defer check.handleBailout(&err)
 
 	check.initFiles(files)
 
+	start := time.Now()
 	check.collectObjects()
+	start = check.stats.phase(&check.stats.Collect, start)
 
 	check.packageObjects(check.resolveOrder())
+	start = check.stats.phase(&check.stats.Decls, start)
 
 	check.functionBodies()
+	start = check.stats.phase(&check.stats.Bodies, start)
 
 	check.initOrder()
 
 	if !check.conf.DisableUnusedImportCheck {
-		check.unusedImports()
+		check.unusedImports(check.pkg.scope.children /* file scopes */)
 	}
 
 	// perform delayed checks
@@ -243,11 +339,39 @@ func (check *Checker) Files(files []*ast.File) (err error) {
 	}
 
 	check.recordUntyped()
+	start = check.stats.phase(&check.stats.Other, start)
 
 	check.pkg.complete = true
+
+	// run user-defined analyzers if type-checking succeeded
+	// or if the client wants to see all errors
+	if check.firstErr == nil || check.conf.Error != nil {
+		check.runAnalyzers()
+		check.stats.phase(&check.stats.Analyzers, start)
+	}
+
+	if check.conf.ReleaseFuncBodies {
+		check.releaseFuncBodies()
+	}
+
 	return
 }
 
+// releaseFuncBodies releases the function bodies of the package files
+// and the checker's remaining references into them.
+func (check *Checker) releaseFuncBodies() {
+	for _, file := range check.files {
+		for _, decl := range file.Decls {
+			if d, _ := decl.(*ast.FuncDecl); d != nil {
+				d.Body = nil
+			}
+		}
+	}
+	check.untyped = nil
+	check.funcs = nil
+	check.delayed = nil
+}
+
 func (check *Checker) recordUntyped() {
 	if !debug && check.Types == nil {
 		return // nothing to do
@@ -324,6 +448,9 @@ func (check *Checker) recordCommaOkTypes(x ast.Expr, a [2]Type) {
 
 func (check *Checker) recordDef(id *ast.Ident, obj Object) {
 	assert(id != nil)
+	if obj != nil {
+		check.stats.Objects++
+	}
 	if m := check.Defs; m != nil {
 		m[id] = obj
 	}
@@ -340,6 +467,7 @@ func (check *Checker) recordUse(id *ast.Ident, obj Object) {
 func (check *Checker) recordImplicit(node ast.Node, obj Object) {
 	assert(node != nil)
 	assert(obj != nil)
+	check.stats.Objects++
 	if m := check.Implicits; m != nil {
 		m[node] = obj
 	}
@@ -354,6 +482,38 @@ func (check *Checker) recordSelection(x *ast.SelectorExpr, kind SelectionKind, r
 	}
 }
 
+func (check *Checker) recordIota(spec *ast.ValueSpec, iota int) {
+	assert(spec != nil)
+	if m := check.Iotas; m != nil {
+		m[spec] = iota
+	}
+}
+
+func (check *Checker) recordConversion(call *ast.CallExpr, kind ConversionKind) {
+	assert(call != nil)
+	assert(kind != InvalidConversion)
+	if m := check.Conversions; m != nil {
+		m[call] = kind
+	}
+}
+
+func (check *Checker) recordDoc(obj Object, doc *ast.CommentGroup) {
+	assert(obj != nil)
+	if doc == nil {
+		return // nothing to record
+	}
+	if m := check.Docs; m != nil {
+		m[obj] = doc
+	}
+}
+
+func (check *Checker) recordStructTag(fld *Var, pairs []TagPair) {
+	assert(fld != nil && pairs != nil)
+	if m := check.StructTags; m != nil {
+		m[fld] = pairs
+	}
+}
+
 func (check *Checker) recordScope(node ast.Node, scope *Scope) {
 	assert(node != nil)
 	assert(scope != nil)
@@ -27,6 +27,7 @@ package types_test
 
 import (
 	"flag"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/scanner"
@@ -34,6 +35,7 @@ import (
 	"io/ioutil"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 
 	_ "golang.org/x/tools/go/gcimporter"
@@ -249,6 +251,9 @@ func checkFiles(t *testing.T, testfiles []string) {
 			t.Error(err)
 			return
 		}
+		if err.(Error).Code == UnknownError {
+			t.Errorf("%s: error without code", err)
+		}
 		// Ignore secondary error messages starting with "\t";
 		// they are clarifying messages for a primary error.
 		if !strings.Contains(err.Error(), ": \t") {
@@ -292,3 +297,101 @@ func TestCheck(t *testing.T) {
 		checkFiles(t, files)
 	}
 }
+
+func TestConcurrentChecks(t *testing.T) {
+	// Packages importing the same package may be checked concurrently,
+	// even with different Sizes (run with -race).
+	lib, err := pkgFor("lib", `package lib; type S struct{ a int8; b int }; var V int`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const src = `package p
+import ("lib"; . "lib"; "unsafe")
+const size = unsafe.Sizeof(lib.S{})
+func f() { V = 1 }
+`
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wordSize := int64(4 + 4*(i%2))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "p.go", src, 0)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conf := Config{
+				Packages: map[string]*Package{"lib": lib},
+				Import: func(imports map[string]*Package, path string) (*Package, error) {
+					if path == "unsafe" {
+						return Unsafe, nil
+					}
+					return imports[path], nil
+				},
+				Sizes: &StdSizes{WordSize: wordSize, MaxAlign: wordSize},
+			}
+			pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			size := pkg.Scope().Lookup("size").(*Const).Val().String()
+			if want := fmt.Sprint(2 * wordSize); size != want {
+				t.Errorf("WordSize %d: got size %s; want %s", wordSize, size, want)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCancel(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+const C = unsafe.Sizeof(0)
+
+func f() { var x int; _ = x }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cancelOnImport := range []bool{false, true} {
+		cancel := make(chan struct{})
+		if !cancelOnImport {
+			close(cancel)
+		}
+		conf := Config{
+			Import: func(map[string]*Package, string) (*Package, error) {
+				if cancelOnImport {
+					close(cancel)
+				}
+				return Unsafe, nil
+			},
+			Cancel: cancel,
+			Error:  func(err error) { t.Errorf("unexpected error: %s", err) },
+		}
+		info := Info{Defs: make(map[*ast.Ident]Object)}
+		pkg, err := conf.Check("p", fset, []*ast.File{f}, &info)
+		if err != ErrCanceled {
+			t.Errorf("got error %v; want %v", err, ErrCanceled)
+		}
+		if pkg == nil || pkg.Complete() {
+			t.Errorf("got package %v; want incomplete package", pkg)
+			continue
+		}
+
+		// the file was processed only if Cancel was closed while checking
+		if got := pkg.Scope().NumChildren() == 1; got != cancelOnImport {
+			t.Errorf("file processed = %v; want %v", got, cancelOnImport)
+		}
+		if obj := pkg.Scope().Lookup("C"); obj != nil && obj.Type() != nil {
+			t.Errorf("%s declared despite cancellation", obj)
+		}
+	}
+}
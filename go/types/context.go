@@ -0,0 +1,141 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Contexts.
+
+package types
+
+import "sync"
+
+// A Context is shared by the checkers of many packages to reduce the
+// memory needed for their types (see Config.Context). Identical pointer,
+// slice, array, map, or channel types, and function types without named
+// parameters or results, are represented by a single instance in all
+// packages checked with the same Context, provided their components are
+// basic types, named types, or themselves such types. The parameters of
+// a shared function type belong to the package that first recorded it.
+// (Without a Context, such types are shared within each package; the
+// Universe scope and the predeclared objects are shared by all checkers
+// in any case.)
+//
+// A Context retains the types it records, and thus the packages in which
+// their named components are declared. The zero value for Context is a
+// ready-to-use empty context. A Context is safe for concurrent use.
+//
+type Context struct {
+	mu    sync.Mutex
+	types map[typeKey][]Type // canonical types by structure; lazily allocated
+	canon map[Type]bool      // set of canonical types; lazily allocated
+}
+
+// A typeKey describes the structure of a pointer, slice, array, map,
+// or channel type. For a function type, it describes the number of its
+// parameters and results, and their first types.
+type typeKey struct {
+	kind      byte // 'p' (pointer), 's' (slice), 'a' (array), 'm' (map), 'c' (channel), 'f' or 'v' (variadic function)
+	len       int64
+	dir       ChanDir
+	key, elem Type
+}
+
+// canonical returns the instance of types identical to typ recorded
+// in ctxt, recording typ if there is no such instance yet and typ is
+// eligible.
+func (ctxt *Context) canonical(typ Type) Type {
+	var k typeKey
+	switch t := typ.(type) {
+	case *Pointer:
+		k = typeKey{kind: 'p', elem: t.base}
+	case *Slice:
+		k = typeKey{kind: 's', elem: t.elem}
+	case *Array:
+		k = typeKey{kind: 'a', len: t.len, elem: t.elem}
+	case *Map:
+		k = typeKey{kind: 'm', key: t.key, elem: t.elem}
+	case *Chan:
+		k = typeKey{kind: 'c', dir: t.dir, elem: t.elem}
+	case *Signature:
+		if t.recv != nil {
+			return typ
+		}
+		k = typeKey{kind: 'f', len: int64(t.params.Len())<<32 | int64(t.results.Len())}
+		if t.variadic {
+			k.kind = 'v'
+		}
+		if t.params.Len() > 0 {
+			k.key = t.params.vars[0].typ
+		}
+		if t.results.Len() > 0 {
+			k.elem = t.results.vars[0].typ
+		}
+	default:
+		return typ
+	}
+
+	ctxt.mu.Lock()
+	defer ctxt.mu.Unlock()
+
+	if !ctxt.isCanonical(k.key) || !ctxt.isCanonical(k.elem) {
+		return typ
+	}
+	sig, _ := typ.(*Signature)
+	if sig != nil && !(ctxt.canonicalVars(sig.params) && ctxt.canonicalVars(sig.results)) {
+		return typ
+	}
+	for _, t := range ctxt.types[k] {
+		if sig == nil || sameVarTypes(sig.params, t.(*Signature).params) && sameVarTypes(sig.results, t.(*Signature).results) {
+			return t
+		}
+	}
+	if ctxt.types == nil {
+		ctxt.types = make(map[typeKey][]Type)
+		ctxt.canon = make(map[Type]bool)
+	}
+	ctxt.types[k] = append(ctxt.types[k], typ)
+	ctxt.canon[typ] = true
+	return typ
+}
+
+// canonicalVars reports whether the variables of tuple t are unnamed
+// and have canonical types. ctxt.mu must be held.
+func (ctxt *Context) canonicalVars(t *Tuple) bool {
+	if t != nil {
+		for _, v := range t.vars {
+			if v.name != "" || !ctxt.isCanonical(v.typ) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sameVarTypes reports whether the variables of the tuples x and y,
+// which have the same length, have the same (canonical) types.
+func sameVarTypes(x, y *Tuple) bool {
+	for i := 0; i < x.Len(); i++ {
+		if x.vars[i].typ != y.vars[i].typ {
+			return false
+		}
+	}
+	return true
+}
+
+// isCanonical reports whether typ, a component of a type, permits
+// the type to be recorded. ctxt.mu must be held.
+func (ctxt *Context) isCanonical(typ Type) bool {
+	switch typ.(type) {
+	case nil, *Basic, *Named:
+		return true
+	}
+	return ctxt.canon[typ]
+}
+
+// canonical returns the instance of typ recorded in the checker's context.
+func (check *Checker) canonical(typ Type) Type {
+	t := check.ctxt.canonical(typ)
+	if t != typ {
+		check.stats.Shared++
+	}
+	return t
+}
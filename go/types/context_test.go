@@ -0,0 +1,122 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestContext(t *testing.T) {
+	lib, err := pkgFor("lib", "package lib; type T int", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	importer := func(_ map[string]*Package, path string) (*Package, error) {
+		return lib, nil
+	}
+	const src = `package p
+
+import "lib"
+
+var (
+	P *lib.T
+	S [][]int
+	A [2]string
+	M map[string]*lib.T
+	C <-chan bool
+	X []struct{}
+)
+`
+	check := func(ctxt *Context) *Package {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf := Config{Import: importer, Context: ctxt}
+		pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg
+	}
+
+	var ctxt Context
+	p1, p2, p3 := check(&ctxt), check(&ctxt), check(nil)
+	for _, name := range []string{"P", "S", "A", "M", "C", "X"} {
+		t1 := p1.Scope().Lookup(name).Type()
+		t2 := p2.Scope().Lookup(name).Type()
+		t3 := p3.Scope().Lookup(name).Type()
+		if !Identical(t1, t2) || !Identical(t1, t3) {
+			t.Errorf("%s: types %s, %s, %s not identical", name, t1, t2, t3)
+		}
+		// types with struct components are not shared
+		if shared := name != "X"; (t1 == t2) != shared {
+			t.Errorf("%s: type %s shared = %v; want %v", name, t1, t1 == t2, shared)
+		}
+		if t1 == t3 {
+			t.Errorf("%s: type %s shared without Context", name, t1)
+		}
+	}
+}
+
+func TestInternedTypes(t *testing.T) {
+	const src = `package p
+
+type T int
+
+var (
+	p1, p2 *T
+	s1     []int
+	s2     = []int{}
+	f1     func(int, ...string) (bool, error)
+	f2     func(int, ...string) (ok bool, err error)
+	f3     func(int, ...string) (bool, error)
+	a      [4]int
+	x      = new(T)
+	y      = a[:]
+)
+
+func variadic(...int) {}
+`
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	pkg, err := pkgFor("p", src, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := func(name string) Type {
+		return pkg.Scope().Lookup(name).Type()
+	}
+
+	for _, test := range []struct {
+		x, y   string
+		shared bool
+	}{
+		{"p1", "p2", true},
+		{"p1", "x", true},
+		{"s1", "s2", true},
+		{"s1", "y", true},
+		{"f1", "f3", true},
+		{"f1", "f2", false}, // named results
+	} {
+		x, y := typ(test.x), typ(test.y)
+		if !Identical(x, y) {
+			t.Errorf("%s and %s have different types %s and %s", test.x, test.y, x, y)
+		}
+		if got := x == y; got != test.shared {
+			t.Errorf("types of %s and %s shared = %v; want %v", test.x, test.y, got, test.shared)
+		}
+	}
+
+	params := typ("variadic").(*Signature).Params()
+	if got := params.At(0).Type(); got != typ("s1") {
+		t.Errorf("variadic parameter type %s not shared", got)
+	}
+}
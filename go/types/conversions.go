@@ -8,18 +8,40 @@ package types
 
 import "golang.org/x/tools/go/exact"
 
+// A ConversionKind describes the rule of the spec
+// that permits a conversion T(x).
+type ConversionKind int
+
+// The following conversion kinds are distinguished.
+const (
+	InvalidConversion ConversionKind = iota // conversion is invalid
+
+	ConstConversion         // constant x is representable by a value of constant type T
+	AssignableConversion    // x is assignable to T
+	UnderlyingConversion    // x's type and T have identical underlying types
+	PointerConversion       // x's type and T are unnamed pointer types with identical base underlying types
+	NumericConversion       // x's type and T are both integer or floating point types
+	ComplexConversion       // x's type and T are both complex types
+	IntToStringConversion   // x is an integer and T is a string type
+	SliceToStringConversion // x is a slice of bytes or runes and T is a string type
+	StringToSliceConversion // x is a string and T is a slice of bytes or runes
+	UnsafePointerConversion // x is a pointer or uintptr and T is unsafe.Pointer, or vice versa
+)
+
 // Conversion type-checks the conversion T(x).
-// The result is in x.
-func (check *Checker) conversion(x *operand, T Type) {
+// The result is in x; the returned kind describes
+// the rule permitting the conversion. If the
+// conversion is invalid, x.mode is set to invalid.
+func (check *Checker) conversion(x *operand, T Type) ConversionKind {
 	constArg := x.mode == constant
 
-	var ok bool
+	var kind ConversionKind
 	switch {
 	case constArg && isConstType(T):
 		// constant conversion
 		switch t := T.Underlying().(*Basic); {
 		case representableConst(x.val, check.conf, t.kind, &x.val):
-			ok = true
+			kind = ConstConversion
 		case x.isInteger() && isString(t):
 			codepoint := int64(-1)
 			if i, ok := exact.Int64Val(x.val); ok {
@@ -29,18 +51,26 @@ func (check *Checker) conversion(x *operand, T Type) {
 			// conversion. This is the same as converting any other out-of-range
 			// value - let string(codepoint) do the work.
 			x.val = exact.MakeString(string(codepoint))
-			ok = true
+			kind = IntToStringConversion
 		}
-	case x.convertibleTo(check.conf, T):
+	default:
 		// non-constant conversion
-		x.mode = value
-		ok = true
+		if kind = x.conversionKind(check.conf, T); kind != InvalidConversion {
+			x.mode = value
+		}
 	}
 
-	if !ok {
-		check.errorf(x.pos(), "cannot convert %s to %s", x, T)
+	if kind == InvalidConversion {
+		// for conversions to interfaces, explain which method doesn't match
+		var reason string
+		if t, _ := T.Underlying().(*Interface); t != nil && !isUntyped(x.typ) {
+			if m, wrongType := MissingMethod(x.typ, t, true); m != nil {
+				reason = " (" + check.missingMethodReason(x.typ, m, wrongType) + ")"
+			}
+		}
+		check.errorf(x.pos(), ImpossibleConversion, "cannot convert %s to %s%s", x, T, reason)
 		x.mode = invalid
-		return
+		return kind
 	}
 
 	// The conversion argument types are final. For untyped values the
@@ -54,19 +84,26 @@ func (check *Checker) conversion(x *operand, T Type) {
 		//   use the default type (e.g., []byte("foo") should report string
 		//   not []byte as type for the constant "foo").
 		// - Keep untyped nil for untyped nil arguments.
-		if isInterface(T) || constArg && !isConstType(T) {
-			final = defaultType(x.typ)
+		if IsInterface(T) || constArg && !isConstType(T) {
+			final = Default(x.typ)
 		}
 		check.updateExprType(x.expr, final, true)
 	}
 
 	x.typ = T
+	return kind
 }
 
 func (x *operand) convertibleTo(conf *Config, T Type) bool {
+	return x.conversionKind(conf, T) != InvalidConversion
+}
+
+// conversionKind returns the kind of the (non-constant) conversion T(x),
+// or InvalidConversion if x is not convertible to T.
+func (x *operand) conversionKind(conf *Config, T Type) ConversionKind {
 	// "x is assignable to T"
 	if x.assignableTo(conf, T) {
-		return true
+		return AssignableConversion
 	}
 
 	// "x's type and T have identical underlying types"
@@ -74,49 +111,54 @@ func (x *operand) convertibleTo(conf *Config, T Type) bool {
 	Vu := V.Underlying()
 	Tu := T.Underlying()
 	if Identical(Vu, Tu) {
-		return true
+		return UnderlyingConversion
 	}
 
 	// "x's type and T are unnamed pointer types and their pointer base types have identical underlying types"
 	if V, ok := V.(*Pointer); ok {
 		if T, ok := T.(*Pointer); ok {
 			if Identical(V.base.Underlying(), T.base.Underlying()) {
-				return true
+				return PointerConversion
 			}
 		}
 	}
 
 	// "x's type and T are both integer or floating point types"
 	if (isInteger(V) || isFloat(V)) && (isInteger(T) || isFloat(T)) {
-		return true
+		return NumericConversion
 	}
 
 	// "x's type and T are both complex types"
 	if isComplex(V) && isComplex(T) {
-		return true
+		return ComplexConversion
 	}
 
 	// "x is an integer or a slice of bytes or runes and T is a string type"
-	if (isInteger(V) || isBytesOrRunes(Vu)) && isString(T) {
-		return true
+	if isString(T) {
+		if isInteger(V) {
+			return IntToStringConversion
+		}
+		if isBytesOrRunes(Vu) {
+			return SliceToStringConversion
+		}
 	}
 
 	// "x is a string and T is a slice of bytes or runes"
 	if isString(V) && isBytesOrRunes(Tu) {
-		return true
+		return StringToSliceConversion
 	}
 
 	// package unsafe:
 	// "any pointer or value of underlying type uintptr can be converted into a unsafe.Pointer"
 	if (isPointer(Vu) || isUintptr(Vu)) && isUnsafePointer(T) {
-		return true
+		return UnsafePointerConversion
 	}
 	// "and vice versa"
 	if isUnsafePointer(V) && (isPointer(Tu) || isUintptr(Tu)) {
-		return true
+		return UnsafePointerConversion
 	}
 
-	return false
+	return InvalidConversion
 }
 
 func isUintptr(typ Type) bool {
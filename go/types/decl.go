@@ -11,26 +11,33 @@ import (
 	"golang.org/x/tools/go/exact"
 )
 
-func (check *Checker) reportAltDecl(obj Object) {
+func (check *Checker) reportAltDecl(obj Object, code ErrorCode) {
 	if pos := obj.Pos(); pos.IsValid() {
 		// We use "other" rather than "previous" here because
 		// the first declaration seen may not be textually
 		// earlier in the source.
-		check.errorf(pos, "\tother declaration of %s", obj.Name()) // secondary error, \t indented
+		check.errorf(pos, code, "\tother declaration of %s", obj.Name()) // secondary error, \t indented
 	}
 }
 
-func (check *Checker) declare(scope *Scope, id *ast.Ident, obj Object) {
+func (check *Checker) declare(scope *Scope, id *ast.Ident, obj Object, scopePos token.Pos) {
 	// spec: "The blank identifier, represented by the underscore
 	// character _, may be used in a declaration like any other
 	// identifier but the declaration does not introduce a new
 	// binding."
 	if obj.Name() != "_" {
-		if alt := scope.Insert(obj); alt != nil {
-			check.errorf(obj.Pos(), "%s redeclared in this block", obj.Name())
-			check.reportAltDecl(alt)
+		// (alt == obj if obj was recycled by RecheckFile)
+		if alt := scope.Insert(obj); alt != nil && alt != obj {
+			check.errorf(obj.Pos(), DuplicateDecl, "%s redeclared in this block", obj.Name())
+			check.reportAltDecl(alt, DuplicateDecl)
 			return
 		}
+		// Dot-imported objects are declared with an invalid scopePos;
+		// they must not be modified since they may be shared with other
+		// packages being checked concurrently.
+		if scopePos.IsValid() {
+			obj.setScopePos(scopePos)
+		}
 	}
 	if id != nil {
 		check.recordDef(id, obj)
@@ -88,6 +95,10 @@ func (check *Checker) objDecl(obj Object, def *Named, path []*TypeName) {
 	default:
 		unreachable()
 	}
+
+	if f := check.conf.Declared; f != nil {
+		f(obj, check.errors)
+	}
 }
 
 func (check *Checker) constDecl(obj *Const, typ, init ast.Expr) {
@@ -111,7 +122,7 @@ func (check *Checker) constDecl(obj *Const, typ, init ast.Expr) {
 	if typ != nil {
 		t := check.typ(typ)
 		if !isConstType(t) {
-			check.errorf(typ.Pos(), "invalid constant type %s", t)
+			check.errorf(typ.Pos(), InvalidConstType, "invalid constant type %s", t)
 			obj.typ = Typ[Invalid]
 			return
 		}
@@ -202,7 +213,10 @@ func (check *Checker) typeDecl(obj *TypeName, typ ast.Expr, def *Named, path []*
 	// type declarations cannot use iota
 	assert(check.iota == nil)
 
-	named := &Named{obj: obj}
+	named := check.named[obj] // the existing type of a recycled obj, if any
+	if named == nil {
+		named = &Named{obj: obj}
+	}
 	def.setUnderlying(named)
 	obj.typ = named // make sure recursive type declarations terminate
 
@@ -268,15 +282,21 @@ func (check *Checker) addMethodDecls(obj *TypeName) {
 		// to it must be unique."
 		if m.name != "_" {
 			if alt := mset.insert(m); alt != nil {
+				if alt == m {
+					// m was recycled by RecheckFile and is
+					// already associated with base
+					check.objDecl(m, nil, nil)
+					continue
+				}
 				switch alt.(type) {
 				case *Var:
-					check.errorf(m.pos, "field and method with the same name %s", m.name)
+					check.errorf(m.pos, DuplicateMethod, "field and method with the same name %s", m.name)
 				case *Func:
-					check.errorf(m.pos, "method %s already declared for %s", m.name, base)
+					check.errorf(m.pos, DuplicateMethod, "method %s already declared for %s", m.name, base)
 				default:
 					unreachable()
 				}
-				check.reportAltDecl(alt)
+				check.reportAltDecl(alt, DuplicateMethod)
 				continue
 			}
 		}
@@ -299,7 +319,7 @@ func (check *Checker) funcDecl(obj *Func, decl *declInfo) {
 	fdecl := decl.fdecl
 	check.funcType(sig, fdecl.Recv, fdecl.Type)
 	if sig.recv == nil && obj.name == "init" && (sig.params.Len() > 0 || sig.results.Len() > 0) {
-		check.errorf(fdecl.Pos(), "func init must have no arguments and no return values")
+		check.errorf(fdecl.Pos(), InvalidInitDecl, "func init must have no arguments and no return values")
 		// ok to continue
 	}
 
@@ -322,6 +342,7 @@ func (check *Checker) declStmt(decl ast.Decl) {
 		for iota, spec := range d.Specs {
 			switch s := spec.(type) {
 			case *ast.ValueSpec:
+				doc := specDoc(d, s.Doc)
 				switch d.Tok {
 				case token.CONST:
 					// determine which init exprs to use
@@ -331,11 +352,13 @@ func (check *Checker) declStmt(decl ast.Decl) {
 					case last == nil:
 						last = new(ast.ValueSpec) // make sure last exists
 					}
+					check.recordIota(s, iota)
 
 					// declare all constants
 					lhs := make([]*Const, len(s.Names))
 					for i, name := range s.Names {
 						obj := NewConst(name.Pos(), pkg, name.Name, nil, exact.MakeInt64(int64(iota)))
+						check.recordDoc(obj, doc)
 						lhs[i] = obj
 
 						var init ast.Expr
@@ -348,14 +371,20 @@ func (check *Checker) declStmt(decl ast.Decl) {
 
 					check.arityMatch(s, last)
 
+					// spec: "The scope of a constant or variable identifier declared
+					// inside a function begins at the end of the ConstSpec or VarSpec
+					// (ShortVarDecl for short variable declarations) and ends at the
+					// end of the innermost containing block."
+					scopePos := s.End()
 					for i, name := range s.Names {
-						check.declare(check.scope, name, lhs[i])
+						check.declare(check.scope, name, lhs[i], scopePos)
 					}
 
 				case token.VAR:
 					lhs0 := make([]*Var, len(s.Names))
 					for i, name := range s.Names {
 						lhs0[i] = NewVar(name.Pos(), pkg, name.Name, nil)
+						check.recordDoc(lhs0[i], doc)
 					}
 
 					// initialize all variables
@@ -395,8 +424,9 @@ func (check *Checker) declStmt(decl ast.Decl) {
 
 					// declare all variables
 					// (only at this point are the variable scopes (parents) set)
+					scopePos := s.End()
 					for i, name := range s.Names {
-						check.declare(check.scope, name, lhs0[i])
+						check.declare(check.scope, name, lhs0[i], scopePos)
 					}
 
 				default:
@@ -405,7 +435,11 @@ func (check *Checker) declStmt(decl ast.Decl) {
 
 			case *ast.TypeSpec:
 				obj := NewTypeName(s.Name.Pos(), pkg, s.Name.Name, nil)
-				check.declare(check.scope, s.Name, obj)
+				check.recordDoc(obj, specDoc(d, s.Doc))
+				// spec: "The scope of a type identifier declared inside a function
+				// begins at the identifier in the TypeSpec and ends at the end of
+				// the innermost containing block."
+				check.declare(check.scope, s.Name, obj, s.Name.Pos())
 				check.typeDecl(obj, s.Type, nil, nil)
 
 			default:
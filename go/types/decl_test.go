@@ -0,0 +1,74 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestBodylessFuncs(t *testing.T) {
+	// Functions and methods implemented in assembly have no body;
+	// that is not an error, even if they have results.
+	const src = `package p
+type T struct{}
+func f(x int) (y int)
+func (T) m() bool
+func g() int { return f(0) }
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pkg.Scope().Lookup("f").Type().String(), "func(x int) (y int)"; got != want {
+		t.Errorf("got f of type %s; want %s", got, want)
+	}
+}
+
+func TestDeclared(t *testing.T) {
+	const src = `package p
+
+var x = y + 1
+var y = len(z)
+var z []T
+
+type T struct{ f int }
+
+func (T) m() {}
+
+const c = "a" + 1
+
+func f() { undefined() }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decls []string
+	conf := Config{
+		Error: func(error) {},
+		Declared: func(obj Object, nerrors int) {
+			if obj.Type() == nil {
+				t.Errorf("%s has no type", obj.Name())
+			}
+			decls = append(decls, fmt.Sprintf("%s:%d", obj.Name(), nerrors))
+		},
+	}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+
+	// dependencies are declared first; the error in f's body
+	// is reported after the last declaration
+	want := "[m:0 T:0 z:0 y:0 x:0 c:1 f:1]"
+	if got := fmt.Sprint(decls); got != want {
+		t.Errorf("got declarations %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+// An ErrorCode classifies a type-checking error. Every Error reported
+// by the checker carries a code other than UnknownError; clients may
+// use it to distinguish kinds of errors without matching error messages.
+//
+// Error codes are stable: new codes may be added in the future, but
+// the existing codes keep their values and meaning. Error messages,
+// on the other hand, may change at any time.
+type ErrorCode int
+
+// The error codes are grouped by the language construct they pertain to.
+const (
+	// UnknownError is the zero ErrorCode; it is never reported.
+	UnknownError ErrorCode = iota
+
+	// InvalidSyntaxTree indicates an ill-formed AST, such as one the
+	// parser produces only after reporting a syntax error, or syntax
+	// the checker does not know how to handle.
+	InvalidSyntaxTree
+
+	// Package clauses and imports.
+	MismatchedPkgName // package name differs from other files, or is _
+	BrokenImport      // import path is invalid or the import failed
+	UnusedImport      // imported package is not used (soft)
+
+	// Declarations.
+	DuplicateDecl     // object redeclared in the same block or file
+	DuplicateMethod   // method declared twice, or clashes with a field
+	InvalidInitDecl   // init declared as non-function, or with a signature
+	InvalidDeclCycle  // declaration refers to itself, directly or indirectly
+	InvalidInitCycle  // package-level variable initialization cycle
+	WrongInitCount    // number of initialization expressions doesn't match
+	InvalidConstType  // constant type is not a basic type
+	InvalidConstInit  // constant initialized with a non-constant value
+	InvalidRecv       // malformed method receiver
+	InvalidMethodDecl // method without (unique) receiver or with blank name
+	InvalidEmbedded   // embedded field or interface of invalid type
+	MissingFuncBody   // function declared without body (soft)
+	UnusedVar         // local variable is declared but not used (soft)
+	NoNewVar          // short variable declaration declares no new variable
+	InvalidStructTag  // struct tag not in the reflect.StructTag format (soft)
+
+	// Names and types.
+	UndeclaredName         // identifier is not declared
+	UndeclaredImportedName // qualified identifier not declared by its package
+	UnexportedName         // qualified identifier is not exported
+	InvalidBlank           // blank identifier used as value or type
+	InvalidIota            // iota used outside constant declaration
+	InvalidPkgUse          // package name used outside of a selector
+	NotAType               // expression denotes a value where a type is required
+	NotAnExpr              // expression denotes a type, built-in, or no value
+	InvalidArrayLen        // array length is not a non-negative integer constant
+	InvalidMapKey          // map key type is not comparable
+
+	// Operands and expressions.
+	TooManyValues        // multi-valued expression used in single-value context
+	UntypedNilUse        // untyped nil used where a typed value is required
+	ImpossibleConversion // conversion between incompatible types
+	NumericOverflow      // constant overflows or is truncated by its type
+	UndefinedOp          // operator not defined for the operand type
+	MismatchedTypes      // binary operands of different types
+	IncomparableOperand  // comparison of values that cannot be compared
+	DivByZero            // division by constant zero (soft unless constant)
+	InvalidShiftOperand  // shifted operand is not an integer
+	InvalidShiftCount    // shift count is not a non-negative integer, or is too large
+	UnaddressableOperand // address of operand cannot be taken
+	InvalidReceive       // receive from non-channel or send-only channel
+	InvalidSend          // send to non-channel or receive-only channel
+	InvalidIndirection   // indirection of non-pointer
+	InvalidIndex         // index is not an integer, is negative, or out of bounds
+	NonIndexableOperand  // indexing of a value that cannot be indexed
+	InvalidSliceExpr     // malformed slice expression or slicing of invalid operand
+	InvalidAssert        // type assertion on non-interface value
+	ImpossibleAssert     // asserted type cannot have the interface type
+	MissingFieldOrMethod // selector denotes no field or method
+	AmbiguousSelector    // selector denotes several fields or methods at the same depth
+	InvalidMethodExpr    // method is not in the method set of the receiver type
+
+	// Composite literals.
+	InvalidLit         // composite literal of invalid or missing type
+	InvalidLitIndex    // array or slice literal index is not a valid constant
+	DuplicateLitKey    // duplicate index, key, or field in a literal
+	MissingLitKey      // map literal element without key
+	MixedStructLit     // struct literal mixes keyed and positional elements
+	InvalidLitField    // struct literal names an unknown or invalid field
+	WrongLitValueCount // positional struct literal has too many or too few values
+
+	// Assignments.
+	IncompatibleAssign  // value is not assignable to the target type
+	UnassignableOperand // left-hand side cannot be assigned to
+	WrongAssignCount    // number of values doesn't match number of variables
+	WrongResultCount    // number of return values doesn't match the signature
+	OutOfScopeResult    // result parameter shadowed at a bare return
+
+	// Calls.
+	InvalidCall       // call of non-function
+	WrongArgCount     // too many or too few arguments
+	InvalidDotDotDot  // invalid use of ...
+	InvalidBuiltinArg // invalid argument to a built-in function
+
+	// Statements.
+	MissingReturn     // function with results does not end in a terminating statement
+	UnusedResult      // expression is not used, or is not allowed in statement context
+	InvalidCond       // non-boolean condition
+	DuplicateDefault  // multiple default cases in switch or select
+	DuplicateCase     // duplicate case in type switch
+	MisplacedBranch   // break, continue, or fallthrough out of place
+	InvalidLabel      // undefined, misplaced, or ill-used label
+	DuplicateLabel    // label already declared (soft)
+	UnusedLabel       // label declared but not used (soft)
+	JumpOverDecl      // goto jumps over variable declaration (soft)
+	InvalidRangeExpr  // range over invalid operand
+	InvalidIterVar    // invalid iteration variable
+	InvalidSelectCase // select case is not a send or receive
+	InvalidPostDecl   // declaration in for statement post statement (soft)
+
+	// Miscellaneous.
+	ImplementationLimit // program exceeds an implementation limit
+	AnalyzerDiagnostic  // reported by a Config.Analyzers function (soft)
+	AssertionFailed     // assert built-in failed (testing only)
+	InternalError       // checker failure, usually caused by malformed imported packages
+)
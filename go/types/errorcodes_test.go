@@ -0,0 +1,60 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestErrorCodes(t *testing.T) {
+	var tests = []struct {
+		src  string
+		code ErrorCode
+	}{
+		{`package p0; var _ = x`, UndeclaredName},
+		{`package p1; var s string; var _ int = s`, IncompatibleAssign},
+		{`package p2; var _ = int("foo")`, ImpossibleConversion},
+		{`package p3; var _ uint8 = 256`, NumericOverflow},
+		{`package p4; var a int; var b string; var _ = a + b`, MismatchedTypes},
+		{`package p5; var _ = 1 / 0`, DivByZero},
+		{`package p6; const x, y = 1`, WrongInitCount},
+		{`package p6a; var x, y = 1`, WrongAssignCount},
+		{`package p7; func f() { x := 0 }`, UnusedVar},
+		{`package p8; import "unsafe"`, UnusedImport},
+		{`package p9; func f() int {}`, MissingReturn},
+		{`package p10; var _ = struct{ x int }{y: 0}`, InvalidLitField},
+		{`package p11; type T int; func (T) m(); func (T) m()`, DuplicateMethod},
+		{`package p12; func f() { L: }`, UnusedLabel},
+		{`package p13; var x int; var _ = x.f`, MissingFieldOrMethod},
+		{`package p14; func f(int) {}; var _ = f(1, 2)`, WrongArgCount},
+		{`package p15; var _ = len(1)`, InvalidBuiltinArg},
+		{`package p16; type T [-1]int`, InvalidArrayLen},
+		{`package p17; var x = x`, InvalidInitCycle},
+		{`package p18; type T struct{ T }`, InvalidDeclCycle},
+	}
+
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", test.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var codes []ErrorCode
+		conf := Config{Error: func(err error) {
+			codes = append(codes, err.(Error).Code)
+		}}
+		conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+
+		if len(codes) == 0 || codes[0] != test.code {
+			t.Errorf("%s: got codes %v; want %d first", test.src, codes, test.code)
+		}
+	}
+}
@@ -13,14 +13,30 @@ import (
 	"strings"
 )
 
+// An internalError is the panic value raised when the checker detects
+// an inconsistency; it is reported as an error of kind InternalError
+// (see Checker.handleBailout).
+type internalError string
+
+func (err internalError) Error() string { return string(err) }
+
 func assert(p bool) {
 	if !p {
-		panic("assertion failed")
+		panic(internalError("assertion failed"))
 	}
 }
 
 func unreachable() {
-	panic("unreachable")
+	panic(internalError("unreachable"))
+}
+
+// qualifier qualifies the names of objects that do not belong
+// to the package being checked by their package path.
+func (check *Checker) qualifier(pkg *Package) string {
+	if pkg != check.pkg {
+		return pkg.path
+	}
+	return ""
 }
 
 func (check *Checker) sprintf(format string, args ...interface{}) string {
@@ -29,17 +45,17 @@ func (check *Checker) sprintf(format string, args ...interface{}) string {
 		case nil:
 			arg = "<nil>"
 		case operand:
-			panic("internal error: should always pass *operand")
+			panic(internalError("should always pass *operand"))
 		case *operand:
-			arg = operandString(check.pkg, a)
+			arg = operandString(a, check.qualifier)
 		case token.Pos:
 			arg = check.fset.Position(a).String()
 		case ast.Expr:
 			arg = ExprString(a)
 		case Object:
-			arg = ObjectString(check.pkg, a)
+			arg = ObjectString(a, check.qualifier)
 		case Type:
-			arg = TypeString(check.pkg, a)
+			arg = TypeString(a, check.qualifier)
 		}
 		args[i] = arg
 	}
@@ -59,8 +75,12 @@ func (check *Checker) dump(format string, args ...interface{}) {
 	fmt.Println(check.sprintf(format, args...))
 }
 
-func (check *Checker) err(pos token.Pos, msg string, soft bool) {
-	err := Error{check.fset, pos, msg, soft}
+func (check *Checker) err(pos token.Pos, code ErrorCode, msg string, soft bool) {
+	err := Error{check.fset, pos, msg, soft, code}
+	if check.buffered {
+		check.buffer = append(check.buffer, err)
+		return
+	}
 	if check.firstErr == nil {
 		check.firstErr = err
 	}
@@ -69,28 +89,39 @@ func (check *Checker) err(pos token.Pos, msg string, soft bool) {
 		panic(bailout{}) // report only first error
 	}
 	f(err)
+	check.errors++
+	if max := check.conf.MaxErrors; max > 0 && check.errors >= max {
+		panic(bailout{}) // too many errors
+	}
 }
 
-func (check *Checker) error(pos token.Pos, msg string) {
-	check.err(pos, msg, false)
+func (check *Checker) error(pos token.Pos, code ErrorCode, msg string) {
+	check.err(pos, code, msg, false)
 }
 
-func (check *Checker) errorf(pos token.Pos, format string, args ...interface{}) {
-	check.err(pos, check.sprintf(format, args...), false)
+func (check *Checker) errorf(pos token.Pos, code ErrorCode, format string, args ...interface{}) {
+	check.err(pos, code, check.sprintf(format, args...), false)
 }
 
-func (check *Checker) softErrorf(pos token.Pos, format string, args ...interface{}) {
-	check.err(pos, check.sprintf(format, args...), true)
+func (check *Checker) softErrorf(pos token.Pos, code ErrorCode, format string, args ...interface{}) {
+	check.err(pos, code, check.sprintf(format, args...), true)
 }
 
 func (check *Checker) invalidAST(pos token.Pos, format string, args ...interface{}) {
-	check.errorf(pos, "invalid AST: "+format, args...)
+	check.errorf(pos, InvalidSyntaxTree, "invalid AST: "+format, args...)
+}
+
+func (check *Checker) invalidArg(pos token.Pos, code ErrorCode, format string, args ...interface{}) {
+	check.errorf(pos, code, "invalid argument: "+format, args...)
 }
 
-func (check *Checker) invalidArg(pos token.Pos, format string, args ...interface{}) {
-	check.errorf(pos, "invalid argument: "+format, args...)
+func (check *Checker) invalidOp(pos token.Pos, code ErrorCode, format string, args ...interface{}) {
+	check.errorf(pos, code, "invalid operation: "+format, args...)
 }
 
-func (check *Checker) invalidOp(pos token.Pos, format string, args ...interface{}) {
-	check.errorf(pos, "invalid operation: "+format, args...)
+// unsupported reports an AST node kind the type-checker doesn't know
+// how to handle (for instance, syntax introduced by a newer parser).
+func (check *Checker) unsupported(pos token.Pos, node ast.Node) {
+	// format node's type here since sprintf converts ast.Exprs to strings
+	check.error(pos, InvalidSyntaxTree, fmt.Sprintf("unsupported syntax %T", node))
 }
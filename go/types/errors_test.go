@@ -0,0 +1,369 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestErrorCallback(t *testing.T) {
+	const src = `package p
+var _ int = "foo"
+func f() {
+	x := 0
+	undeclared()
+}
+var _ = 1 + true
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With an Error callback, all errors are reported.
+	var errors []Error
+	conf := Config{Error: func(err error) { errors = append(errors, err.(Error)) }}
+	_, first := conf.Check("p", fset, []*ast.File{f}, nil)
+
+	var got []string
+	for _, err := range errors {
+		s := err.Error()
+		if err.Soft {
+			s += " (soft)"
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		`p.go:2:13: cannot convert "foo" (untyped string constant) to int`,
+		`p.go:7:9: cannot convert 1 (untyped int constant) to untyped bool`,
+		`p.go:5:2: undeclared name: undeclared`, // function bodies are checked last
+		`p.go:4:2: x declared but not used (soft)`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("error %d: got %q; want %q", i, got[i], want[i])
+		}
+	}
+	if first == nil || first.Error() != errors[0].Error() {
+		t.Errorf("Check returned %v; want first error %v", first, errors[0])
+	}
+
+	// Without an Error callback, checking stops at the first error.
+	conf.Error = nil
+	_, err = conf.Check("p", fset, []*ast.File{f}, nil)
+	if err == nil || err.Error() != errors[0].Error() {
+		t.Errorf("Check returned %v; want %v", err, errors[0])
+	}
+
+	// The returned error is an Error whose position maps back to the source.
+	if e, ok := err.(Error); !ok {
+		t.Errorf("Check returned %T; want Error", err)
+	} else if pos := e.Fset.Position(e.Pos); pos.Line != 2 || pos.Column != 13 || e.Soft {
+		t.Errorf("got error at %s (soft = %v); want hard error at p.go:2:13", pos, e.Soft)
+	}
+}
+
+func TestIllFormedAST(t *testing.T) {
+	// Each source contains errors (syntax errors, yielding partial ASTs
+	// with ast.Bad* nodes, or type errors). The checker must continue
+	// to record the objects defined or used by every identifier y, z,
+	// and k; and k must be of invalid type.
+	sources := []string{
+		`package p; func f() { var y int; for k := range { _, _ = k, y } }`,
+		`package p; func f() { var y int; for k, _ := range y { _, _ = k, y } }`,
+		`package p; func f() { var y int; for k := range undeclared { _, _ = k, y } }`,
+		`package p; func f() { var y int; switch z := y.(type) { case int: _, _ = z, y } }`,
+		`package p; func f() { var y int; switch z := undeclared.(type) { case int, string: _, _ = z, y } }`,
+		`package p; func f() { var y int; switch undeclared { case y: } }`,
+		`package p; func f() { var y int; switch { case y, y: } }`,
+		`package p; func f() { var y int; _ = T{f: y, y} }`,
+		`package p; func f() { var y int; _ = undeclared[y] }`,
+		`package p; func f() { var y int; _ = y[y] }`,
+		`package p; func f() { var y int; _ = y[y:y] }`,
+		`package p; func f() { var y int; _ = y.(y) }`,
+		`package p; func f() { var y int; y(y, y) }`,
+		`package p; func f() { var y int; _ = len(y, y) }`,
+		`package p; func f() { var y int; _ = len(undeclared, y) }`,
+		`package p; func f() { var y int; _ = y + ; _ = y }`,
+		`package p; func f() { var y = ; _ = y }`,
+		`package p; func f() { var y int; if { _ = y } }`,
+	}
+
+	for _, src := range sources {
+		fset := token.NewFileSet()
+		f, _ := parser.ParseFile(fset, "p.go", src, parser.AllErrors)
+		if f == nil {
+			t.Fatalf("%s: no AST", src)
+		}
+
+		info := Info{
+			Defs: make(map[*ast.Ident]Object),
+			Uses: make(map[*ast.Ident]Object),
+		}
+		conf := Config{Error: func(error) {}}
+		conf.Check("p", fset, []*ast.File{f}, &info) // ignore errors
+
+		ast.Inspect(f, func(n ast.Node) bool {
+			id, _ := n.(*ast.Ident)
+			if id == nil || id.Name != "y" && id.Name != "z" && id.Name != "k" {
+				return true
+			}
+			obj := info.Defs[id]
+			if obj == nil {
+				obj = info.Uses[id]
+			}
+			if obj == nil {
+				if _, found := info.Defs[id]; !found || id.Name != "z" {
+					// (a type switch lhs z is recorded with a nil object)
+					t.Errorf("%s: %s at %s: no object recorded", src, id.Name, fset.Position(id.Pos()))
+				}
+				return true
+			}
+			if id.Name == "k" && obj.Type() != Typ[Invalid] {
+				t.Errorf("%s: %s at %s: got type %s; want invalid type", src, id.Name, fset.Position(id.Pos()), obj.Type())
+			}
+			return true
+		})
+	}
+}
+
+func TestUnusedSoftErrors(t *testing.T) {
+	const src = `package p
+import "unsafe"
+import u "unsafe"
+import . "unsafe"
+func f(x interface{}) {
+	v := 0
+	switch t := x.(type) {}
+	var _ int = nil
+}`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	conf := Config{Error: func(err error) {
+		e := err.(Error)
+		s := e.Error()
+		if e.Soft {
+			s += " (soft)"
+		}
+		got = append(got, s)
+	}}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+
+	want := []string{
+		`p.go:8:14: cannot convert nil (untyped nil value) to int`,
+		`p.go:6:2: v declared but not used (soft)`,
+		`p.go:7:9: t declared but not used (soft)`,
+		`p.go:2:8: "unsafe" imported but not used (soft)`,
+		`p.go:3:8: "unsafe" imported but not used as u (soft)`,
+		`p.go:4:8: "unsafe" imported but not used (soft)`,
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestErrorOrder(t *testing.T) {
+	const src = `package p
+
+import (
+	"a"
+	"b"
+	. "c"
+	. "d"
+	e "e"
+)
+
+func f() {
+	var x, y, z int
+	{
+		var u, v int
+	}
+L1:
+L2:
+L3:
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{
+		Import: func(imports map[string]*Package, path string) (*Package, error) {
+			pkg := NewPackage(path, path)
+			pkg.MarkComplete()
+			return pkg, nil
+		},
+	}
+
+	// errors of each kind are reported in source order
+	want := []string{
+		"p.go:16:1: label L1 declared but not used",
+		"p.go:17:1: label L2 declared but not used",
+		"p.go:18:1: label L3 declared but not used",
+		"p.go:12:6: x declared but not used",
+		"p.go:12:9: y declared but not used",
+		"p.go:12:12: z declared but not used",
+		"p.go:14:7: u declared but not used",
+		"p.go:14:10: v declared but not used",
+		`p.go:4:2: "a" imported but not used`,
+		`p.go:5:2: "b" imported but not used`,
+		`p.go:6:2: "c" imported but not used`,
+		`p.go:7:2: "d" imported but not used`,
+		`p.go:8:2: "e" imported but not used`,
+	}
+	for i := 0; i < 10; i++ {
+		var errors []string
+		conf.Error = func(err error) {
+			errors = append(errors, err.Error())
+		}
+		conf.Check("p", fset, []*ast.File{f}, nil)
+		if fmt.Sprint(errors) != fmt.Sprint(want) {
+			t.Fatalf("run %d: got errors %q; want %q", i, errors, want)
+		}
+	}
+}
+
+func TestInternalError(t *testing.T) {
+	// malformed imported packages: V and T have no type
+	for _, test := range []struct {
+		obj func(lib *Package) Object
+		src string
+	}{
+		{func(lib *Package) Object { return NewVar(token.NoPos, lib, "V", nil) }, `var _ = lib.V + 1`},
+		{func(lib *Package) Object { return NewTypeName(token.NoPos, lib, "T", nil) }, `var _ lib.T`},
+	} {
+		lib := NewPackage("lib", "lib")
+		lib.Scope().Insert(test.obj(lib))
+		lib.MarkComplete()
+
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", `package p; import "lib"; `+test.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reported []error
+		conf := Config{
+			Import: func(map[string]*Package, string) (*Package, error) { return lib, nil },
+			Error:  func(err error) { reported = append(reported, err) },
+		}
+		_, err = conf.Check("p", fset, []*ast.File{f}, nil)
+		e, ok := err.(Error)
+		if !ok || e.Code != InternalError || !strings.HasPrefix(e.Msg, "internal error: ") {
+			t.Errorf("%s: got error %v; want internal error", test.src, err)
+			continue
+		}
+		if len(reported) != 1 || reported[0] != err {
+			t.Errorf("%s: got reported errors %v; want %v", test.src, reported, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	fset := token.NewFileSet()
+	good, err := parser.ParseFile(fset, "good.go", `package p; var x int = y + 1; var _ = x`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad, err := parser.ParseFile(fset, "bad.go", `package p; var y = 1; funcc z() {}`, 0)
+	if err == nil {
+		t.Fatal("no syntax error")
+	}
+
+	var errs []Error
+	conf := Config{Error: func(err error) { errs = append(errs, err.(Error)) }}
+	info := &Info{Defs: make(map[*ast.Ident]Object)}
+	files := []*ast.File{good, bad, nil} // nil: a file that could not be parsed
+	if _, err := conf.Check("p", fset, files, info); err != nil {
+		t.Fatal(err)
+	}
+	for id, obj := range info.Defs {
+		if id.Name == "x" && obj.Type() != Typ[Int] {
+			t.Errorf("x has type %s; want int", obj.Type())
+		}
+	}
+
+	// Undeclared names may be declared by the unparsed declarations;
+	// they are reported as soft errors.
+	f, _ := parser.ParseFile(fset, "bad2.go", `package p; funcc z() {}; var _ = undeclared`, 0)
+	errs = nil
+	conf.Check("p", fset, []*ast.File{good, bad, f}, nil)
+	if len(errs) != 1 || !errs[0].Soft || errs[0].Code != UndeclaredName {
+		t.Errorf("got errors %v; want soft undeclared name error", errs)
+	}
+
+	// Without syntax errors, undeclared names are hard errors.
+	f, _ = parser.ParseFile(fset, "ok.go", `package p; var _ = undeclared`, 0)
+	errs = nil
+	conf.Check("p", fset, []*ast.File{f}, nil)
+	if len(errs) != 1 || errs[0].Soft {
+		t.Errorf("got errors %v; want hard undeclared name error", errs)
+	}
+}
+
+func TestDivAndShiftErrors(t *testing.T) {
+	var tests = []struct {
+		expr string
+		code ErrorCode
+		soft bool
+	}{
+		{`1 / 0`, DivByZero, false},
+		{`i / 0`, DivByZero, true},
+		{`i % (1 - 1)`, DivByZero, true},
+		{`f / 0`, 0, false}, // no error for floats
+		{`1 << -1`, InvalidShiftCount, false},
+		{`1 << 1.5`, InvalidShiftCount, false},
+		{`1 << 10000`, InvalidShiftCount, false},
+		{`i << -1`, InvalidShiftCount, true},
+		{`i << 1.5`, InvalidShiftCount, false},
+		{`i << "s"`, InvalidShiftCount, false},
+		{`i << f`, InvalidShiftCount, false},
+		{`i << 2.0`, 0, false},
+	}
+
+	for _, test := range tests {
+		src := "package p; var i int; var f float64; var _ = " + test.expr
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var errs []Error
+		conf := Config{Error: func(err error) {
+			errs = append(errs, err.(Error))
+		}}
+		conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+
+		if test.code == 0 {
+			if len(errs) > 0 {
+				t.Errorf("%s: unexpected error: %s", test.expr, errs[0])
+			}
+			continue
+		}
+		if len(errs) != 1 || errs[0].Code != test.code || errs[0].Soft != test.soft {
+			t.Errorf("%s: got %v; want one error with code %d (soft = %v)", test.expr, errs, test.code, test.soft)
+		}
+	}
+}
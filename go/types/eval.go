@@ -2,25 +2,27 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// This file implements New, Eval and EvalNode.
+// This file implements New, Eval, EvalNode, and CheckExpr.
 
 package types
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"sort"
 )
 
 // New is a convenience function to create a new type from a given
 // expression or type literal string evaluated in Universe scope.
-// New(str) is shorthand for Eval(str, nil, nil), but only returns
-// the type result, and panics in case of an error.
+// New(str) is shorthand for Eval(token.NewFileSet(), nil, token.NoPos, str),
+// but only returns the type result, and panics in case of an error.
 // Position info for objects in the result type is undefined.
 //
 func New(str string) Type {
-	tv, err := Eval(str, nil, nil)
+	tv, err := Eval(token.NewFileSet(), nil, token.NoPos, str)
 	if err != nil {
 		panic(err)
 	}
@@ -28,18 +30,21 @@ func New(str string) Type {
 }
 
 // Eval returns the type and, if constant, the value for the
-// expression or type literal string str evaluated in scope.
-// If the expression contains function literals, the function
-// bodies are ignored (though they must be syntactically correct).
+// expression expr, evaluated at position pos of package pkg,
+// which must have been derived from type-checking an AST with
+// complete position information relative to the provided file
+// set.
+//
+// If the expression contains function literals, their bodies
+// are ignored (i.e., the bodies are not type-checked).
 //
 // If pkg == nil, the Universe scope is used and the provided
-// scope is ignored. Otherwise, the scope must belong to the
-// package (either the package scope, or nested within the
-// package scope).
+// position pos is ignored. If pkg != nil, and pos is invalid,
+// the package scope is used. Otherwise, pos must belong to the
+// package.
 //
-// An error is returned if the scope is incorrect, the string
-// has syntax errors, or if it cannot be evaluated in the scope.
-// Position info for objects in the result type is undefined.
+// An error is returned if pos is not within the package or
+// if the node cannot be evaluated.
 //
 // Note: Eval should not be used instead of running Check to compute
 // types and values, but in addition to Check. Eval will re-evaluate
@@ -48,22 +53,47 @@ func New(str string) Type {
 // level untyped constants will return an untyped type rather then the
 // respective context-specific type.
 //
-func Eval(str string, pkg *Package, scope *Scope) (TypeAndValue, error) {
-	node, err := parser.ParseExpr(str)
+func Eval(fset *token.FileSet, pkg *Package, pos token.Pos, expr string) (tv TypeAndValue, err error) {
+	// determine scope
+	var scope *Scope
+	if pkg == nil {
+		scope = Universe
+		pos = token.NoPos
+	} else if !pos.IsValid() {
+		scope = pkg.scope
+	} else {
+		// The package scope has no extent - consider
+		// the package's file scopes instead.
+		scope = pkg.scope.Innermost(pos)
+		if scope == nil {
+			return TypeAndValue{}, fmt.Errorf("no position %s found in package %s", fset.Position(pos), pkg.name)
+		}
+	}
+
+	// parse expression
+	node, err := parser.ParseExprFrom(fset, "eval", expr, 0)
 	if err != nil {
 		return TypeAndValue{}, err
 	}
 
-	// Create a file set that looks structurally identical to the
-	// one created by parser.ParseExpr for correct error positions.
-	fset := token.NewFileSet()
-	fset.AddFile("", len(str), fset.Base()).SetLinesForContent([]byte(str))
+	// initialize checker
+	check := NewChecker(nil, fset, pkg, nil)
+	check.scope = scope
+	check.pos = pos
+	defer check.handleBailout(&err)
 
-	return EvalNode(fset, node, pkg, scope)
+	// evaluate node
+	var x operand
+	check.rawExpr(&x, node, nil)
+	return TypeAndValue{x.mode, x.typ, x.val}, nil
 }
 
-// EvalNode is like Eval but instead of string it accepts
-// an expression node and respective file set.
+// EvalNode is like Eval but instead of an expression string and
+// a position it accepts an expression node and a scope in which
+// the node is evaluated. The scope must belong to the package
+// (either the package scope, or nested within the package scope),
+// or be ignored if pkg == nil. All objects in the scope and its
+// parents are visible, independent of their declaration position.
 //
 // An error is returned if the scope is incorrect
 // if the node cannot be evaluated in the scope.
@@ -93,3 +123,145 @@ func EvalNode(fset *token.FileSet, node ast.Expr, pkg *Package, scope *Scope) (t
 	check.rawExpr(&x, node, nil)
 	return TypeAndValue{x.mode, x.typ, x.val}, nil
 }
+
+// CheckExpr type-checks the expression expr in an environment consisting
+// of the Universe scope, the variables in env (indexed by name), and any
+// packages referred to by qualified identifiers. A qualified identifier
+// pkg.Name whose package identifier is not declared in env or Universe
+// denotes a package imported before (by importer, such as a dependency
+// of another package) with that name, or else it is resolved by calling
+// importer with the identifier as import path; if that fails, a package
+// with that name imported as a dependency of the other packages of expr
+// is used. The result doesn't depend on the order of the identifiers in
+// expr. An import error is reported like any other error. The unsafe
+// package is always available. If importer is nil, DefaultImport is used.
+//
+// The result is the type and, if constant, the value of expr. If info
+// is provided, it is filled in for expr and its sub-expressions as if
+// expr were type-checked as part of a package. Errors are reported at
+// positions within expr in the provided file set; if there is more
+// than one error, only the first one is returned. As for EvalNode,
+// the bodies of function literals are not checked.
+//
+func CheckExpr(fset *token.FileSet, env map[string]Type, importer Importer, expr ast.Expr, info *Info) (tv TypeAndValue, err error) {
+	if importer == nil {
+		importer = DefaultImport
+	}
+
+	// Use a synthetic package; its scope holds the environment,
+	// and a nested file scope holds the imported packages.
+	pkg := NewPackage("", "expr")
+	for name, typ := range env {
+		pkg.scope.Insert(NewVar(token.NoPos, pkg, name, typ))
+	}
+	scope := NewScope(pkg.scope, token.NoPos, token.NoPos, "expr")
+
+	conf := &Config{Import: importer}
+	check := NewChecker(conf, fset, pkg, info)
+	check.scope = scope
+	defer check.handleBailout(&err)
+
+	// declare imported packages
+	check.declareExprImports(scope, expr)
+
+	// evaluate expr
+	var x operand
+	check.rawExpr(&x, expr, nil)
+	check.recordUntyped()
+	return TypeAndValue{x.mode, x.typ, x.val}, nil
+}
+
+// declareExprImports declares, in scope, the packages denoted by the
+// package identifiers of the qualified identifiers in expr. So that the
+// result doesn't depend on the order of the identifiers in expr, all
+// packages are imported, in the order of their names, before any
+// identifier is resolved: an identifier denotes the package imported
+// with that import path or else a package with that name imported
+// before, including the dependencies of the packages imported for expr.
+// If there are several packages with that name, the one with the
+// smallest path is chosen. The unsafe package is always available.
+// An import error is reported if the identifier denotes no package.
+func (check *Checker) declareExprImports(scope *Scope, expr ast.Expr) {
+	// collect the package identifiers, in source order
+	var idents []*ast.Ident
+	seen := make(map[string]bool)
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, _ := n.(*ast.SelectorExpr); sel != nil {
+			if ident, _ := sel.X.(*ast.Ident); ident != nil && !seen[ident.Name] {
+				seen[ident.Name] = true
+				if _, obj := scope.LookupParent(ident.Name, token.NoPos); obj == nil {
+					idents = append(idents, ident)
+				}
+			}
+		}
+		return true
+	})
+
+	// import the packages not imported before by name
+	packages := check.conf.Packages
+	var names []string
+	for _, ident := range idents {
+		if name := ident.Name; name != "unsafe" && packageNamed(packages, name) == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	imported := make(map[string]*Package)
+	errs := make(map[string]error)
+	for _, name := range names {
+		imp, err := check.conf.Import(packages, name)
+		if imp == nil && err == nil {
+			err = errors.New("Config.Import returned nil but no error")
+		}
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+		if packages[imp.path] == nil {
+			packages[imp.path] = imp
+		}
+		imported[name] = imp
+	}
+
+	// resolve the identifiers
+	for _, ident := range idents {
+		name := ident.Name
+		imp := imported[name]
+		switch {
+		case name == "unsafe":
+			imp = Unsafe
+		case imp == nil || imp.name != name:
+			if p := packageNamed(packages, name); p != nil {
+				imp = p
+			}
+		}
+		if imp == nil {
+			check.errorf(ident.Pos(), BrokenImport, "could not import %s (%s)", name, errs[name])
+			continue
+		}
+		if imp.name != name {
+			continue // reported as undeclared name
+		}
+		scope.Insert(NewPkgName(token.NoPos, check.pkg, name, imp))
+	}
+}
+
+// packageNamed returns the package with the given name in packages:
+// the one with that path or else the one with the smallest path.
+// The result is nil if there is no such package.
+func packageNamed(packages map[string]*Package, name string) *Package {
+	if imp := packages[name]; imp != nil && imp.name == name {
+		return imp
+	}
+	var paths []string
+	for path, imp := range packages {
+		if imp.name == name {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths)
+	return packages[paths[0]]
+}
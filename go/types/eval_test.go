@@ -7,6 +7,7 @@
 package types_test
 
 import (
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -17,8 +18,8 @@ import (
 	. "golang.org/x/tools/go/types"
 )
 
-func testEval(t *testing.T, pkg *Package, scope *Scope, str string, typ Type, typStr, valStr string) {
-	gotTv, err := Eval(str, pkg, scope)
+func testEval(t *testing.T, fset *token.FileSet, pkg *Package, pos token.Pos, str string, typ Type, typStr, valStr string) {
+	gotTv, err := Eval(fset, pkg, pos, str)
 	if err != nil {
 		t.Errorf("Eval(%q) failed: %s", str, err)
 		return
@@ -56,13 +57,13 @@ func testEval(t *testing.T, pkg *Package, scope *Scope, str string, typ Type, ty
 
 func TestEvalBasic(t *testing.T) {
 	for _, typ := range Typ[Bool : String+1] {
-		testEval(t, nil, nil, typ.Name(), typ, "", "")
+		testEval(t, token.NewFileSet(), nil, token.NoPos, typ.Name(), typ, "", "")
 	}
 }
 
 func TestEvalComposite(t *testing.T) {
 	for _, test := range independentTestTypes {
-		testEval(t, nil, nil, test.src, nil, test.str, "")
+		testEval(t, token.NewFileSet(), nil, token.NoPos, test.src, nil, test.str, "")
 	}
 }
 
@@ -78,7 +79,7 @@ func TestEvalArith(t *testing.T) {
 		`len([10]struct{}{}) == 2*5`,
 	}
 	for _, test := range tests {
-		testEval(t, nil, nil, test, Typ[UntypedBool], "", "true")
+		testEval(t, token.NewFileSet(), nil, token.NoPos, test, Typ[UntypedBool], "", "true")
 	}
 }
 
@@ -109,17 +110,8 @@ func f(a int, s string) float64 {
 		t.Fatal(err)
 	}
 
-	pkgScope := pkg.Scope()
-	if n := pkgScope.NumChildren(); n != 1 {
-		t.Fatalf("got %d file scopes, want 1", n)
-	}
-
-	fileScope := pkgScope.Child(0)
-	if n := fileScope.NumChildren(); n != 1 {
-		t.Fatalf("got %d functions scopes, want 1", n)
-	}
-
-	funcScope := fileScope.Child(0)
+	// evaluate at the return statement of f
+	pos := file.Pos() + token.Pos(strings.Index(src, "return"))
 
 	var tests = []string{
 		`true => true, untyped bool`,
@@ -137,7 +129,51 @@ func f(a int, s string) float64 {
 	for _, test := range tests {
 		str, typ := split(test, ", ")
 		str, val := split(str, "=>")
-		testEval(t, pkg, funcScope, str, nil, typ, val)
+		testEval(t, fset, pkg, pos, str, nil, typ, val)
+	}
+}
+
+func TestEvalPos(t *testing.T) {
+	src := `
+package p
+var x int
+func f() {
+	/* x int */
+	x := "foo"
+	/* x string */
+	{
+		x := 1.0
+		/* x float64 */
+		_ = x
+	}
+	/* x string */
+	_ = x
+}
+/* x int */
+var _ = x
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := Check("p", fset, []*ast.File{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each comment /* x T */ states the type of x at the comment's position
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			typ := strings.TrimSpace(c.Text[len("/* x ") : len(c.Text)-len("*/")])
+			testEval(t, fset, pkg, c.Pos(), "x", nil, typ, "")
+		}
+	}
+
+	// positions outside the package are reported
+	if _, err := Eval(fset, pkg, file.End()+100, "x"); err == nil {
+		t.Errorf("Eval at position outside package succeeded")
 	}
 }
 
@@ -146,3 +182,132 @@ func split(s, sep string) (string, string) {
 	i := strings.Index(s, sep)
 	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+len(sep):])
 }
+
+func TestCheckExpr(t *testing.T) {
+	sources := map[string]string{
+		"users": `package users
+			type User struct {
+				Name string
+				Age  int
+			}
+			func (u *User) IsAdult() bool { return u.Age >= 18 }`,
+		"strs": `package strs
+			const Max = 10
+			func HasPrefix(s, prefix string) bool { return len(s) >= len(prefix) && s[:len(prefix)] == prefix }`,
+	}
+	importer := func(imports map[string]*Package, path string) (*Package, error) {
+		if pkg := imports[path]; pkg != nil {
+			return pkg, nil
+		}
+		pkg, err := pkgFor(path, sources[path], nil)
+		if err != nil {
+			return nil, err
+		}
+		imports[path] = pkg
+		return pkg, nil
+	}
+
+	users, err := importer(make(map[string]*Package), "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]Type{
+		"user":   NewPointer(users.Scope().Lookup("User").Type()),
+		"prefix": Typ[String],
+	}
+
+	// parse returns the expression src in a file set such that
+	// the expression starts at the beginning of line 2.
+	parse := func(src string) (*token.FileSet, ast.Expr) {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "expr", "package p; var _ =\n"+src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fset, f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0]
+	}
+
+	for _, test := range []struct {
+		src      string
+		typ, val string
+	}{
+		{`user.Age > 18 && strs.HasPrefix(user.Name, prefix)`, `bool`, ``},
+		{`user.IsAdult()`, `bool`, ``},
+		{`strs.Max*2 + 1`, `untyped int`, `21`},
+		{`strs.Max*2 + len("foo")`, `int`, `23`},
+		{`unsafe.Sizeof(user.Age)`, `uintptr`, `8`},
+		{`user.Name[:len(prefix)]`, `string`, ``},
+	} {
+		fset, expr := parse(test.src)
+		info := &Info{
+			Types: make(map[ast.Expr]TypeAndValue),
+			Uses:  make(map[*ast.Ident]Object),
+		}
+		tv, err := CheckExpr(fset, env, importer, expr, info)
+		if err != nil {
+			t.Errorf("%s: %s", test.src, err)
+			continue
+		}
+		if got := tv.Type.String(); got != test.typ {
+			t.Errorf("%s: got type %s; want %s", test.src, got, test.typ)
+		}
+		var val string
+		if tv.Value != nil {
+			val = tv.Value.String()
+		}
+		if val != test.val {
+			t.Errorf("%s: got value %s; want %s", test.src, val, test.val)
+		}
+
+		// all sub-expressions must have been recorded
+		ast.Inspect(expr, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Ident:
+				if info.Uses[n] == nil && info.Types[n].Type == nil {
+					t.Errorf("%s: no information recorded for %s", test.src, n.Name)
+				}
+			case ast.Expr:
+				if info.Types[n].Type == nil {
+					t.Errorf("%s: no type recorded for %s", test.src, ExprString(n))
+				}
+			}
+			return true
+		})
+	}
+
+	// an undeclared name is reported at its position within the expression
+	fset, expr := parse(`user.Age + undeclared*2`)
+	_, err = CheckExpr(fset, env, importer, expr, nil)
+	if want := "expr:2:12: undeclared name: undeclared"; err == nil || err.Error() != want {
+		t.Errorf("got error %v; want %s", err, want)
+	}
+
+	// packages imported before are found by name, such as the
+	// dependencies of a package; other import paths are reported
+	strs, err := importer(make(map[string]*Package), "strs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	importUsers := func(imports map[string]*Package, path string) (*Package, error) {
+		if path != "users" {
+			return nil, fmt.Errorf("can't find import: %s", path)
+		}
+		imports[path] = users
+		imports["lib/strs"] = strs // a dependency of users
+		return users, nil
+	}
+	for _, src := range []string{
+		`users.User{}.Age < strs.Max`,
+		`strs.Max > users.User{}.Age`, // independent of the operand order
+	} {
+		fset, expr = parse(src)
+		if _, err := CheckExpr(fset, env, importUsers, expr, nil); err != nil {
+			t.Errorf("%s: %s", src, err)
+		}
+	}
+	fset, expr = parse(`user.Age + nosuch.X`)
+	_, err = CheckExpr(fset, env, importUsers, expr, nil)
+	if want := "expr:2:12: could not import nosuch (can't find import: nosuch)"; err == nil || err.Error() != want {
+		t.Errorf("got error %v; want %s", err, want)
+	}
+}
@@ -7,7 +7,6 @@
 package types
 
 import (
-	"fmt"
 	"go/ast"
 	"go/token"
 	"math"
@@ -69,7 +68,7 @@ var unaryOpPredicates = opPredicates{
 func (check *Checker) op(m opPredicates, x *operand, op token.Token) bool {
 	if pred := m[op]; pred != nil {
 		if !pred(x.typ) {
-			check.invalidOp(x.pos(), "operator %s not defined for %s", op, x)
+			check.invalidOp(x.pos(), UndefinedOp, "operator %s not defined for %s", op, x)
 			return false
 		}
 	} else {
@@ -85,23 +84,23 @@ func (check *Checker) unary(x *operand, op token.Token) {
 		// spec: "As an exception to the addressability
 		// requirement x may also be a composite literal."
 		if _, ok := unparen(x.expr).(*ast.CompositeLit); !ok && x.mode != variable {
-			check.invalidOp(x.pos(), "cannot take address of %s", x)
+			check.invalidOp(x.pos(), UnaddressableOperand, "cannot take address of %s", x)
 			x.mode = invalid
 			return
 		}
 		x.mode = value
-		x.typ = &Pointer{base: x.typ}
+		x.typ = check.canonical(&Pointer{base: x.typ})
 		return
 
 	case token.ARROW:
 		typ, ok := x.typ.Underlying().(*Chan)
 		if !ok {
-			check.invalidOp(x.pos(), "cannot receive from non-channel %s", x)
+			check.invalidOp(x.pos(), InvalidReceive, "cannot receive from non-channel %s", x)
 			x.mode = invalid
 			return
 		}
 		if typ.dir == SendOnly {
-			check.invalidOp(x.pos(), "cannot receive from send-only channel %s", x)
+			check.invalidOp(x.pos(), InvalidReceive, "cannot receive from send-only channel %s", x)
 			x.mode = invalid
 			return
 		}
@@ -324,6 +323,7 @@ func (check *Checker) representable(x *operand, typ *Basic) {
 	assert(x.mode == constant)
 	if !representableConst(x.val, check.conf, typ.kind, &x.val) {
 		var msg string
+		code := ImpossibleConversion
 		if isNumeric(x.typ) && isNumeric(typ) {
 			// numeric conversion : error msg
 			//
@@ -337,10 +337,11 @@ func (check *Checker) representable(x *operand, typ *Basic) {
 			} else {
 				msg = "%s overflows %s"
 			}
+			code = NumericOverflow
 		} else {
 			msg = "cannot convert %s to %s"
 		}
-		check.errorf(x.pos(), msg, x, typ)
+		check.errorf(x.pos(), code, msg, x, typ)
 		x.mode = invalid
 	}
 }
@@ -446,7 +447,7 @@ func (check *Checker) updateExprType(x ast.Expr, typ Type, final bool) {
 	// We already know from the shift check that it is representable
 	// as an integer if it is a constant.
 	if old.isLhs && !isInteger(typ) {
-		check.invalidOp(x.Pos(), "shifted operand %s (type %s) must be integer", x, typ)
+		check.invalidOp(x.Pos(), InvalidShiftOperand, "shifted operand %s (type %s) must be integer", x, typ)
 		return
 	}
 
@@ -518,7 +519,7 @@ func (check *Checker) convertUntyped(x *operand, target Type) {
 				unreachable()
 			case UntypedNil:
 				// Unsafe.Pointer is a basic type that includes nil.
-				if !hasNil(target) {
+				if !HasNil(target) {
 					goto Error
 				}
 			default:
@@ -542,7 +543,7 @@ func (check *Checker) convertUntyped(x *operand, target Type) {
 			if !t.Empty() {
 				goto Error
 			}
-			target = defaultType(x.typ)
+			target = Default(x.typ)
 		}
 	case *Pointer, *Signature, *Slice, *Map, *Chan:
 		if !x.isNil() {
@@ -559,7 +560,7 @@ func (check *Checker) convertUntyped(x *operand, target Type) {
 	return
 
 Error:
-	check.errorf(x.pos(), "cannot convert %s to %s", x, target)
+	check.errorf(x.pos(), ImpossibleConversion, "cannot convert %s to %s", x, target)
 	x.mode = invalid
 }
 
@@ -572,7 +573,7 @@ func (check *Checker) comparison(x, y *operand, op token.Token) {
 		switch op {
 		case token.EQL, token.NEQ:
 			// spec: "The equality operators == and != apply to operands that are comparable."
-			defined = Comparable(x.typ) || x.isNil() && hasNil(y.typ) || y.isNil() && hasNil(x.typ)
+			defined = Comparable(x.typ) || x.isNil() && HasNil(y.typ) || y.isNil() && HasNil(x.typ)
 		case token.LSS, token.LEQ, token.GTR, token.GEQ:
 			// spec: The ordering operators <, <=, >, and >= apply to operands that are ordered."
 			defined = isOrdered(x.typ)
@@ -591,7 +592,7 @@ func (check *Checker) comparison(x, y *operand, op token.Token) {
 	}
 
 	if err != "" {
-		check.errorf(x.pos(), "cannot compare %s %s %s (%s)", x.expr, op, y.expr, err)
+		check.errorf(x.pos(), IncomparableOperand, "cannot compare %s %s %s (%s)", x.expr, op, y.expr, err)
 		x.mode = invalid
 		return
 	}
@@ -606,8 +607,8 @@ func (check *Checker) comparison(x, y *operand, op token.Token) {
 		// time will be materialized. Update the expression trees.
 		// If the current types are untyped, the materialized type
 		// is the respective default type.
-		check.updateExprType(x.expr, defaultType(x.typ), true)
-		check.updateExprType(y.expr, defaultType(y.typ), true)
+		check.updateExprType(x.expr, Default(x.typ), true)
+		check.updateExprType(y.expr, Default(y.typ), true)
 	}
 
 	// spec: "Comparison operators compare two operands and yield
@@ -621,7 +622,7 @@ func (check *Checker) shift(x, y *operand, op token.Token) {
 	// The lhs must be of integer type or be representable
 	// as an integer; otherwise the shift has no chance.
 	if !isInteger(x.typ) && (!untypedx || !representableConst(x.val, nil, UntypedInt, nil)) {
-		check.invalidOp(x.pos(), "shifted operand %s must be integer", x)
+		check.invalidOp(x.pos(), InvalidShiftOperand, "shifted operand %s must be integer", x)
 		x.mode = invalid
 		return
 	}
@@ -632,14 +633,25 @@ func (check *Checker) shift(x, y *operand, op token.Token) {
 	switch {
 	case isInteger(y.typ) && isUnsigned(y.typ):
 		// nothing to do
-	case isUntyped(y.typ):
+	case isUntyped(y.typ) && isNumeric(y.typ):
 		check.convertUntyped(y, Typ[UntypedInt])
 		if y.mode == invalid {
 			x.mode = invalid
 			return
 		}
+		if y.mode == constant {
+			// The conversion succeeds for untyped float constants
+			// such as 1.5; the count must be integral.
+			val, ok := exact.ToInt(y.val)
+			if !ok {
+				check.invalidOp(y.pos(), InvalidShiftCount, "shift count %s must be integer", y)
+				x.mode = invalid
+				return
+			}
+			y.val = val
+		}
 	default:
-		check.invalidOp(y.pos(), "shift count %s must be unsigned integer", y)
+		check.invalidOp(y.pos(), InvalidShiftCount, "shift count %s must be unsigned integer", y)
 		x.mode = invalid
 		return
 	}
@@ -648,9 +660,14 @@ func (check *Checker) shift(x, y *operand, op token.Token) {
 		if y.mode == constant {
 			// rhs must be within reasonable bounds
 			const stupidShift = 1023 - 1 + 52 // so we can express smallestFloat64
+			if exact.Sign(y.val) < 0 {
+				check.invalidOp(y.pos(), InvalidShiftCount, "shift count %s must not be negative", y)
+				x.mode = invalid
+				return
+			}
 			s, ok := exact.Uint64Val(y.val)
 			if !ok || s > stupidShift {
-				check.invalidOp(y.pos(), "stupid shift count %s", y)
+				check.invalidOp(y.pos(), InvalidShiftCount, "stupid shift count %s", y)
 				x.mode = invalid
 				return
 			}
@@ -662,6 +679,7 @@ func (check *Checker) shift(x, y *operand, op token.Token) {
 				x.typ = Typ[UntypedInt]
 			}
 			x.val = exact.Shift(x.val, op, uint(s))
+			check.constSize(x)
 			return
 		}
 
@@ -686,13 +704,14 @@ func (check *Checker) shift(x, y *operand, op token.Token) {
 	}
 
 	// constant rhs must be >= 0
+	// (the shift is still well-typed; the error is soft)
 	if y.mode == constant && exact.Sign(y.val) < 0 {
-		check.invalidOp(y.pos(), "shift count %s must not be negative", y)
+		check.softErrorf(y.pos(), InvalidShiftCount, "invalid operation: shift count %s must not be negative", y)
 	}
 
 	// non-constant shift - lhs must be an integer
 	if !isInteger(x.typ) {
-		check.invalidOp(x.pos(), "shifted operand %s must be integer", x)
+		check.invalidOp(x.pos(), InvalidShiftOperand, "shifted operand %s must be integer", x)
 		x.mode = invalid
 		return
 	}
@@ -755,7 +774,7 @@ func (check *Checker) binary(x *operand, lhs, rhs ast.Expr, op token.Token) {
 		// only report an error if we have valid types
 		// (otherwise we had an error reported elsewhere already)
 		if x.typ != Typ[Invalid] && y.typ != Typ[Invalid] {
-			check.invalidOp(x.pos(), "mismatched types %s and %s", x.typ, y.typ)
+			check.invalidOp(x.pos(), MismatchedTypes, "mismatched types %s and %s", x.typ, y.typ)
 		}
 		x.mode = invalid
 		return
@@ -767,7 +786,13 @@ func (check *Checker) binary(x *operand, lhs, rhs ast.Expr, op token.Token) {
 	}
 
 	if (op == token.QUO || op == token.REM) && (x.mode == constant || isInteger(x.typ)) && y.mode == constant && exact.Sign(y.val) == 0 {
-		check.invalidOp(y.pos(), "division by zero")
+		if x.mode != constant {
+			// The operation panics at run time but is well-typed:
+			// report a soft error and keep x's type.
+			check.softErrorf(y.pos(), DivByZero, "invalid operation: division by zero")
+			return
+		}
+		check.invalidOp(y.pos(), DivByZero, "division by zero")
 		x.mode = invalid
 		return
 	}
@@ -779,9 +804,10 @@ func (check *Checker) binary(x *operand, lhs, rhs ast.Expr, op token.Token) {
 			op = token.QUO_ASSIGN
 		}
 		x.val = exact.BinaryOp(x.val, op, y.val)
+		check.constSize(x)
 		// Typed constants must be representable in
 		// their type after each constant operation.
-		if isTyped(typ) {
+		if isTyped(typ) && x.mode != invalid {
 			check.representable(x, typ)
 		}
 		return
@@ -809,19 +835,19 @@ func (check *Checker) index(index ast.Expr, max int64) (i int64, valid bool) {
 
 	// the index must be of integer type
 	if !isInteger(x.typ) {
-		check.invalidArg(x.pos(), "index %s must be integer", &x)
+		check.invalidArg(x.pos(), InvalidIndex, "index %s must be integer", &x)
 		return
 	}
 
 	// a constant index i must be in bounds
 	if x.mode == constant {
 		if exact.Sign(x.val) < 0 {
-			check.invalidArg(x.pos(), "index %s must not be negative", &x)
+			check.invalidArg(x.pos(), InvalidIndex, "index %s must not be negative", &x)
 			return
 		}
 		i, valid = exact.Int64Val(x.val)
 		if !valid || max >= 0 && i >= max {
-			check.errorf(x.pos(), "index %s is out of bounds", &x)
+			check.errorf(x.pos(), InvalidIndex, "index %s is out of bounds", &x)
 			return i, false
 		}
 		// 0 <= i [ && i < max ]
@@ -849,12 +875,12 @@ func (check *Checker) indexedElts(elts []ast.Expr, typ Type, length int64) int64
 					index = i
 					validIndex = true
 				} else {
-					check.errorf(e.Pos(), "index %s must be integer constant", kv.Key)
+					check.errorf(e.Pos(), InvalidLitIndex, "index %s must be integer constant", kv.Key)
 				}
 			}
 			eval = kv.Value
 		} else if length >= 0 && index >= length {
-			check.errorf(e.Pos(), "index %d is out of bounds (>= %d)", index, length)
+			check.errorf(e.Pos(), InvalidLitIndex, "index %d is out of bounds (>= %d)", index, length)
 		} else {
 			validIndex = true
 		}
@@ -862,7 +888,7 @@ func (check *Checker) indexedElts(elts []ast.Expr, typ Type, length int64) int64
 		// if we have a valid index, check for duplicate entries
 		if validIndex {
 			if visited[index] {
-				check.errorf(e.Pos(), "duplicate index %d in array or slice literal", index)
+				check.errorf(e.Pos(), DuplicateLitKey, "duplicate index %d in array or slice literal", index)
 			}
 			visited[index] = true
 		}
@@ -875,7 +901,7 @@ func (check *Checker) indexedElts(elts []ast.Expr, typ Type, length int64) int64
 		var x operand
 		check.exprWithHint(&x, eval, typ)
 		if !check.assignment(&x, typ) && x.mode != invalid {
-			check.errorf(x.pos(), "cannot use %s as %s value in array or slice literal", &x, typ)
+			check.errorf(x.pos(), IncompatibleAssign, "cannot use %s as %s value in array or slice literal", &x, typ)
 		}
 	}
 	return max
@@ -905,7 +931,10 @@ func (check *Checker) rawExpr(x *operand, e ast.Expr, hint Type) exprKind {
 		}()
 	}
 
+	check.stats.Exprs++
+	check.enter(e)
 	kind := check.exprInternal(x, e, hint)
+	check.leave()
 
 	// convert x into a user-friendly set of values
 	// TODO(gri) this code can be simplified
@@ -935,6 +964,16 @@ func (check *Checker) rawExpr(x *operand, e ast.Expr, hint Type) exprKind {
 	return kind
 }
 
+// keyVal maps a constant value to a key for duplicate detection in
+// map literals. String values are compared by their contents.
+//
+func keyVal(x exact.Value) interface{} {
+	if x.Kind() == exact.String {
+		return exact.StringVal(x)
+	}
+	return x
+}
+
 // exprInternal contains the core of type checking of expressions.
 // Must only be called by rawExpr.
 //
@@ -954,7 +993,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 	case *ast.Ellipsis:
 		// ellipses are handled explicitly where they are legal
 		// (array composite literals and parameter lists)
-		check.error(e.Pos(), "invalid use of '...'")
+		check.error(e.Pos(), InvalidDotDotDot, "invalid use of '...'")
 		goto Error
 
 	case *ast.BasicLit:
@@ -963,19 +1002,22 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			check.invalidAST(e.Pos(), "invalid literal %v", e.Value)
 			goto Error
 		}
+		x.expr = e // for error position in constSize
+		check.constSize(x)
+		if x.mode == invalid {
+			goto Error
+		}
 
 	case *ast.FuncLit:
-		if sig, ok := check.typ(e.Type).(*Signature); ok {
-			// Anonymous functions are considered part of the
-			// init expression/func declaration which contains
-			// them: use existing package-level declaration info.
+		sig := check.funcLitType(e.Type)
+		// Anonymous functions are considered part of the
+		// init expression/func declaration which contains
+		// them: use existing package-level declaration info.
+		if !check.conf.IgnoreFuncBodies {
 			check.funcBody(check.decl, "", sig, e.Body)
-			x.mode = value
-			x.typ = sig
-		} else {
-			check.invalidAST(e.Pos(), "invalid function literal %s", e)
-			goto Error
 		}
+		x.mode = value
+		x.typ = sig
 
 	case *ast.CompositeLit:
 		typ := hint
@@ -998,7 +1040,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			}
 		}
 		if typ == nil {
-			check.error(e.Pos(), "missing type in composite literal")
+			check.error(e.Pos(), InvalidLit, "missing type in composite literal")
 			goto Error
 		}
 
@@ -1014,24 +1056,24 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 				for _, e := range e.Elts {
 					kv, _ := e.(*ast.KeyValueExpr)
 					if kv == nil {
-						check.error(e.Pos(), "mixture of field:value and value elements in struct literal")
+						check.error(e.Pos(), MixedStructLit, "mixture of field:value and value elements in struct literal")
 						continue
 					}
 					key, _ := kv.Key.(*ast.Ident)
 					if key == nil {
-						check.errorf(kv.Pos(), "invalid field name %s in struct literal", kv.Key)
+						check.errorf(kv.Pos(), InvalidLitField, "invalid field name %s in struct literal", kv.Key)
 						continue
 					}
 					i := fieldIndex(utyp.fields, check.pkg, key.Name)
 					if i < 0 {
-						check.errorf(kv.Pos(), "unknown field %s in struct literal", key.Name)
+						check.errorf(kv.Pos(), InvalidLitField, "unknown field %s in struct literal", key.Name)
 						continue
 					}
 					fld := fields[i]
 					check.recordUse(key, fld)
 					// 0 <= i < len(fields)
 					if visited[i] {
-						check.errorf(kv.Pos(), "duplicate field name %s in struct literal", key.Name)
+						check.errorf(kv.Pos(), DuplicateLitKey, "duplicate field name %s in struct literal", key.Name)
 						continue
 					}
 					visited[i] = true
@@ -1039,7 +1081,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 					etyp := fld.typ
 					if !check.assignment(x, etyp) {
 						if x.mode != invalid {
-							check.errorf(x.pos(), "cannot use %s as %s value in struct literal", x, etyp)
+							check.errorf(x.pos(), IncompatibleAssign, "cannot use %s as %s value in struct literal", x, etyp)
 						}
 						continue
 					}
@@ -1048,25 +1090,25 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 				// no element must have a key
 				for i, e := range e.Elts {
 					if kv, _ := e.(*ast.KeyValueExpr); kv != nil {
-						check.error(kv.Pos(), "mixture of field:value and value elements in struct literal")
+						check.error(kv.Pos(), MixedStructLit, "mixture of field:value and value elements in struct literal")
 						continue
 					}
 					check.expr(x, e)
 					if i >= len(fields) {
-						check.error(x.pos(), "too many values in struct literal")
+						check.error(x.pos(), WrongLitValueCount, "too many values in struct literal")
 						break // cannot continue
 					}
 					// i < len(fields)
 					etyp := fields[i].typ
 					if !check.assignment(x, etyp) {
 						if x.mode != invalid {
-							check.errorf(x.pos(), "cannot use %s as %s value in struct literal", x, etyp)
+							check.errorf(x.pos(), IncompatibleAssign, "cannot use %s as %s value in struct literal", x, etyp)
 						}
 						continue
 					}
 				}
 				if len(e.Elts) < len(fields) {
-					check.error(e.Rbrace, "too few values in struct literal")
+					check.error(e.Rbrace, WrongLitValueCount, "too few values in struct literal")
 					// ok to continue
 				}
 			}
@@ -1086,49 +1128,51 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			for _, e := range e.Elts {
 				kv, _ := e.(*ast.KeyValueExpr)
 				if kv == nil {
-					check.error(e.Pos(), "missing key in map literal")
+					check.error(e.Pos(), MissingLitKey, "missing key in map literal")
 					continue
 				}
 				check.expr(x, kv.Key)
 				if !check.assignment(x, utyp.key) {
 					if x.mode != invalid {
-						check.errorf(x.pos(), "cannot use %s as %s key in map literal", x, utyp.key)
+						check.errorf(x.pos(), IncompatibleAssign, "cannot use %s as %s key in map literal", x, utyp.key)
 					}
 					continue
 				}
 				if x.mode == constant {
 					duplicate := false
+					key := keyVal(x.val)
 					// if the key is of interface type, the type is also significant when checking for duplicates
 					if _, ok := utyp.key.Underlying().(*Interface); ok {
-						for _, vtyp := range visited[x.val] {
+						for _, vtyp := range visited[key] {
 							if Identical(vtyp, x.typ) {
 								duplicate = true
 								break
 							}
 						}
-						visited[x.val] = append(visited[x.val], x.typ)
+						visited[key] = append(visited[key], x.typ)
 					} else {
-						_, duplicate = visited[x.val]
-						visited[x.val] = nil
+						_, duplicate = visited[key]
+						visited[key] = nil
 					}
 					if duplicate {
-						check.errorf(x.pos(), "duplicate key %s in map literal", x.val)
+						check.errorf(x.pos(), DuplicateLitKey, "duplicate key %s in map literal", x.val)
 						continue
 					}
 				}
 				check.exprWithHint(x, kv.Value, utyp.elem)
 				if !check.assignment(x, utyp.elem) {
 					if x.mode != invalid {
-						check.errorf(x.pos(), "cannot use %s as %s value in map literal", x, utyp.elem)
+						check.errorf(x.pos(), IncompatibleAssign, "cannot use %s as %s value in map literal", x, utyp.elem)
 					}
 					continue
 				}
 			}
 
 		default:
+			check.useElts(e.Elts)
 			// if utyp is invalid, an error was reported before
 			if utyp != Typ[Invalid] {
-				check.errorf(e.Pos(), "invalid composite literal type %s", typ)
+				check.errorf(e.Pos(), InvalidLit, "invalid composite literal type %s", typ)
 				goto Error
 			}
 		}
@@ -1147,6 +1191,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 	case *ast.IndexExpr:
 		check.expr(x, e.X)
 		if x.mode == invalid {
+			check.use(e.Index)
 			goto Error
 		}
 
@@ -1192,7 +1237,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			check.expr(&key, e.Index)
 			if !check.assignment(&key, typ.key) {
 				if key.mode != invalid {
-					check.invalidOp(key.pos(), "cannot use %s as map index of type %s", &key, typ.key)
+					check.invalidOp(key.pos(), IncompatibleAssign, "cannot use %s as map index of type %s", &key, typ.key)
 				}
 				goto Error
 			}
@@ -1203,7 +1248,8 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		}
 
 		if !valid {
-			check.invalidOp(x.pos(), "cannot index %s", x)
+			check.invalidOp(x.pos(), NonIndexableOperand, "cannot index %s", x)
+			check.use(e.Index)
 			goto Error
 		}
 
@@ -1218,6 +1264,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 	case *ast.SliceExpr:
 		check.expr(x, e.X)
 		if x.mode == invalid {
+			check.use(e.Low, e.High, sliceMax(e))
 			goto Error
 		}
 
@@ -1227,7 +1274,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		case *Basic:
 			if isString(typ) {
 				if slice3(e) {
-					check.invalidOp(x.pos(), "3-index slice of string")
+					check.invalidOp(x.pos(), InvalidSliceExpr, "3-index slice of string")
 					goto Error
 				}
 				valid = true
@@ -1245,16 +1292,16 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			valid = true
 			length = typ.len
 			if x.mode != variable {
-				check.invalidOp(x.pos(), "cannot slice %s (value not addressable)", x)
+				check.invalidOp(x.pos(), InvalidSliceExpr, "cannot slice %s (value not addressable)", x)
 				goto Error
 			}
-			x.typ = &Slice{elem: typ.elem}
+			x.typ = check.canonical(&Slice{elem: typ.elem})
 
 		case *Pointer:
 			if typ, _ := typ.base.Underlying().(*Array); typ != nil {
 				valid = true
 				length = typ.len
-				x.typ = &Slice{elem: typ.elem}
+				x.typ = check.canonical(&Slice{elem: typ.elem})
 			}
 
 		case *Slice:
@@ -1263,7 +1310,8 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		}
 
 		if !valid {
-			check.invalidOp(x.pos(), "cannot slice %s", x)
+			check.invalidOp(x.pos(), InvalidSliceExpr, "cannot slice %s", x)
+			check.use(e.Low, e.High, sliceMax(e))
 			goto Error
 		}
 
@@ -1271,7 +1319,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 
 		// spec: "Only the first index may be omitted; it defaults to 0."
 		if slice3(e) && (e.High == nil || sliceMax(e) == nil) {
-			check.error(e.Rbrack, "2nd and 3rd index required in 3-index slice")
+			check.error(e.Rbrack, InvalidSyntaxTree, "2nd and 3rd index required in 3-index slice")
 			goto Error
 		}
 
@@ -1308,7 +1356,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 			if x > 0 {
 				for _, y := range ind[i+1:] {
 					if y >= 0 && x > y {
-						check.errorf(e.Rbrack, "invalid slice indices: %d > %d", x, y)
+						check.errorf(e.Rbrack, InvalidSliceExpr, "invalid slice indices: %d > %d", x, y)
 						break L // only report one error, ok to continue
 					}
 				}
@@ -1318,11 +1366,13 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 	case *ast.TypeAssertExpr:
 		check.expr(x, e.X)
 		if x.mode == invalid {
+			check.use(e.Type)
 			goto Error
 		}
 		xtyp, _ := x.typ.Underlying().(*Interface)
 		if xtyp == nil {
-			check.invalidOp(x.pos(), "%s is not an interface", x)
+			check.invalidOp(x.pos(), InvalidAssert, "%s is not an interface", x)
+			check.use(e.Type)
 			goto Error
 		}
 		// x.(type) expressions are handled explicitly in type switches
@@ -1347,13 +1397,13 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		case invalid:
 			goto Error
 		case typexpr:
-			x.typ = &Pointer{base: x.typ}
+			x.typ = check.canonical(&Pointer{base: x.typ})
 		default:
 			if typ, ok := x.typ.Underlying().(*Pointer); ok {
 				x.mode = variable
 				x.typ = typ.base
 			} else {
-				check.invalidOp(x.pos(), "cannot indirect %s", x)
+				check.invalidOp(x.pos(), InvalidIndirection, "cannot indirect %s", x)
 				goto Error
 			}
 		}
@@ -1394,7 +1444,10 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		// types, which are comparatively rare.
 
 	default:
-		panic(fmt.Sprintf("%s: unknown expression type %T", check.fset.Position(e.Pos()), e))
+		// unknown expression kinds (e.g., produced by a newer parser)
+		// are reported but don't stop checking of the surrounding code
+		check.unsupported(e.Pos(), e)
+		goto Error
 	}
 
 	// everything went well
@@ -1414,13 +1467,20 @@ func (check *Checker) typeAssertion(pos token.Pos, x *operand, xtyp *Interface,
 		return
 	}
 
-	var msg string
+	check.errorf(pos, ImpossibleAssert, "%s cannot have dynamic type %s (%s)", x, T, check.missingMethodReason(T, method, wrongType))
+}
+
+// missingMethodReason returns a description of why the type V has
+// no method matching the interface method m: either it doesn't have
+// a method with that name, or the respective method has the wrong
+// signature (wrongType is set). Signatures are shown without receivers.
+func (check *Checker) missingMethodReason(V Type, m *Func, wrongType bool) string {
 	if wrongType {
-		msg = "wrong type for method"
-	} else {
-		msg = "missing method"
+		if obj, _, _ := lookupFieldOrMethod(V, false, m.pkg, m.name); obj != nil {
+			return check.sprintf("wrong type for method %s: have %s, want %s", m.name, obj.Type(), m.typ)
+		}
 	}
-	check.errorf(pos, "%s cannot have dynamic type %s (%s %s)", x, T, msg, method.name)
+	return "missing method " + m.name
 }
 
 // expr typechecks expression e and initializes x with the expression value.
@@ -1439,7 +1499,7 @@ func (check *Checker) expr(x *operand, e ast.Expr) {
 	case typexpr:
 		msg = "is not an expression"
 	}
-	check.errorf(x.pos(), "%s %s", x, msg)
+	check.errorf(x.pos(), NotAnExpr, "%s %s", x, msg)
 	x.mode = invalid
 }
 
@@ -1461,7 +1521,7 @@ func (check *Checker) exprWithHint(x *operand, e ast.Expr, hint Type) {
 	case typexpr:
 		msg = "is not an expression"
 	}
-	check.errorf(x.pos(), "%s %s", x, msg)
+	check.errorf(x.pos(), NotAnExpr, "%s %s", x, msg)
 	x.mode = invalid
 }
 
@@ -1471,7 +1531,7 @@ func (check *Checker) exprWithHint(x *operand, e ast.Expr, hint Type) {
 func (check *Checker) exprOrType(x *operand, e ast.Expr) {
 	check.rawExpr(x, e, nil)
 	if x.mode == novalue {
-		check.errorf(x.pos(), "%s used as value or type", x)
+		check.errorf(x.pos(), NotAnExpr, "%s used as value or type", x)
 		x.mode = invalid
 	}
 }
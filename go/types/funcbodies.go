@@ -0,0 +1,214 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the concurrent checking of function bodies
+// (see Config.FuncBodyWorkers).
+
+package types
+
+import (
+	"go/ast"
+	"sync"
+)
+
+// A bodyResult describes the outcome of checking a function body concurrently.
+type bodyResult struct {
+	errors []Error     // errors found, in the order found
+	p      interface{} // if set, the value of the panic that stopped the check
+	done   bool        // set if the body was checked
+}
+
+// concurrentFunctionBodies typechecks all function bodies using up to
+// n goroutines. Each goroutine uses a copy of the checker with its own
+// context, Info maps, and statistics; errors are collected for each
+// function and the results are merged in source order once all
+// bodies are checked.
+func (check *Checker) concurrentFunctionBodies(n int) {
+	if n > len(check.funcs) {
+		n = len(check.funcs)
+	}
+
+	results := make([]bodyResult, len(check.funcs))
+	work := make(chan int, len(check.funcs))
+	for i := range check.funcs {
+		work <- i
+	}
+	close(work)
+
+	check.mu = new(sync.Mutex)
+	defer func() { check.mu = nil }()
+
+	workers := make([]*Checker, n)
+	var wg sync.WaitGroup
+	for k := range workers {
+		w := check.worker()
+		workers[k] = w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				if !w.checkBody(check.funcs[i], &results[i]) {
+					return // stop after a panic
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, w := range workers {
+		mergeInfo(check.Info, w.Info)
+		for x, info := range w.untyped {
+			check.rememberUntyped(x, info.isLhs, info.mode, info.typ, info.val)
+		}
+		check.stats.add(w.stats)
+	}
+
+	// report errors in the order the bodies would have been checked
+	for i := range results {
+		r := &results[i]
+		if !r.done && r.p == nil {
+			continue // not checked because of a panic in another body
+		}
+		for _, err := range r.errors {
+			check.err(err.Pos, err.Code, err.Msg, err.Soft)
+		}
+		if r.p != nil {
+			if _, ok := r.p.(bailout); ok {
+				// The check was abandoned because it was canceled
+				// or exceeded an implementation limit (the error
+				// was reported above).
+				check.checkCanceled()
+			}
+			panic(r.p)
+		}
+	}
+}
+
+// worker returns a copy of check for checking function bodies concurrently.
+func (check *Checker) worker() *Checker {
+	w := *check // copy
+	w.Info = newInfo(check.Info)
+	w.stats = new(Stats)
+	w.buffered = true
+	w.buffer = nil
+	w.untyped = nil
+	w.funcs = nil
+	w.delayed = nil
+	w.context = context{}
+	w.errors = 0
+	return &w
+}
+
+// checkBody checks the body of f and records the outcome in r.
+// It reports whether the check completed without panic.
+func (check *Checker) checkBody(f funcInfo, r *bodyResult) (ok bool) {
+	defer func() {
+		r.errors = check.buffer
+		check.buffer = nil
+		if p := recover(); p != nil {
+			r.p = p
+			return
+		}
+		r.done = true
+		ok = true
+	}()
+
+	check.checkCanceled()
+	check.funcBody(f.decl, f.name, f.sig, f.body)
+
+	// The delayed checks of a function body don't depend on other
+	// function bodies; perform them now so that their errors are
+	// reported with the function.
+	for len(check.delayed) > 0 {
+		fn := check.delayed[0]
+		check.delayed = check.delayed[1:]
+		fn()
+	}
+	return
+}
+
+// newInfo returns an Info with a new, empty map for each map of info
+// that is not nil.
+func newInfo(info *Info) *Info {
+	w := new(Info)
+	if info.Types != nil {
+		w.Types = make(map[ast.Expr]TypeAndValue)
+	}
+	if info.Defs != nil {
+		w.Defs = make(map[*ast.Ident]Object)
+	}
+	if info.Uses != nil {
+		w.Uses = make(map[*ast.Ident]Object)
+	}
+	if info.Implicits != nil {
+		w.Implicits = make(map[ast.Node]Object)
+	}
+	if info.Selections != nil {
+		w.Selections = make(map[*ast.SelectorExpr]*Selection)
+	}
+	if info.Docs != nil {
+		w.Docs = make(map[Object]*ast.CommentGroup)
+	}
+	if info.Conversions != nil {
+		w.Conversions = make(map[*ast.CallExpr]ConversionKind)
+	}
+	if info.Iotas != nil {
+		w.Iotas = make(map[*ast.ValueSpec]int)
+	}
+	if info.Scopes != nil {
+		w.Scopes = make(map[ast.Node]*Scope)
+	}
+	if info.StructTags != nil {
+		w.StructTags = make(map[*Var][]TagPair)
+	}
+	return w
+}
+
+// mergeInfo adds the map entries of src to the corresponding maps of dst.
+func mergeInfo(dst, src *Info) {
+	for k, v := range src.Types {
+		dst.Types[k] = v
+	}
+	for k, v := range src.Defs {
+		dst.Defs[k] = v
+	}
+	for k, v := range src.Uses {
+		dst.Uses[k] = v
+	}
+	for k, v := range src.Implicits {
+		dst.Implicits[k] = v
+	}
+	for k, v := range src.Selections {
+		dst.Selections[k] = v
+	}
+	for k, v := range src.Docs {
+		dst.Docs[k] = v
+	}
+	for k, v := range src.Conversions {
+		dst.Conversions[k] = v
+	}
+	for k, v := range src.Iotas {
+		dst.Iotas[k] = v
+	}
+	for k, v := range src.Scopes {
+		dst.Scopes[k] = v
+	}
+	for k, v := range src.StructTags {
+		dst.StructTags[k] = v
+	}
+}
+
+// lock and unlock guard the package data shared by function
+// bodies checked concurrently; otherwise they do nothing.
+func (check *Checker) lock() {
+	if check.mu != nil {
+		check.mu.Lock()
+	}
+}
+
+func (check *Checker) unlock() {
+	if check.mu != nil {
+		check.mu.Unlock()
+	}
+}
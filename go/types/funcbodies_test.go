@@ -0,0 +1,172 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestIgnoreFuncBodies(t *testing.T) {
+	const src = `package p
+import "unsafe"
+type T struct{ f int }
+func (T) m() int { return undeclared }
+var v = func() int { var x int = "s"; return unsafe.Sizeof(x) }()
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ignore := range []bool{false, true} {
+		var errors []string
+		conf := Config{
+			IgnoreFuncBodies: ignore,
+			Error:            func(err error) { errors = append(errors, err.Error()) },
+		}
+		info := Info{Defs: make(map[*ast.Ident]Object)}
+		pkg, _ := conf.Check("p", fset, []*ast.File{f}, &info)
+
+		if got, want := len(errors) > 0, !ignore; got != want {
+			t.Errorf("IgnoreFuncBodies = %v: got errors %v", ignore, errors)
+		}
+
+		// package-level declarations are checked regardless
+		if v := pkg.Scope().Lookup("v"); v == nil || v.Type().String() != "int" {
+			t.Errorf("IgnoreFuncBodies = %v: got v = %v; want var of type int", ignore, v)
+		}
+		var sawLocal bool
+		for id := range info.Defs {
+			if id.Name == "x" {
+				sawLocal = true
+			}
+		}
+		if sawLocal == ignore {
+			t.Errorf("IgnoreFuncBodies = %v: local x recorded = %v", ignore, sawLocal)
+		}
+	}
+}
+
+func TestReleaseFuncBodies(t *testing.T) {
+	const src = `package p
+
+func f(x int) int {
+	y := x + 1
+	return y
+}
+
+var v = func() int { return 0 }()
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bodies int // bodies seen by the analyzer
+	conf := Config{
+		ReleaseFuncBodies: true,
+		Analyzers: []Analyzer{func(_ *Package, _ *Info, files []*ast.File, _ func(token.Pos, string)) {
+			ast.Inspect(files[0], func(n ast.Node) bool {
+				if _, ok := n.(*ast.BlockStmt); ok {
+					bodies++
+				}
+				return true
+			})
+		}},
+	}
+	info := Info{Uses: make(map[*ast.Ident]Object)}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+	if bodies != 2 {
+		t.Errorf("analyzer saw %d bodies; want 2", bodies)
+	}
+
+	// the function body was released, but not the function literal
+	// of the package-level variable declaration
+	fdecl := f.Decls[0].(*ast.FuncDecl)
+	if fdecl.Body != nil {
+		t.Errorf("body of %s not released", fdecl.Name.Name)
+	}
+	if lit := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0].(*ast.CallExpr).Fun.(*ast.FuncLit); lit.Body == nil {
+		t.Errorf("body of function literal released")
+	}
+
+	// type information for the body is still recorded
+	var uses []string
+	for id, obj := range info.Uses {
+		if _, ok := obj.(*Var); ok {
+			uses = append(uses, fmt.Sprintf("%s: %s", fset.Position(id.Pos()), obj))
+		}
+	}
+	sort.Strings(uses)
+	want := []string{
+		"p.go:4:7: var x int",
+		"p.go:5:9: var y int",
+	}
+	if fmt.Sprint(uses) != fmt.Sprint(want) {
+		t.Errorf("got uses %q; want %q", uses, want)
+	}
+}
+
+func TestFuncBodyWorkers(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("package p\n\nimport \"strings\"\nimport . \"fmt\"\n\nvar g int\n\ntype T struct{ f int }\n\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&buf, "func f%d(t T) int {\n", i)
+		fmt.Fprintf(&buf, "\tg = strings.Index(\"x\", Sprint(t.f)) + f%d(T{})\n", (i+1)%100)
+		if i%10 == 0 {
+			fmt.Fprintf(&buf, "\tx := 0\n\treturn t.g\n")
+		} else {
+			fmt.Fprintf(&buf, "\treturn func() int { return g + %d }()\n", i)
+		}
+		fmt.Fprintf(&buf, "}\n\n")
+	}
+	src := buf.String()
+
+	check := func(workers int) (errs []string, info *Info) {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info = &Info{
+			Types:      make(map[ast.Expr]TypeAndValue),
+			Defs:       make(map[*ast.Ident]Object),
+			Uses:       make(map[*ast.Ident]Object),
+			Selections: make(map[*ast.SelectorExpr]*Selection),
+			Scopes:     make(map[ast.Node]*Scope),
+		}
+		conf := Config{
+			FuncBodyWorkers: workers,
+			Error:           func(err error) { errs = append(errs, err.Error()) },
+		}
+		conf.Check("p", fset, []*ast.File{f}, info)
+		return
+	}
+
+	errs, info := check(0)
+	for _, workers := range []int{2, 8, 200} {
+		errs2, info2 := check(workers)
+		if fmt.Sprint(errs2) != fmt.Sprint(errs) {
+			t.Errorf("%d workers: got errors\n%s\nwant\n%s", workers, strings.Join(errs2, "\n"), strings.Join(errs, "\n"))
+		}
+		if len(info2.Types) != len(info.Types) || len(info2.Defs) != len(info.Defs) ||
+			len(info2.Uses) != len(info.Uses) || len(info2.Selections) != len(info.Selections) ||
+			len(info2.Scopes) != len(info.Scopes) {
+			t.Errorf("%d workers: Info maps differ", workers)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Hash.
+
+package types
+
+// Hash returns a hash value for the type t such that
+// Identical(x, y) implies Hash(x) == Hash(y).
+//
+// The hash value depends only on the structure of t and on the
+// package paths and names of the named types it refers to; it
+// does not depend on the identity of the objects involved and
+// thus is the same for a type across different runs of a program.
+// Named types are not expanded: different named types with the
+// same name and package path have the same hash value.
+func Hash(t Type) uint64 {
+	h := hasher{hash: fnvOffset}
+	h.hashType(t)
+	return h.hash
+}
+
+// A hasher computes a 64bit Fowler-Noll-Vo (FNV-1a) hash.
+type hasher struct {
+	hash  uint64
+	iface bool // set if hashing the methods of an interface
+}
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+func (h *hasher) hashInt(x int64) {
+	for i := uint(0); i < 64; i += 8 {
+		h.hash = (h.hash ^ uint64(byte(x>>i))) * fnvPrime
+	}
+}
+
+func (h *hasher) hashString(s string) {
+	h.hashInt(int64(len(s)))
+	for i := 0; i < len(s); i++ {
+		h.hash = (h.hash ^ uint64(s[i])) * fnvPrime
+	}
+}
+
+// hashType hashes t the way identical compares types. Each type
+// constructor is hashed with a distinct tag to distinguish, e.g.,
+// []T from *T. Named types are not expanded.
+func (h *hasher) hashType(t Type) {
+	switch t := t.(type) {
+	case *Basic:
+		h.hashInt(1)
+		h.hashInt(int64(t.kind))
+
+	case *Array:
+		h.hashInt(2)
+		h.hashInt(t.len)
+		h.hashType(t.elem)
+
+	case *Slice:
+		h.hashInt(3)
+		h.hashType(t.elem)
+
+	case *Struct:
+		h.hashInt(4)
+		h.hashInt(int64(len(t.fields)))
+		for i, f := range t.fields {
+			if f.anonymous {
+				h.hashInt(1)
+			} else {
+				h.hashInt(0)
+			}
+			h.hashString(t.Tag(i))
+			h.hashString(f.name) // (ignore f.pkg, see sameId)
+			h.hashType(f.typ)
+		}
+
+	case *Pointer:
+		h.hashInt(5)
+		h.hashType(t.base)
+
+	case *Tuple:
+		h.hashInt(6)
+		h.hashTuple(t)
+
+	case *Signature:
+		h.hashInt(7)
+		if t.variadic {
+			h.hashInt(1)
+		} else {
+			h.hashInt(0)
+		}
+		h.hashTuple(t.params)
+		h.hashTuple(t.results)
+
+	case *Interface:
+		// The method set of an interface is sorted by method
+		// Id; identical interfaces have the same methods in
+		// the same order.
+		h.hashInt(8)
+		h.hashInt(int64(len(t.allMethods)))
+		// Interfaces may be cyclic without going through a named
+		// type (see identical). Identical cyclic interfaces need
+		// not have the same unrolled structure, thus only the
+		// method names of nested interfaces are hashed.
+		iface := h.iface
+		h.iface = true
+		for _, m := range t.allMethods {
+			h.hashString(m.name) // (ignore m.pkg, see sameId)
+			if !iface {
+				h.hashType(m.typ)
+			}
+		}
+		h.iface = iface
+
+	case *Map:
+		h.hashInt(9)
+		h.hashType(t.key)
+		h.hashType(t.elem)
+
+	case *Chan:
+		h.hashInt(10)
+		h.hashInt(int64(t.dir))
+		h.hashType(t.elem)
+
+	case *Named:
+		h.hashInt(11)
+		if pkg := t.obj.pkg; pkg != nil {
+			h.hashString(pkg.path)
+		}
+		h.hashString(t.obj.name)
+
+	default:
+		unreachable()
+	}
+}
+
+func (h *hasher) hashTuple(t *Tuple) {
+	h.hashInt(int64(t.Len()))
+	if t != nil {
+		for _, v := range t.vars {
+			h.hashType(v.typ)
+		}
+	}
+}
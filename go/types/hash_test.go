@@ -0,0 +1,97 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestHash(t *testing.T) {
+	const src = `package p
+type T struct{ x int }
+type I interface{ m() I }
+type R1 interface{ m() interface{ R1 } }
+type R2 interface{ m() interface{ R3 } }
+type R3 interface{ m() interface{ R2 } }
+var (
+	v0 int
+	v1 rune
+	v2 int32
+	v3 []int
+	v4 [3]int
+	v5 *int
+	v6 chan int
+	v7 <-chan int
+	v8 map[string]int
+	v9 func(int, ...string) bool
+	v10 func(int, []string) bool
+	v11 struct{ a int; b string }
+	v12 struct{ a int; b string "tag" }
+	v13 struct{ T }
+	v14 struct{ T T }
+	v15 interface{ m(); n() int }
+	v16 interface{ n() int; m() }
+	v17 T
+	v18 I
+	v19 interface{ I }
+	v20 func() (int, error)
+	v21 func() (x int, err error)
+)
+`
+	// Check the same source twice, to obtain identical types
+	// consisting of different objects.
+	var pkgs [2]*Package
+	for i := range pkgs {
+		pkg, err := pkgFor("hash.go", src, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgs[i] = pkg
+	}
+
+	var list []Type
+	for i := 0; ; i++ {
+		obj := pkgs[0].Scope().Lookup(fmt.Sprintf("v%d", i))
+		if obj == nil {
+			break
+		}
+		list = append(list, obj.Type())
+	}
+
+	for i, x := range list {
+		// Hash must be consistent with Identical.
+		for j, y := range list {
+			if Identical(x, y) && Hash(x) != Hash(y) {
+				t.Errorf("v%d and v%d are identical but hash differently (%s)", i, j, x)
+			}
+		}
+
+		// Hash must not depend on object identity.
+		y := pkgs[1].Scope().Lookup(fmt.Sprintf("v%d", i)).Type()
+		if Hash(x) != Hash(y) {
+			t.Errorf("v%d: hash differs across type-checks (%s)", i, x)
+		}
+	}
+
+	// Hash must terminate on recursive interfaces.
+	underlying := func(name string) Type { return pkgs[0].Scope().Lookup(name).Type().Underlying() }
+	if x, y := underlying("R1"), underlying("R2"); !Identical(x, y) || Hash(x) != Hash(y) {
+		t.Errorf("R1 and R2 must be identical and have the same hash")
+	}
+
+	// Spot-check that some non-identical types hash differently.
+	for _, p := range [][2]int{
+		{0, 3}, {3, 4}, {3, 5}, {6, 7}, {9, 10}, {11, 12},
+		{13, 14}, {17, 18}, {15, 19}, {0, 17},
+	} {
+		x, y := list[p[0]], list[p[1]]
+		if Hash(x) == Hash(y) {
+			t.Errorf("v%d (%s) and v%d (%s) have the same hash", p[0], x, p[1], y)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package types
 import (
 	"container/heap"
 	"fmt"
+	"sort"
 )
 
 // initOrder computes the Info.InitOrder for package variables.
@@ -135,17 +136,17 @@ func valIndex(a []*objNode) int {
 // reportCycle reports an error for the cycle starting at i.
 func (check *Checker) reportCycle(cycle []*objNode, i int) {
 	obj := cycle[i].obj
-	check.errorf(obj.Pos(), "initialization cycle for %s", obj.Name())
+	check.errorf(obj.Pos(), InvalidInitCycle, "initialization cycle for %s", obj.Name())
 	// print cycle
 	for _ = range cycle {
-		check.errorf(obj.Pos(), "\t%s refers to", obj.Name()) // secondary error, \t indented
+		check.errorf(obj.Pos(), InvalidInitCycle, "\t%s refers to", obj.Name()) // secondary error, \t indented
 		i++
 		if i >= len(cycle) {
 			i = 0
 		}
 		obj = cycle[i].obj
 	}
-	check.errorf(obj.Pos(), "\t%s", obj.Name())
+	check.errorf(obj.Pos(), InvalidInitCycle, "\t%s", obj.Name())
 }
 
 // An objNode represents a node in the object dependency graph.
@@ -187,9 +188,22 @@ func dependencyGraph(objMap map[Object]*declInfo) []*objNode {
 		i++
 	}
 
+	// sort the edges in source order so that the paths found
+	// for cycle errors do not depend on map iteration order
+	for _, n := range G {
+		sort.Sort(bySourceOrder(n.out))
+	}
+
 	return G
 }
 
+// bySourceOrder sorts nodes in the source order of their objects.
+type bySourceOrder []*objNode
+
+func (a bySourceOrder) Len() int           { return len(a) }
+func (a bySourceOrder) Less(i, j int) bool { return a[i].obj.order() < a[j].obj.order() }
+func (a bySourceOrder) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // nodeQueue implements the container/heap interface;
 // a nodeQueue may be used as a priority queue.
 type nodeQueue []*objNode
@@ -203,3 +203,85 @@ L7 uses var z int`
 		t.Errorf("Unexpected defs/uses\ngot:\n%s\nwant:\n%s", got, want)
 	}
 }
+
+// unknownExpr and unknownStmt simulate AST node kinds
+// that the type-checker doesn't know about.
+type unknownExpr struct{ ast.Expr }
+type unknownStmt struct{ ast.Stmt }
+
+// This tests that unknown AST nodes in function bodies cause
+// an "unsupported syntax" error rather than a crash, and that
+// checking continues with the remaining code.
+func TestUnsupportedSyntax(t *testing.T) {
+	const src = `
+package p
+func f() int {
+	x := 0
+	_ = x
+	_ = x + 1
+	return x
+}
+`
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// replace the rhs of _ = x with an unknown expression
+	// and add an unknown and a bad statement to the body
+	body := f.Decls[0].(*ast.FuncDecl).Body
+	assign := body.List[1].(*ast.AssignStmt)
+	pos := assign.Rhs[0].Pos()
+	assign.Rhs[0] = unknownExpr{&ast.BadExpr{From: pos, To: pos + 1}}
+	ret := body.List[3]
+	body.List = append(body.List[:3],
+		unknownStmt{&ast.EmptyStmt{Semicolon: ret.Pos()}},
+		&ast.BadStmt{From: ret.Pos(), To: ret.Pos()},
+		ret,
+	)
+
+	var errors []string
+	conf := Config{Error: func(err error) { errors = append(errors, err.Error()) }}
+	types := make(map[ast.Expr]TypeAndValue)
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, &Info{Types: types}) // must not crash
+
+	if len(errors) != 2 {
+		t.Fatalf("got %d errors (%v); want 2", len(errors), errors)
+	}
+	for _, err := range errors {
+		if !strings.Contains(err, "unsupported syntax") {
+			t.Errorf("got error %q; want unsupported syntax error", err)
+		}
+	}
+
+	// the statements following the unknown expression were checked
+	sum := body.List[2].(*ast.AssignStmt).Rhs[0]
+	if tv := types[sum]; tv.Type != Typ[Int] {
+		t.Errorf("got type %s for %s; want int", tv.Type, ExprString(sum))
+	}
+}
+
+// The parser doesn't accept **T as embedded field type;
+// make sure a synthesized one is rejected with an error.
+func TestEmbeddedPointerPointer(t *testing.T) {
+	const src = `
+package p
+type T struct{}
+type S struct{ *T }
+`
+	f, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// turn *T into **T
+	styp := f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	ptr := styp.Fields.List[0].Type.(*ast.StarExpr)
+	styp.Fields.List[0].Type = &ast.StarExpr{Star: ptr.Star, X: ptr}
+
+	var conf Config
+	_, err = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if err == nil || !strings.Contains(err.Error(), "4:16: anonymous field type cannot be a pointer") {
+		t.Errorf("got error %v; want anonymous field pointer error", err)
+	}
+}
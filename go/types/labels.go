@@ -7,12 +7,13 @@ package types
 import (
 	"go/ast"
 	"go/token"
+	"sort"
 )
 
 // labels checks correct label use in body.
 func (check *Checker) labels(body *ast.BlockStmt) {
 	// set of all labels in this body
-	all := NewScope(nil, "label")
+	all := NewScope(nil, token.NoPos, token.NoPos, "label")
 
 	fwdJumps := check.blockBranches(all, nil, nil, body.List)
 
@@ -29,15 +30,20 @@ func (check *Checker) labels(body *ast.BlockStmt) {
 		} else {
 			msg = "label %s not declared"
 		}
-		check.errorf(jmp.Label.Pos(), msg, name)
+		check.errorf(jmp.Label.Pos(), InvalidLabel, msg, name)
 	}
 
 	// spec: "It is illegal to define a label that is never used."
+	var unused []diagnostic
 	for _, obj := range all.elems {
 		if lbl := obj.(*Label); !lbl.used {
-			check.softErrorf(lbl.pos, "label %s declared but not used", lbl.name)
+			unused = append(unused, diagnostic{lbl.pos, lbl.name})
 		}
 	}
+	sort.Sort(byPos(unused))
+	for _, d := range unused {
+		check.softErrorf(d.pos, UnusedLabel, "label %s declared but not used", d.msg)
+	}
 }
 
 // A block tracks label declarations in a block and its enclosing blocks.
@@ -133,8 +139,8 @@ func (check *Checker) blockBranches(all *Scope, parent *block, lstmt *ast.Labele
 			if name := s.Label.Name; name != "_" {
 				lbl := NewLabel(s.Label.Pos(), check.pkg, name)
 				if alt := all.Insert(lbl); alt != nil {
-					check.softErrorf(lbl.pos, "label %s already declared", name)
-					check.reportAltDecl(alt)
+					check.softErrorf(lbl.pos, DuplicateLabel, "label %s already declared", name)
+					check.reportAltDecl(alt, DuplicateLabel)
 					// ok to continue
 				} else {
 					b.insert(s)
@@ -150,6 +156,7 @@ func (check *Checker) blockBranches(all *Scope, parent *block, lstmt *ast.Labele
 						if jumpsOverVarDecl(jmp) {
 							check.softErrorf(
 								jmp.Label.Pos(),
+								JumpOverDecl,
 								"goto %s jumps over variable declaration at line %d",
 								name,
 								check.fset.Position(varDeclPos).Line,
@@ -187,7 +194,7 @@ func (check *Checker) blockBranches(all *Scope, parent *block, lstmt *ast.Labele
 					}
 				}
 				if !valid {
-					check.errorf(s.Label.Pos(), "invalid break label %s", name)
+					check.errorf(s.Label.Pos(), InvalidLabel, "invalid break label %s", name)
 					return
 				}
 
@@ -202,7 +209,7 @@ func (check *Checker) blockBranches(all *Scope, parent *block, lstmt *ast.Labele
 					}
 				}
 				if !valid {
-					check.errorf(s.Label.Pos(), "invalid continue label %s", name)
+					check.errorf(s.Label.Pos(), InvalidLabel, "invalid continue label %s", name)
 					return
 				}
 
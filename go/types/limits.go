@@ -0,0 +1,84 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the checker's implementation limits
+// (see Config.MaxDepth, Config.MaxConstBits, and Config.MaxErrors).
+
+package types
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/exact"
+)
+
+// Limits used if the respective Config fields are not set.
+// They are high enough to never be exceeded by real code. Note
+// that each operand of a chain of binary expressions such as
+// a + b + c is nested one level deeper than the next one, and
+// generated code may contain chains of many thousand terms.
+//
+// Functions operating on types, such as Identical and WriteType,
+// need no limit of their own: they recurse along the structure
+// of types, which is bounded by the nesting depth of the type
+// expressions they were checked from, and they don't expand
+// named types, through which all cycles pass.
+const (
+	defaultMaxDepth     = 50000
+	defaultMaxConstBits = 1 << 16
+)
+
+// enter increments the nesting depth of the expression or type
+// expression e being checked and aborts type-checking if it exceeds
+// the configured maximum. Each call must be paired with a call of
+// leave. The position of e is only needed for the error; computing
+// it takes time linear in the length of a chain of binary expressions.
+func (check *Checker) enter(e ast.Expr) {
+	check.depth++
+	max := check.conf.MaxDepth
+	if max <= 0 {
+		max = defaultMaxDepth
+	}
+	if check.depth > max {
+		// Continuing would only exceed the limit again; and
+		// the nesting depth is unbalanced. Stop type-checking.
+		check.errorf(e.Pos(), ImplementationLimit, "implementation limit exceeded: nesting depth exceeds %d", max)
+		panic(bailout{})
+	}
+}
+
+// leave decrements the nesting depth.
+func (check *Checker) leave() {
+	check.depth--
+}
+
+// constSize reports an error and invalidates x if x is a numeric
+// constant whose representation exceeds the configured maximum size.
+func (check *Checker) constSize(x *operand) {
+	if x.mode != constant {
+		return
+	}
+	max := check.conf.MaxConstBits
+	if max <= 0 {
+		max = defaultMaxConstBits
+	}
+	if bits := constBits(x.val); bits > max {
+		check.errorf(x.pos(), ImplementationLimit, "implementation limit exceeded: constant too large (%d bits)", bits)
+		x.mode = invalid
+	}
+}
+
+// constBits returns the number of bits required to represent
+// the numeric constant x; the result is 0 for other constants.
+func constBits(x exact.Value) int {
+	switch x.Kind() {
+	case exact.Int:
+		return exact.BitLen(x)
+	case exact.Float:
+		return exact.BitLen(exact.Num(x)) + exact.BitLen(exact.Denom(x))
+	case exact.Complex:
+		return constBits(exact.Real(x)) + constBits(exact.Imag(x))
+	}
+	return 0
+}
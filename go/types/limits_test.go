@@ -0,0 +1,123 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+// deeplyNested returns a file declaring T and x, where x is
+// initialized with the expression -(-(...(-1)...)) and T's
+// type is *****int, both nested n levels deep. The parser has
+// its own nesting limit, so the ASTs are constructed directly.
+func deeplyNested(t *testing.T, n int) (*token.FileSet, *ast.File) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", "package p; type T int; var x = 1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := &f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type
+	val := &f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0]
+	for i := 0; i < n; i++ {
+		*typ = &ast.StarExpr{Star: (*typ).Pos(), X: *typ}
+		*val = &ast.UnaryExpr{OpPos: (*val).Pos(), Op: token.SUB, X: *val}
+	}
+	return fset, f
+}
+
+func TestMaxDepth(t *testing.T) {
+	const limit = "implementation limit exceeded: nesting depth exceeds"
+
+	// real code doesn't come close to the default limit
+	fset, f := deeplyNested(t, 100)
+	var conf Config
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// neither do long chains of binary expressions in generated code
+	long, err := parser.ParseFile(fset, "long.go", "package p; var x = 1"+strings.Repeat(" + 1", 20000), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conf.Check(long.Name.Name, fset, []*ast.File{long}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// a very deeply nested expression fails with an error (rather than crashing)
+	fset, f = deeplyNested(t, 100000)
+	var errors []string
+	conf.Error = func(err error) { errors = append(errors, err.Error()) }
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if len(errors) != 1 || !strings.Contains(errors[0], limit) {
+		t.Errorf("got errors %v; want single nesting depth error", errors)
+	}
+
+	// the limit is configurable
+	fset, f = deeplyNested(t, 100)
+	errors = nil
+	conf.MaxDepth = 50
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if len(errors) != 1 || !strings.Contains(errors[0], limit+" 50") {
+		t.Errorf("got errors %v; want single nesting depth error", errors)
+	}
+}
+
+func TestMaxConstBits(t *testing.T) {
+	const src = `package p
+const c = 1 << 1000
+const c2 = c * c
+const c8 = c2 * c2 * c2 * c2
+var _ = c8 * c8 / c8 / c8
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the default limit is not exceeded
+	var conf Config
+	if _, err := conf.Check(f.Name.Name, fset, []*ast.File{f}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	conf.MaxConstBits = 5000
+	_, err = conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	const want = "p.go:4:12: implementation limit exceeded: constant too large (6001 bits)"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v; want %s", err, want)
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	const src = `package p
+var _ = a
+var _ = b
+var _ = c
+var _ = d
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errors []string
+	conf := Config{
+		Error:     func(err error) { errors = append(errors, err.Error()) },
+		MaxErrors: 2,
+	}
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+	if len(errors) != 2 {
+		t.Errorf("got %d errors (%v); want 2", len(errors), errors)
+	}
+}
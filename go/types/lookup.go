@@ -6,6 +6,8 @@
 
 package types
 
+import "sort"
+
 // LookupFieldOrMethod looks up a field or method with given package and name
 // in T and returns the corresponding *Var or *Func, an index sequence, and a
 // bool indicating if there were any pointer indirections on the path to the
@@ -243,15 +245,24 @@ func MissingMethod(V Type, T *Interface, static bool) (method *Func, wrongType b
 	// TODO(gri) Consider using method sets here. Might be more efficient.
 
 	if ityp, _ := V.Underlying().(*Interface); ityp != nil {
-		// TODO(gri) allMethods is sorted - can do this more efficiently
+		// Both method lists are sorted by unique method name;
+		// match them in a single pass.
+		if debug {
+			assert(sort.IsSorted(byUniqueMethodName(ityp.allMethods)))
+			assert(sort.IsSorted(byUniqueMethodName(T.allMethods)))
+		}
+		methods := ityp.allMethods
 		for _, m := range T.allMethods {
-			_, obj := lookupMethod(ityp.allMethods, m.pkg, m.name)
+			id := m.Id()
+			for len(methods) > 0 && methods[0].Id() < id {
+				methods = methods[1:]
+			}
 			switch {
-			case obj == nil:
+			case len(methods) == 0 || methods[0].Id() != id:
 				if static {
 					return m, false
 				}
-			case !Identical(obj.Type(), m.typ):
+			case !Identical(methods[0].typ, m.typ):
 				return m, true
 			}
 		}
@@ -275,6 +286,66 @@ func MissingMethod(V Type, T *Interface, static bool) (method *Func, wrongType b
 	return
 }
 
+// A MethodMismatch describes a method required by an interface that a
+// type lacks or has with the wrong signature.
+type MethodMismatch struct {
+	Method  *Func // method required by the interface
+	Have    *Func // method of the same name, or nil if there is none
+	PtrRecv bool  // Have has a pointer receiver but the type is not a pointer
+}
+
+// MissingMethods is like MissingMethod but reports all methods of T
+// that V lacks or has with the wrong type, ordered by their unique Id.
+// For a method whose signature differs, Have is the method of V, and
+// the expected and actual signatures are Method.Type() and Have.Type().
+// If V is not a pointer and the method is declared with a pointer
+// receiver, Have is that method and PtrRecv is set; V doesn't have it,
+// but *V does. The result is empty if V implements T.
+//
+func MissingMethods(V Type, T *Interface, static bool) []MethodMismatch {
+	var list []MethodMismatch
+
+	if ityp, _ := V.Underlying().(*Interface); ityp != nil {
+		// Both method lists are sorted by unique method name;
+		// match them in a single pass.
+		methods := ityp.allMethods
+		for _, m := range T.allMethods {
+			id := m.Id()
+			for len(methods) > 0 && methods[0].Id() < id {
+				methods = methods[1:]
+			}
+			switch {
+			case len(methods) == 0 || methods[0].Id() != id:
+				if static {
+					list = append(list, MethodMismatch{Method: m})
+				}
+			case !Identical(methods[0].typ, m.typ):
+				list = append(list, MethodMismatch{Method: m, Have: methods[0]})
+			}
+		}
+		return list
+	}
+
+	for _, m := range T.allMethods {
+		obj, _, indirect := lookupFieldOrMethod(V, false, m.pkg, m.name)
+		ptrRecv := false
+		if obj == nil && indirect {
+			// method with pointer receiver
+			obj, _, _ = lookupFieldOrMethod(NewPointer(V), false, m.pkg, m.name)
+			ptrRecv = true
+		}
+
+		f, _ := obj.(*Func)
+		switch {
+		case f == nil:
+			list = append(list, MethodMismatch{Method: m})
+		case ptrRecv || !Identical(f.typ, m.typ):
+			list = append(list, MethodMismatch{m, f, ptrRecv})
+		}
+	}
+	return list
+}
+
 // assertableTo reports whether a value of type V can be asserted to have type T.
 // It returns (nil, false) as affirmative answer. Otherwise it returns a missing
 // method required by V and whether it is missing or just has the wrong type.
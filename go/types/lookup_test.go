@@ -0,0 +1,116 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestMissingMethod(t *testing.T) {
+	const src = `package p
+type I interface {
+	m()
+	n(int) string
+}
+type J1 interface {
+	m()
+}
+type J2 interface {
+	n(string) string
+}
+type T struct{}
+func (T) m() {}
+`
+	lookup := typesFor(t, src)
+	iface := lookup("I").Underlying().(*Interface)
+
+	for _, test := range []struct {
+		V         Type
+		static    bool
+		method    string // missing method, if any
+		wrongType bool
+	}{
+		{lookup("J1"), true, "n", false},
+		{lookup("J1"), false, "", false}, // n may be provided by the dynamic type
+		{lookup("J2"), true, "m", false},
+		{lookup("J2"), false, "n", true},
+		{NewInterface(nil, nil).Complete(), true, "m", false},
+		{NewInterface(nil, nil).Complete(), false, "", false},
+		// for non-interface types, static doesn't matter
+		{lookup("T"), true, "n", false},
+		{lookup("T"), false, "n", false},
+	} {
+		m, wrongType := MissingMethod(test.V, iface, test.static)
+		var name string
+		if m != nil {
+			name = m.Name()
+		}
+		if name != test.method || wrongType != test.wrongType {
+			t.Errorf("MissingMethod(%s, I, %v) = %q, %v; want %q, %v", test.V, test.static, name, wrongType, test.method, test.wrongType)
+		}
+	}
+}
+
+func TestMissingMethods(t *testing.T) {
+	const src = `package p
+type I interface {
+	a()
+	b(int) string
+	c()
+	d()
+}
+type T struct{}
+func (T) b(string) string { return "" }
+func (*T) c() {}
+func (T) d() {}
+type J interface{ b(int) string; e() }
+`
+	lookup := typesFor(t, src)
+	I := lookup("I").Underlying().(*Interface)
+
+	describe := func(list []MethodMismatch) string {
+		var descs []string
+		for _, m := range list {
+			s := m.Method.Name()
+			switch {
+			case m.Have == nil:
+				s += " missing"
+			case m.PtrRecv:
+				s += " has pointer receiver"
+			default:
+				s += fmt.Sprintf(": have %s, want %s", m.Have.Type(), m.Method.Type())
+			}
+			descs = append(descs, s)
+		}
+		return strings.Join(descs, "; ")
+	}
+
+	T := lookup("T")
+	for _, test := range []struct {
+		V      Type
+		T      *Interface
+		static bool
+		want   string
+	}{
+		{T, I, true, "a missing; b: have func(string) string, want func(int) string; c has pointer receiver"},
+		{NewPointer(T), I, true, "a missing; b: have func(string) string, want func(int) string"},
+		{lookup("J"), I, true, "a missing; c missing; d missing"},
+		{lookup("J"), I, false, ""},
+		{I, I, true, ""},
+	} {
+		got := describe(MissingMethods(test.V, test.T, test.static))
+		if got != test.want {
+			t.Errorf("MissingMethods(%s, %s, %v) = %q; want %q", test.V, test.T, test.static, got, test.want)
+		}
+		// MissingMethods agrees with MissingMethod
+		if m, _ := MissingMethod(test.V, test.T, test.static); (m == nil) != (got == "") {
+			t.Errorf("MissingMethod(%s, %s, %v) = %v, inconsistent with MissingMethods", test.V, test.T, test.static, m)
+		}
+	}
+}
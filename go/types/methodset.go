@@ -37,6 +37,7 @@ func (s *MethodSet) String() string {
 func (s *MethodSet) Len() int { return len(s.list) }
 
 // At returns the i'th method in s for 0 <= i < s.Len().
+// The methods are ordered by their unique Id.
 func (s *MethodSet) At(i int) *Selection { return s.list[i] }
 
 // Lookup returns the method with matching package and name, or nil if not found.
@@ -0,0 +1,84 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestNewMethodSet(t *testing.T) {
+	const src = `package p
+type A struct{}
+func (A) a() {}
+func (*A) pa() {}
+
+type B struct{}
+func (B) b() {}
+func (B) x() {}
+
+type C struct{}
+func (C) x() {}
+
+type D struct{ A; *B }
+func (D) d() {}
+
+type E struct{ B; C } // x is ambiguous
+
+type F struct{ E }
+func (F) x() {} // shadows E's (ambiguous) x
+
+type G struct{ D }
+
+type I interface{ m(); n() }
+type J interface{ I; o() }
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(name string) Type { return pkg.Scope().Lookup(name).Type() }
+
+	for _, test := range []struct {
+		T    Type
+		want string // name[index], followed by * for indirect selections
+	}{
+		{lookup("A"), "a[0]"},
+		{NewPointer(lookup("A")), "a[0]* pa[1]*"},
+		{lookup("D"), "a[0 0] b[1 0]* d[0] x[1 1]*"},
+		{NewPointer(lookup("D")), "a[0 0]* b[1 0]* d[0]* pa[0 1]* x[1 1]*"},
+		{lookup("E"), "b[0 0]"},
+		{lookup("F"), "b[0 0 0] x[0]"},
+		{lookup("G"), "a[0 0 0] b[0 1 0]* d[0 0] x[0 1 1]*"},
+		{lookup("I"), "m[0]* n[1]*"},
+		{lookup("J"), "m[0]* n[1]* o[2]*"},
+		{NewPointer(lookup("I")), ""}, // *I has no methods
+		{Typ[Int], ""},
+	} {
+		mset := NewMethodSet(test.T)
+		var list []string
+		for i := 0; i < mset.Len(); i++ {
+			sel := mset.At(i)
+			s := fmt.Sprintf("%s%v", sel.Obj().Name(), sel.Index())
+			if sel.Indirect() {
+				s += "*"
+			}
+			list = append(list, s)
+
+			if got := mset.Lookup(sel.Obj().Pkg(), sel.Obj().Name()); got != sel {
+				t.Errorf("%s: Lookup(%s) = %v; want %v", test.T, sel.Obj().Name(), got, sel)
+			}
+		}
+		if got := strings.Join(list, " "); got != test.want {
+			t.Errorf("NewMethodSet(%s) = %s; want %s", test.T, got, test.want)
+		}
+		if got := mset.Lookup(pkg, "nonexistent"); got != nil {
+			t.Errorf("%s: Lookup(nonexistent) = %v; want nil", test.T, got)
+		}
+	}
+}
@@ -4,7 +4,10 @@
 
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"go/token"
+)
 
 // A Package describes a Go package.
 type Package struct {
@@ -23,7 +26,7 @@ func NewPackage(path, name string) *Package {
 	if name == "_" {
 		panic("invalid package name _")
 	}
-	scope := NewScope(Universe, fmt.Sprintf("package %q", path))
+	scope := NewScope(Universe, token.NoPos, token.NoPos, fmt.Sprintf("package %q", path))
 	return &Package{path: path, name: name, scope: scope}
 }
 
@@ -46,7 +49,8 @@ func (pkg *Package) Complete() bool { return pkg.complete }
 func (pkg *Package) MarkComplete() { pkg.complete = true }
 
 // Imports returns the list of packages explicitly imported by
-// pkg; the list is in source order. Package unsafe is excluded.
+// pkg; the list is in source order. Package unsafe and the
+// fake package "C" (see Config.FakeImportC) are excluded.
 func (pkg *Package) Imports() []*Package { return pkg.imports }
 
 // SetImports sets the list of explicitly imported packages to list.
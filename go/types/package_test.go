@@ -0,0 +1,67 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestPackageImports(t *testing.T) {
+	libs := map[string]*Package{
+		"a": NewPackage("a", "a"),
+		"b": NewPackage("b", "b"),
+	}
+	for _, lib := range libs {
+		lib.MarkComplete()
+	}
+	conf := Config{
+		Import: func(imports map[string]*Package, path string) (*Package, error) {
+			if lib := libs[path]; lib != nil {
+				return lib, nil
+			}
+			return nil, fmt.Errorf("no package %s", path)
+		},
+	}
+
+	sources := []string{
+		`package p; import ("b"; _ "unsafe"; _ "a")`,
+		`package p; import (x "a"; _ "b"); var _ = x.X`,
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range sources {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("p%d.go", i), src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	// errors are expected (unused imports, undeclared x.X);
+	// the imports are recorded nonetheless
+	conf.Error = func(error) {}
+	pkg, _ := conf.Check("p", fset, files, nil)
+
+	// imports are listed once, in source order, excluding unsafe
+	var got []string
+	for _, imp := range pkg.Imports() {
+		got = append(got, imp.Path())
+	}
+	if want := "b a"; strings.Join(got, " ") != want {
+		t.Errorf("got imports %v; want %s", got, want)
+	}
+
+	pkg.SetImports(nil)
+	if len(pkg.Imports()) != 0 {
+		t.Errorf("got imports %v after SetImports(nil)", pkg.Imports())
+	}
+}
@@ -71,13 +71,31 @@ func isConstType(typ Type) bool {
 	return ok && t.info&IsConstType != 0
 }
 
-func isInterface(typ Type) bool {
+// IsInterface reports whether typ is an interface type.
+func IsInterface(typ Type) bool {
 	_, ok := typ.Underlying().(*Interface)
 	return ok
 }
 
 // Comparable reports whether values of type T are comparable.
 func Comparable(T Type) bool {
+	return comparable(T, nil)
+}
+
+// comparable reports whether values of type T are comparable.
+// seen records the named types visited so far; it is needed to
+// terminate on invalid recursive types (e.g., type T struct{ f T }).
+func comparable(T Type, seen map[*Named]bool) bool {
+	if t, _ := T.(*Named); t != nil {
+		if seen[t] {
+			return true // the remaining fields decide
+		}
+		if seen == nil {
+			seen = make(map[*Named]bool)
+		}
+		seen[t] = true
+	}
+
 	switch t := T.Underlying().(type) {
 	case *Basic:
 		// assume invalid types to be comparable
@@ -87,19 +105,19 @@ func Comparable(T Type) bool {
 		return true
 	case *Struct:
 		for _, f := range t.fields {
-			if !Comparable(f.typ) {
+			if !comparable(f.typ, seen) {
 				return false
 			}
 		}
 		return true
 	case *Array:
-		return Comparable(t.elem)
+		return comparable(t.elem, seen)
 	}
 	return false
 }
 
-// hasNil reports whether a type includes the nil value.
-func hasNil(typ Type) bool {
+// HasNil reports whether a type includes the nil value.
+func HasNil(typ Type) bool {
 	switch t := typ.Underlying().(type) {
 	case *Basic:
 		return t.kind == UnsafePointer
@@ -109,26 +127,128 @@ func hasNil(typ Type) bool {
 	return false
 }
 
+// ContainsPointers reports whether a value of type T contains pointers,
+// i.e., whether its representation includes words that may refer to
+// memory: besides pointers these are strings, slices, maps, channels,
+// functions, interfaces, and unsafe.Pointer values, and structs and
+// (non-empty) arrays containing any of them.
+func ContainsPointers(T Type) bool {
+	return containsPointers(T, nil)
+}
+
+// containsPointers reports whether a value of type T contains pointers.
+// seen records the named types visited so far; it is needed to terminate
+// on invalid recursive types (see comparable).
+func containsPointers(T Type, seen map[*Named]bool) bool {
+	if t, _ := T.(*Named); t != nil {
+		if seen[t] {
+			return false // the remaining fields decide
+		}
+		if seen == nil {
+			seen = make(map[*Named]bool)
+		}
+		seen[t] = true
+	}
+
+	switch t := T.Underlying().(type) {
+	case *Basic:
+		return t.kind == String || t.kind == UnsafePointer
+	case *Slice, *Pointer, *Signature, *Interface, *Map, *Chan:
+		return true
+	case *Struct:
+		for _, f := range t.fields {
+			if containsPointers(f.typ, seen) {
+				return true
+			}
+		}
+	case *Array:
+		return t.len > 0 && containsPointers(t.elem, seen)
+	}
+	return false
+}
+
 // Identical reports whether x and y are identical.
 func Identical(x, y Type) bool {
-	return identical(x, y, nil)
+	var c comparer
+	return c.identical(x, y, nil)
 }
 
-// An ifacePair is a node in a stack of interface type pairs compared for identity.
-type ifacePair struct {
-	x, y *Interface
-	prev *ifacePair
+// IdenticalByPath is like Identical but named types declared at
+// package level are identical if they have the same name and are
+// declared in packages with the same path, rather than if they
+// originate in the same type declaration. Thus IdenticalByPath may
+// be used to compare types obtained from different type-checks of
+// the same package. Local named types are compared as by Identical.
+func IdenticalByPath(x, y Type) bool {
+	c := comparer{byPath: true}
+	return c.identical(x, y, nil)
 }
 
-func (p *ifacePair) identical(q *ifacePair) bool {
+// A typePair is a node in a stack of type pairs compared for identity:
+// interfaces, and named types if they are compared by their structure.
+type typePair struct {
+	x, y Type
+	prev *typePair
+}
+
+func (p *typePair) identical(q *typePair) bool {
 	return p.x == q.x && p.y == q.y || p.x == q.y && p.y == q.x
 }
 
-func identical(x, y Type, p *ifacePair) bool {
+// seen reports whether the pair q is on the stack p.
+func (p *typePair) seen(q *typePair) bool {
+	for ; p != nil; p = p.prev {
+		if p.identical(q) {
+			return true
+		}
+	}
+	return false
+}
+
+// A comparer controls how types are compared for identity.
+type comparer struct {
+	// If byPath is set, named types are identical if they have the
+	// same (package-level) name and package path (see IdenticalByPath).
+	byPath bool
+
+	// If structure is set, named types are replaced by their
+	// underlying types (see IdenticalStructure).
+	structure bool
+
+	// If vars is set, it is called for each pair of types compared,
+	// before they are compared by their structure. If vars handles the
+	// pair (such as a pair containing a type variable, see Unify), the
+	// result of the comparison is identical.
+	vars func(x, y Type, p *typePair) (identical, handled bool)
+}
+
+// identical reports whether x and y are identical.
+func (c *comparer) identical(x, y Type, p *typePair) bool {
 	if x == y {
 		return true
 	}
 
+	if c.vars != nil {
+		if identical, handled := c.vars(x, y, p); handled {
+			return identical
+		}
+	}
+
+	if c.structure {
+		_, xn := x.(*Named)
+		_, yn := y.(*Named)
+		if xn || yn {
+			// Like interfaces, named types can lead to cycles:
+			// if x and y were compared before, they must be equal
+			// (see the comment for interfaces below).
+			q := &typePair{x, y, p}
+			if p.seen(q) {
+				return true
+			}
+			return c.identical(x.Underlying(), y.Underlying(), q)
+		}
+	}
+
 	switch x := x.(type) {
 	case *Basic:
 		// Basic types are singletons except for the rune and byte
@@ -142,13 +262,13 @@ func identical(x, y Type, p *ifacePair) bool {
 		// Two array types are identical if they have identical element types
 		// and the same array length.
 		if y, ok := y.(*Array); ok {
-			return x.len == y.len && identical(x.elem, y.elem, p)
+			return x.len == y.len && c.identical(x.elem, y.elem, p)
 		}
 
 	case *Slice:
 		// Two slice types are identical if they have identical element types.
 		if y, ok := y.(*Slice); ok {
-			return identical(x.elem, y.elem, p)
+			return c.identical(x.elem, y.elem, p)
 		}
 
 	case *Struct:
@@ -163,7 +283,7 @@ func identical(x, y Type, p *ifacePair) bool {
 					if f.anonymous != g.anonymous ||
 						x.Tag(i) != y.Tag(i) ||
 						!f.sameId(g.pkg, g.name) ||
-						!identical(f.typ, g.typ, p) {
+						!c.identical(f.typ, g.typ, p) {
 						return false
 					}
 				}
@@ -174,7 +294,7 @@ func identical(x, y Type, p *ifacePair) bool {
 	case *Pointer:
 		// Two pointer types are identical if they have identical base types.
 		if y, ok := y.(*Pointer); ok {
-			return identical(x.base, y.base, p)
+			return c.identical(x.base, y.base, p)
 		}
 
 	case *Tuple:
@@ -185,7 +305,7 @@ func identical(x, y Type, p *ifacePair) bool {
 				if x != nil {
 					for i, v := range x.vars {
 						w := y.vars[i]
-						if !identical(v.typ, w.typ, p) {
+						if !c.identical(v.typ, w.typ, p) {
 							return false
 						}
 					}
@@ -201,8 +321,8 @@ func identical(x, y Type, p *ifacePair) bool {
 		// names are not required to match.
 		if y, ok := y.(*Signature); ok {
 			return x.variadic == y.variadic &&
-				identical(x.params, y.params, p) &&
-				identical(x.results, y.results, p)
+				c.identical(x.params, y.params, p) &&
+				c.identical(x.results, y.results, p)
 		}
 
 	case *Interface:
@@ -228,19 +348,16 @@ func identical(x, y Type, p *ifacePair) bool {
 				//
 				// If x and y were compared before, they must be equal
 				// (if they were not, the recursion would have stopped);
-				// search the ifacePair stack for the same pair.
+				// search the typePair stack for the same pair.
 				//
 				// This is a quadratic algorithm, but in practice these stacks
 				// are extremely short (bounded by the nesting depth of interface
 				// type declarations that recur via parameter types, an extremely
 				// rare occurrence). An alternative implementation might use a
 				// "visited" map, but that is probably less efficient overall.
-				q := &ifacePair{x, y, p}
-				for p != nil {
-					if p.identical(q) {
-						return true // same pair was compared before
-					}
-					p = p.prev
+				q := &typePair{x, y, p}
+				if p.seen(q) {
+					return true // same pair was compared before
 				}
 				if debug {
 					assert(sort.IsSorted(byUniqueMethodName(a)))
@@ -248,7 +365,7 @@ func identical(x, y Type, p *ifacePair) bool {
 				}
 				for i, f := range a {
 					g := b[i]
-					if f.Id() != g.Id() || !identical(f.typ, g.typ, q) {
+					if f.Id() != g.Id() || !c.identical(f.typ, g.typ, q) {
 						return false
 					}
 				}
@@ -259,20 +376,23 @@ func identical(x, y Type, p *ifacePair) bool {
 	case *Map:
 		// Two map types are identical if they have identical key and value types.
 		if y, ok := y.(*Map); ok {
-			return identical(x.key, y.key, p) && identical(x.elem, y.elem, p)
+			return c.identical(x.key, y.key, p) && c.identical(x.elem, y.elem, p)
 		}
 
 	case *Chan:
 		// Two channel types are identical if they have identical value types
 		// and the same direction.
 		if y, ok := y.(*Chan); ok {
-			return x.dir == y.dir && identical(x.elem, y.elem, p)
+			return x.dir == y.dir && c.identical(x.elem, y.elem, p)
 		}
 
 	case *Named:
 		// Two named types are identical if their type names originate
 		// in the same type declaration.
 		if y, ok := y.(*Named); ok {
+			if c.byPath {
+				return x.obj == y.obj || samePath(x.obj, y.obj)
+			}
 			return x.obj == y.obj
 		}
 
@@ -283,11 +403,37 @@ func identical(x, y Type, p *ifacePair) bool {
 	return false
 }
 
-// defaultType returns the default "typed" type for an "untyped" type;
+// samePath reports whether x and y are package-level objects with
+// the same name in packages with the same path.
+func samePath(x, y Object) bool {
+	xpkg, ypkg := x.Pkg(), y.Pkg()
+	if xpkg == nil || ypkg == nil {
+		return false // objects in the universe scope are unique
+	}
+	return x.Name() == y.Name() && xpkg.path == ypkg.path &&
+		x.Parent() == xpkg.scope && y.Parent() == ypkg.scope
+}
+
+// IdenticalStructure reports whether x and y are identical if all
+// named types are replaced by their underlying types, recursively.
+// For instance, given
+//
+//	type List struct{ next *List }
+//
+// the types List, struct{ next *List }, and struct{ next *struct{ next *List } }
+// have identical structure. The methods of named types are ignored.
+//
+func IdenticalStructure(x, y Type) bool {
+	c := comparer{structure: true}
+	return c.identical(x, y, nil)
+}
+
+// Default returns the default "typed" type for an "untyped" type;
 // it returns the incoming type for all other types. The default type
-// for untyped nil is untyped nil.
+// for untyped nil is untyped nil: nil has no default type, and its
+// type must be determined by the context in which it is used.
 //
-func defaultType(typ Type) Type {
+func Default(typ Type) Type {
 	if t, ok := typ.(*Basic); ok {
 		switch t.kind {
 		case UntypedBool:
@@ -0,0 +1,267 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestComparable(t *testing.T) {
+	// Comparable must terminate for (invalid) recursive types, which
+	// can be constructed programmatically: type T struct{ a [1]T; f F }
+	newRecursive := func(f Type) Type {
+		obj := NewTypeName(token.NoPos, nil, "T", nil)
+		T := NewNamed(obj, nil, nil)
+		T.SetUnderlying(NewStruct([]*Var{
+			NewField(token.NoPos, nil, "a", NewArray(T, 1), false),
+			NewField(token.NoPos, nil, "f", f, false),
+		}, nil))
+		return T
+	}
+
+	for _, test := range []struct {
+		typ  Type
+		want bool
+	}{
+		{Typ[Int], true},
+		{Typ[UntypedNil], false},
+		{NewSlice(Typ[Int]), false},
+		{NewMap(Typ[Int], Typ[Int]), false},
+		{NewArray(NewSlice(Typ[Int]), 1), false},
+		{NewPointer(NewSlice(Typ[Int])), true},
+		{newRecursive(Typ[Int]), true},
+		{newRecursive(NewSlice(Typ[Int])), false},
+	} {
+		if got := Comparable(test.typ); got != test.want {
+			t.Errorf("Comparable(%s) = %v; want %v", test.typ, got, test.want)
+		}
+	}
+}
+
+// Identical must terminate for types that recur via anonymous
+// interfaces (see also testdata/cycles4.src).
+func TestIdenticalRecursive(t *testing.T) {
+	const src = `package p
+type T1 interface { m() interface{T1} }
+type T2 interface { m() interface{T2} }
+type T3 interface { m() interface{T4} }
+type T4 interface { m() interface{T3} }
+type T5 interface { m() interface{T5}; n() }
+`
+	lookup := typesFor(t, src)
+	underlying := func(name string) Type { return lookup(name).Underlying() }
+
+	for _, test := range []struct {
+		x, y string
+		want bool
+	}{
+		{"T1", "T1", true},
+		{"T1", "T2", true},
+		{"T1", "T3", true},
+		{"T3", "T4", true},
+		{"T1", "T5", false},
+	} {
+		if got := Identical(underlying(test.x), underlying(test.y)); got != test.want {
+			t.Errorf("Identical(%s, %s) = %v; want %v", test.x, test.y, got, test.want)
+		}
+	}
+}
+
+func TestIdenticalStructure(t *testing.T) {
+	const src = `package p
+type List struct{ next *List }
+type Node struct{ next *Node }
+type Pair struct{ next *struct{ next *Pair } }
+type Tree struct{ left, right *Tree }
+type Int int
+type F func(Int) []Int
+type I interface{ m() I }
+type J interface{ m() J }
+type K interface{ m() int }
+var (
+	v0 struct{ next *List }
+	v1 struct{ x Int; y F }
+	v2 struct{ x int; y func(int) []int }
+	v3 struct{ x int; y func(int) []string }
+)
+`
+	typ := typesFor(t, src)
+
+	for _, test := range []struct {
+		x, y string
+		want bool
+	}{
+		{"List", "List", true},
+		{"List", "Node", true},
+		{"List", "Pair", true},
+		{"List", "v0", true},
+		{"List", "Tree", false},
+		{"Int", "v0", false},
+		{"v1", "v2", true},
+		{"v1", "v3", false},
+		{"I", "J", true},
+		{"I", "K", false},
+	} {
+		x, y := typ(test.x), typ(test.y)
+		if got := IdenticalStructure(x, y); got != test.want {
+			t.Errorf("IdenticalStructure(%s, %s) = %v; want %v", test.x, test.y, got, test.want)
+		}
+		if got := IdenticalStructure(y, x); got != test.want {
+			t.Errorf("IdenticalStructure(%s, %s) = %v; want %v", test.y, test.x, got, test.want)
+		}
+	}
+
+	// Identical types have identical structure.
+	if !IdenticalStructure(Typ[Byte], Universe.Lookup("byte").Type()) {
+		t.Errorf("byte and uint8 must have identical structure")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	for _, test := range []struct {
+		typ  Type
+		want string
+	}{
+		{Typ[UntypedBool], "bool"},
+		{Typ[UntypedInt], "int"},
+		{Typ[UntypedRune], "rune"},
+		{Typ[UntypedFloat], "float64"},
+		{Typ[UntypedComplex], "complex128"},
+		{Typ[UntypedString], "string"},
+		{Typ[UntypedNil], "untyped nil"},
+		{Typ[Int8], "int8"},
+	} {
+		if got := Default(test.typ).String(); got != test.want {
+			t.Errorf("Default(%s) = %s; want %s", test.typ, got, test.want)
+		}
+	}
+}
+
+func TestContainsPointers(t *testing.T) {
+	const src = `package p
+import "unsafe"
+type S struct{ a, b int; c [2]struct{ d float64; e string } }
+type E struct{ x int; y [0]*int; z struct{} }
+type R struct{ f R; g int } // invalid recursive type
+var (
+	v0 int
+	v1 string
+	v2 unsafe.Pointer
+	v3 *int
+	v4 []int
+	v5 map[int]int
+	v6 chan int
+	v7 func()
+	v8 interface{}
+	v9 [4]int
+	v10 [4]*int
+	v11 S
+	v12 E
+	v13 struct{ a int; b bool }
+	v14 R
+	v15 complex128
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Error: func(error) {}} // ignore the error for R
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, nil)
+
+	want := []bool{false, true, true, true, true, true, true, true, true, false, true, true, false, false, false, false}
+	for i, want := range want {
+		typ := pkg.Scope().Lookup(fmt.Sprintf("v%d", i)).Type()
+		if got := ContainsPointers(typ); got != want {
+			t.Errorf("ContainsPointers(%s) = %v; want %v", typ, got, want)
+		}
+	}
+
+	for _, test := range []struct {
+		typ  Type
+		want bool
+	}{
+		{Typ[Int], false},
+		{Typ[String], false},
+		{Typ[UnsafePointer], true},
+		{NewPointer(Typ[Int]), true},
+		{NewStruct(nil, nil), false},
+		{NewChan(SendRecv, Typ[Int]), true},
+	} {
+		if got := HasNil(test.typ); got != test.want {
+			t.Errorf("HasNil(%s) = %v; want %v", test.typ, got, test.want)
+		}
+	}
+}
+
+func TestIdenticalByPath(t *testing.T) {
+	const src = `package p
+type T struct{ x int; next *T }
+type I interface{ m(T) error }
+var (
+	v0 T
+	v1 *T
+	v2 map[string][]T
+	v3 func(I) (T, error)
+	v4 struct{ t T; i I }
+	v5 int
+)
+func f() interface{} {
+	type L int
+	var x L
+	return x
+}
+`
+	// Check the same source twice, to obtain distinct but
+	// equivalent types.
+	var pkgs [2]*Package
+	for i := range pkgs {
+		pkg, err := pkgFor("p", src, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgs[i] = pkg
+	}
+
+	for i := 0; i <= 5; i++ {
+		name := fmt.Sprintf("v%d", i)
+		x := pkgs[0].Scope().Lookup(name).Type()
+		y := pkgs[1].Scope().Lookup(name).Type()
+		if !IdenticalByPath(x, y) {
+			t.Errorf("%s: IdenticalByPath(%s, %s) = false", name, x, y)
+		}
+		if got, want := Identical(x, y), i == 5; got != want {
+			t.Errorf("%s: Identical(%s, %s) = %v; want %v", name, x, y, got, want)
+		}
+	}
+
+	// Different types remain different.
+	if IdenticalByPath(pkgs[0].Scope().Lookup("v0").Type(), pkgs[1].Scope().Lookup("v1").Type()) {
+		t.Errorf("IdenticalByPath(T, *T) = true")
+	}
+
+	// Local types are compared by identity.
+	local := func(pkg *Package) Type {
+		return pkg.Scope().Lookup("f").(*Func).Scope().Lookup("L").Type()
+	}
+	if IdenticalByPath(local(pkgs[0]), local(pkgs[1])) {
+		t.Errorf("IdenticalByPath(L, L) = true for local types from different type-checks")
+	}
+
+	// Packages with different paths have different types.
+	other := NewPackage("q", "p")
+	obj := NewTypeName(token.NoPos, other, "T", nil)
+	NewNamed(obj, pkgs[0].Scope().Lookup("T").Type().Underlying(), nil)
+	if IdenticalByPath(pkgs[0].Scope().Lookup("T").Type(), obj.Type()) {
+		t.Errorf("IdenticalByPath(p.T, q.T) = true")
+	}
+}
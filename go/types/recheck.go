@@ -0,0 +1,362 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Checker.RecheckFile.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// RecheckFile type-checks file, a modified version of the package file
+// old previously checked with check.Files, and records the type information
+// for file in info (which may be nil). The other package files are not
+// checked again: their package-level objects and type information are
+// reused as is. This makes it possible to provide diagnostics for a file
+// of a large package while the file is edited.
+//
+// So that the type information of the other package files remains valid,
+// the package-level objects declared in old, and the struct fields and
+// interface methods of their types, are reused for the corresponding
+// declarations in file, with updated positions. Consequently, RecheckFile
+// only supports edits of function bodies, positions, and comments: the
+// package-level declarations of file must otherwise be the same as those
+// of old. If they differ, RecheckFile returns an error that is not of type
+// Error and leaves the checker unchanged; the package must then be checked
+// anew.
+//
+// Only function bodies and package-level declarations of file are checked,
+// with the exception of Info.InitOrder, which is computed for the entire
+// package.
+//
+func (check *Checker) RecheckFile(old, file *ast.File, info *Info) (err error) {
+	pkg := check.pkg
+
+	// find the file scope of old
+	index := -1
+	for i, scope := range pkg.scope.children /* file scopes */ {
+		if scope.pos == old.Pos() {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("file %s was not checked as part of package %s", check.fset.Position(old.Pos()).Filename, pkg.path)
+	}
+	oldScope := pkg.scope.children[index]
+
+	if !equalSyntax(reflect.ValueOf(old.Name), reflect.ValueOf(file.Name)) ||
+		!equalSyntax(reflect.ValueOf(old.Decls), reflect.ValueOf(file.Decls)) {
+		return errors.New("package-level declarations changed")
+	}
+
+	// collect the package-level objects declared by old, in source order
+	var objList []Object
+	for obj, d := range check.objMap {
+		if d.file != oldScope {
+			continue
+		}
+		// Redeclared objects (errors) are not in the package scope;
+		// they cannot be reused consistently.
+		if d.fdecl == nil || d.fdecl.Recv == nil && obj.Name() != "init" {
+			if obj.Name() != "_" && pkg.scope.Lookup(obj.Name()) != obj {
+				return fmt.Errorf("%s redeclared in package %s", obj.Name(), pkg.path)
+			}
+		}
+		objList = append(objList, obj)
+	}
+	sort.Sort(inSourceOrder(objList))
+
+	// use info for the duration of the check
+	if info == nil {
+		info = new(Info)
+	}
+	defer func(info *Info) {
+		check.Info = info
+		check.recycled = nil
+		check.named = nil
+		check.oldTypes = nil
+	}(check.Info)
+	check.Info = info
+	check.recycled = objList
+	check.named = make(map[*TypeName]*Named)
+	check.oldTypes = make(map[Object]Type)
+
+	defer check.handleBailout(&err)
+
+	check.initFiles([]*ast.File{file})
+
+	// remove the file scope of old and type-check file in its place
+	children := pkg.scope.children
+	children = append(children[:index], children[index+1:]...)
+	pkg.scope.children = children
+
+	start := time.Now()
+	check.collectObjects()
+	start = check.stats.phase(&check.stats.Collect, start)
+
+	children = pkg.scope.children
+	n := len(children) - 1
+	fileScope := children[n]
+	copy(children[index+1:], children[index:n])
+	children[index] = fileScope
+
+	check.packageObjects(check.resolveOrder())
+	reused := check.reuseMembers(objList)
+	start = check.stats.phase(&check.stats.Decls, start)
+
+	check.functionBodies()
+	start = check.stats.phase(&check.stats.Bodies, start)
+
+	check.initOrder()
+
+	if !check.conf.DisableUnusedImportCheck {
+		check.unusedImports([]*Scope{fileScope})
+	}
+
+	// perform delayed checks
+	for _, f := range check.delayed {
+		f()
+	}
+
+	check.recordUntyped()
+	remapObjects(info, reused)
+	start = check.stats.phase(&check.stats.Other, start)
+
+	if check.firstErr == nil || check.conf.Error != nil {
+		check.runAnalyzers()
+		check.stats.phase(&check.stats.Analyzers, start)
+	}
+
+	if check.conf.ReleaseFuncBodies {
+		check.releaseFuncBodies()
+	}
+
+	return
+}
+
+// recycle returns the next recycled object in place of the newly
+// allocated package-level object obj, if RecheckFile is in progress.
+// The recycled object is reset so that its declaration is checked anew.
+func (check *Checker) recycle(obj Object) Object {
+	if len(check.recycled) == 0 {
+		return obj
+	}
+	if _, ok := obj.(*Func); !ok && obj.Name() == "init" {
+		return obj // invalid declaration; not in check.objMap
+	}
+	alt := check.recycled[0]
+	check.recycled = check.recycled[1:]
+
+	// alt and obj stem from the same declaration
+	assert(alt.Name() == obj.Name())
+	switch alt := alt.(type) {
+	case *Const:
+		alt.pos = obj.Pos()
+		alt.typ = nil
+		alt.val = obj.(*Const).val // value of iota
+		alt.visited = false
+	case *Var:
+		check.oldTypes[alt] = alt.typ
+		alt.pos = obj.Pos()
+		alt.typ = nil
+		alt.visited = false
+	case *TypeName:
+		if named, _ := alt.typ.(*Named); named != nil {
+			check.named[alt] = named
+			check.oldTypes[alt] = named.underlying
+		}
+		alt.pos = obj.Pos()
+		alt.typ = nil
+	case *Func:
+		check.oldTypes[alt] = alt.typ
+		alt.pos = obj.Pos()
+		alt.typ = nil
+	default:
+		unreachable()
+	}
+	return alt
+}
+
+// reuseMembers replaces the struct fields and interface methods of the
+// new types of the reused objects in objList with the corresponding fields
+// and methods of their previous types, to which the other package files
+// may refer. The result maps each replaced object to its replacement.
+func (check *Checker) reuseMembers(objList []Object) map[Object]Object {
+	reused := make(map[Object]Object)
+	for _, obj := range objList {
+		old := check.oldTypes[obj]
+		typ := obj.Type()
+		if _, ok := obj.(*TypeName); ok && typ != nil {
+			typ = typ.Underlying()
+		}
+		// The declarations are the same, and so are the types unless
+		// they depend on invalid declarations; be conservative.
+		if old != nil && typ != nil && Identical(old, typ) {
+			reuseMembers(old, typ, reused)
+		}
+	}
+	return reused
+}
+
+// reuseMembers replaces the struct fields and interface methods of typ
+// with the corresponding objects of old, an identical type, and records
+// the replacements in reused. The reused objects take on the positions
+// of the objects they replace.
+func reuseMembers(old, typ Type, reused map[Object]Object) {
+	if old == typ {
+		return
+	}
+	switch t := typ.(type) {
+	case *Array:
+		reuseMembers(old.(*Array).elem, t.elem, reused)
+
+	case *Slice:
+		reuseMembers(old.(*Slice).elem, t.elem, reused)
+
+	case *Struct:
+		o := old.(*Struct)
+		for i, f := range t.fields {
+			g := o.fields[i]
+			if g == f {
+				continue
+			}
+			reuseMembers(g.typ, f.typ, reused)
+			g.pos = f.pos
+			reused[f] = g
+			t.fields[i] = g
+		}
+
+	case *Pointer:
+		reuseMembers(old.(*Pointer).base, t.base, reused)
+
+	case *Tuple:
+		o := old.(*Tuple)
+		for i, v := range t.vars {
+			reuseMembers(o.vars[i].typ, v.typ, reused)
+		}
+
+	case *Signature:
+		// The receiver of an interface method is the interface
+		// itself; it has no members of its own.
+		o := old.(*Signature)
+		reuseMembers(o.params, t.params, reused)
+		reuseMembers(o.results, t.results, reused)
+
+	case *Interface:
+		o := old.(*Interface)
+		for i, m := range t.methods {
+			g := o.methods[i]
+			if g == m {
+				continue
+			}
+			reuseMembers(g.typ, m.typ, reused)
+			g.pos = m.pos
+			reused[m] = g
+			t.methods[i] = g
+		}
+		for i, m := range t.allMethods {
+			if g, _ := reused[m].(*Func); g != nil {
+				t.allMethods[i] = g
+			}
+		}
+
+	case *Map:
+		o := old.(*Map)
+		reuseMembers(o.key, t.key, reused)
+		reuseMembers(o.elem, t.elem, reused)
+
+	case *Chan:
+		reuseMembers(old.(*Chan).elem, t.elem, reused)
+	}
+}
+
+// remapObjects replaces the objects recorded in info that were
+// replaced by reuseMembers.
+func remapObjects(info *Info, reused map[Object]Object) {
+	if len(reused) == 0 {
+		return
+	}
+	for id, obj := range info.Defs {
+		if alt := reused[obj]; alt != nil {
+			info.Defs[id] = alt
+		}
+	}
+	for id, obj := range info.Uses {
+		if alt := reused[obj]; alt != nil {
+			info.Uses[id] = alt
+		}
+	}
+	for _, sel := range info.Selections {
+		if alt := reused[sel.obj]; alt != nil {
+			sel.obj = alt
+		}
+	}
+}
+
+var (
+	posType          = reflect.TypeOf(token.NoPos)
+	objectType       = reflect.TypeOf((*ast.Object)(nil))
+	scopeType        = reflect.TypeOf((*ast.Scope)(nil))
+	commentGroupType = reflect.TypeOf((*ast.CommentGroup)(nil))
+	funcDeclType     = reflect.TypeOf(ast.FuncDecl{})
+	blockStmtPtrType = reflect.TypeOf((*ast.BlockStmt)(nil))
+)
+
+// equalSyntax reports whether the syntax trees x and y are the same,
+// ignoring positions, comments, parser objects, and function bodies
+// (but not the bodies of function literals).
+func equalSyntax(x, y reflect.Value) bool {
+	if x.Type() != y.Type() {
+		return false
+	}
+	switch x.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if x.IsNil() || y.IsNil() {
+			return x.IsNil() == y.IsNil()
+		}
+		return equalSyntax(x.Elem(), y.Elem())
+	case reflect.Slice:
+		if x.Len() != y.Len() {
+			return false
+		}
+		for i := 0; i < x.Len(); i++ {
+			if !equalSyntax(x.Index(i), y.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < x.NumField(); i++ {
+			switch x.Type().Field(i).Type {
+			case posType, objectType, scopeType, commentGroupType:
+				continue
+			case blockStmtPtrType:
+				if x.Type() == funcDeclType {
+					continue // function body
+				}
+			}
+			if !equalSyntax(x.Field(i), y.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.String:
+		return x.String() == y.String()
+	case reflect.Bool:
+		return x.Bool() == y.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return x.Int() == y.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return x.Uint() == y.Uint()
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestRecheckFile(t *testing.T) {
+	const (
+		a = `package p
+
+type T struct{ x int }
+
+func (T) m() int { return 0 }
+
+var V = f()
+`
+		b = `package p
+
+import "unsafe"
+
+func (t T) n() int { return t.x }
+
+func f() int { return g() }
+
+func g() int { return 0 }
+
+const S = unsafe.Sizeof(T{})
+`
+		// b with a different function body
+		b2 = `package p
+
+import "unsafe"
+
+// n has a new body.
+func (t T) n() int {
+	var y int
+	return t.z
+}
+
+func f() int { return 0 }
+
+func g() int { return 0 }
+
+const S = unsafe.Sizeof(T{})
+`
+		// b with a different declaration
+		b3 = `package p
+
+import "unsafe"
+
+func (t T) n() int { return t.x }
+
+func f() string { return "" }
+
+func g() int { return 0 }
+
+const S = unsafe.Sizeof(T{})
+`
+	)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range []string{a, b, b2, b3} {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("f%d.go", i), src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	conf := Config{Error: func(error) {}}
+	pkg := NewPackage("p", "")
+	check := NewChecker(&conf, fset, pkg, nil)
+	if err := check.Files(files[:2]); err != nil {
+		t.Fatal(err)
+	}
+	T := pkg.Scope().Lookup("T").Type()
+	n, _, _ := LookupFieldOrMethod(T, false, pkg, "n")
+
+	// recheck b2 in place of b
+	var errors []string
+	conf.Error = func(err error) { errors = append(errors, err.Error()) }
+	info := Info{
+		Defs: make(map[*ast.Ident]Object),
+		Uses: make(map[*ast.Ident]Object),
+	}
+	if err := check.RecheckFile(files[1], files[2], &info); err == nil {
+		t.Fatal("RecheckFile succeeded unexpectedly")
+	}
+	want := []string{
+		"f2.go:8:9: invalid operation: t (variable of type T) has no field or method z",
+		"f2.go:7:6: y declared but not used",
+	}
+	if fmt.Sprint(errors) != fmt.Sprint(want) {
+		t.Errorf("got errors %q; want %q", errors, want)
+	}
+
+	// only the modified file was checked
+	for id := range info.Uses {
+		if pos := id.Pos(); pos < files[2].Pos() || pos > files[2].End() {
+			t.Errorf("%s: unexpected use of %s outside of modified file", fset.Position(pos), id.Name)
+		}
+	}
+
+	// the package-level objects of the modified file were reused, with new positions
+	for id, obj := range info.Defs {
+		if obj == nil || obj.Parent() != pkg.Scope() && obj.Name() != "n" {
+			continue
+		}
+		if got, want := fset.Position(obj.Pos()), fset.Position(id.Pos()); got != want {
+			t.Errorf("%s declared at %s; want %s", obj.Name(), got, want)
+		}
+	}
+	if got, _, _ := LookupFieldOrMethod(T, false, pkg, "n"); got != n {
+		t.Errorf("got method %v; want %v", got, n)
+	}
+	if got, want := fset.Position(n.Pos()).String(), "f2.go:6:12"; got != want {
+		t.Errorf("method n declared at %s; want %s", got, want)
+	}
+	if got := pkg.Scope().Lookup("f").Type().String(); got != "func() int" {
+		t.Errorf("f has type %s", got)
+	}
+	if got := fmt.Sprint(info.InitOrder); got != "[V = f()]" {
+		t.Errorf("got init order %s", got)
+	}
+
+	// rechecking the file declaring T retains T
+	a2, err := parser.ParseFile(fset, "a2.go", "// a2\n"+a, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errors = nil
+	check.RecheckFile(files[0], a2, nil)
+	if errors != nil {
+		t.Errorf("unexpected errors %q", errors)
+	}
+	if got := pkg.Scope().Lookup("T").Type(); got != T {
+		t.Errorf("got type %v; want %v", got, T)
+	}
+	if got := T.Underlying().String(); got != "struct{x int}" {
+		t.Errorf("T has underlying type %s", got)
+	}
+	if got := NewMethodSet(T).Len(); got != 2 {
+		t.Errorf("T has %d methods; want 2", got)
+	}
+
+	// a changed declaration cannot be rechecked
+	errors = nil
+	err = check.RecheckFile(files[2], files[3], nil)
+	if _, ok := err.(Error); err == nil || ok {
+		t.Errorf("got error %v; want non-type error", err)
+	}
+	if errors != nil {
+		t.Errorf("unexpected errors %q", errors)
+	}
+	if got := pkg.Scope().Lookup("f").Type().String(); got != "func() int" {
+		t.Errorf("f has type %s after failed recheck", got)
+	}
+
+	// the file must belong to the package
+	if err := check.RecheckFile(files[1], files[3], nil); err == nil {
+		t.Errorf("recheck of replaced file succeeded unexpectedly")
+	}
+}
+
+func TestRecheckFileMembers(t *testing.T) {
+	const (
+		a = `package p
+
+type T struct{ f int }
+
+type I interface{ M() }
+
+var V struct{ g struct{ h int } }
+`
+		b = `package p
+
+func f(x T, i I) int {
+	i.M()
+	return x.f + V.g.h
+}
+`
+	)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range []string{a, b, "// a2\n" + a} {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("f%d.go", i), src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	var conf Config
+	info := Info{Uses: make(map[*ast.Ident]Object)}
+	pkg := NewPackage("p", "")
+	check := NewChecker(&conf, fset, pkg, &info)
+	if err := check.Files(files[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// recheck a, which declares the fields and methods used by b
+	info2 := Info{Defs: make(map[*ast.Ident]Object)}
+	if err := check.RecheckFile(files[0], files[2], &info2); err != nil {
+		t.Fatal(err)
+	}
+
+	scope := pkg.Scope()
+	T := scope.Lookup("T").Type().Underlying().(*Struct)
+	I := scope.Lookup("I").Type().Underlying().(*Interface)
+	V := scope.Lookup("V").Type().(*Struct)
+	members := map[string]Object{
+		"f": T.Field(0),
+		"M": I.Method(0),
+		"g": V.Field(0),
+		"h": V.Field(0).Type().(*Struct).Field(0),
+	}
+
+	// the uses in b refer to the members of the current types
+	for id, obj := range info.Uses {
+		if want, ok := members[id.Name]; ok && obj != want {
+			t.Errorf("%s: %s refers to stale object", fset.Position(id.Pos()), id.Name)
+		}
+	}
+
+	// the members were defined in the rechecked file
+	for id, obj := range info2.Defs {
+		if want, ok := members[id.Name]; ok {
+			if obj != want {
+				t.Errorf("%s: %s defines new object", fset.Position(id.Pos()), id.Name)
+			}
+			if obj.Pos() != id.Pos() {
+				t.Errorf("%s declared at %s", id.Name, fset.Position(obj.Pos()))
+			}
+		}
+	}
+	if got := I.NumMethods(); got != 1 || I.Method(0) != members["M"] {
+		t.Errorf("interface I has stale methods")
+	}
+}
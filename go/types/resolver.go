@@ -10,6 +10,8 @@ import (
 	"go/ast"
 	"go/token"
 	pathLib "path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -60,22 +62,22 @@ func (check *Checker) arityMatch(s, init *ast.ValueSpec) {
 	case init == nil && r == 0:
 		// var decl w/o init expr
 		if s.Type == nil {
-			check.errorf(s.Pos(), "missing type or init expr")
+			check.errorf(s.Pos(), WrongInitCount, "missing type or init expr")
 		}
 	case l < r:
 		if l < len(s.Values) {
 			// init exprs from s
 			n := s.Values[l]
-			check.errorf(n.Pos(), "extra init expr %s", n)
+			check.errorf(n.Pos(), WrongInitCount, "extra init expr %s", n)
 			// TODO(gri) avoid declared but not used error here
 		} else {
 			// init exprs "inherited"
-			check.errorf(s.Pos(), "extra init expr at %s", init.Pos())
+			check.errorf(s.Pos(), WrongInitCount, "extra init expr at %s", init.Pos())
 			// TODO(gri) avoid declared but not used error here
 		}
 	case l > r && (init != nil || r != 1):
 		n := s.Names[r]
-		check.errorf(n.Pos(), "missing init expr for %s", n)
+		check.errorf(n.Pos(), WrongInitCount, "missing init expr for %s", n)
 	}
 }
 
@@ -104,13 +106,15 @@ func (check *Checker) declarePkgObj(ident *ast.Ident, obj Object, d *declInfo) {
 	// spec: "A package-scope or file-scope identifier with name init
 	// may only be declared to be a function with this (func()) signature."
 	if ident.Name == "init" {
-		check.errorf(ident.Pos(), "cannot declare init - must be func")
+		check.errorf(ident.Pos(), InvalidInitDecl, "cannot declare init - must be func")
 		return
 	}
 
-	check.declare(check.pkg.scope, ident, obj)
+	check.declare(check.pkg.scope, ident, obj, token.NoPos)
 	check.objMap[obj] = d
-	obj.setOrder(uint32(len(check.objMap)))
+	if obj.order() == 0 { // recycled objects keep their order
+		obj.setOrder(uint32(len(check.objMap)))
+	}
 }
 
 // filename returns a filename suitable for debugging output.
@@ -128,16 +132,36 @@ func (check *Checker) filename(fileNo int) string {
 func (check *Checker) collectObjects() {
 	pkg := check.pkg
 
-	importer := check.conf.Import
-	if importer == nil {
-		if DefaultImport != nil {
-			importer = DefaultImport
-		} else {
-			// Panic if we encounter an import.
-			importer = func(map[string]*Package, string) (*Package, error) {
-				panic(`no Config.Import or DefaultImport (missing import _ "golang.org/x/tools/go/gcimporter"?)`)
+	var importer func(path, dir string) (*Package, error)
+	if from := check.conf.ImporterFrom; from != nil {
+		importer = func(path, dir string) (*Package, error) {
+			imp, err := from.ImportFrom(path, dir, 0)
+			if err != nil {
+				return nil, err
+			}
+			if imp == nil {
+				return nil, errors.New("Config.ImporterFrom returned nil but no error")
+			}
+			if check.conf.Packages[imp.path] == nil {
+				check.conf.Packages[imp.path] = imp
+			}
+			return imp, nil
+		}
+	} else {
+		imp := check.conf.Import
+		if imp == nil {
+			if DefaultImport != nil {
+				imp = DefaultImport
+			} else {
+				// Panic if we encounter an import.
+				imp = func(map[string]*Package, string) (*Package, error) {
+					panic(`no Config.Import or DefaultImport (missing import _ "golang.org/x/tools/go/gcimporter"?)`)
+				}
 			}
 		}
+		importer = func(path, _ string) (*Package, error) {
+			return imp(check.conf.Packages, path)
+		}
 	}
 
 	// pkgImports is the set of packages already imported by any package file seen
@@ -149,13 +173,18 @@ func (check *Checker) collectObjects() {
 	}
 
 	for fileNo, file := range check.files {
+		check.checkCanceled()
+
 		// The package identifier denotes the current package,
 		// but there is no corresponding package object.
 		check.recordDef(file.Name, nil)
 
-		fileScope := NewScope(check.pkg.scope, check.filename(fileNo))
+		fileScope := NewScope(check.pkg.scope, file.Pos(), file.End(), check.filename(fileNo))
 		check.recordScope(file, fileScope)
 
+		// directory of the importing file, for ImporterFrom
+		fileDir := filepath.Dir(check.fset.Position(file.Name.Pos()).Filename)
+
 		for _, decl := range file.Decls {
 			switch d := decl.(type) {
 			case *ast.BadDecl:
@@ -170,21 +199,24 @@ func (check *Checker) collectObjects() {
 						var imp *Package
 						path, err := validatedImportPath(s.Path.Value)
 						if err != nil {
-							check.errorf(s.Path.Pos(), "invalid import path (%s)", err)
+							check.errorf(s.Path.Pos(), BrokenImport, "invalid import path (%s)", err)
 							continue
 						}
 						if path == "C" && check.conf.FakeImportC {
-							// TODO(gri) shouldn't create a new one each time
-							imp = NewPackage("C", "C")
-							imp.fake = true
+							// all imports of "C" share the same fake package
+							if check.fakeC == nil {
+								check.fakeC = NewPackage("C", "C")
+								check.fakeC.fake = true
+							}
+							imp = check.fakeC
 						} else {
 							var err error
-							imp, err = importer(check.conf.Packages, path)
+							imp, err = importer(path, fileDir)
 							if imp == nil && err == nil {
 								err = errors.New("Config.Import returned nil but no error")
 							}
 							if err != nil {
-								check.errorf(s.Path.Pos(), "could not import %s (%s)", path, err)
+								check.errorf(s.Path.Pos(), BrokenImport, "could not import %s (%s)", path, err)
 								continue
 							}
 						}
@@ -194,7 +226,7 @@ func (check *Checker) collectObjects() {
 						// for clients; it is not needed for type-checking)
 						if !pkgImports[imp] {
 							pkgImports[imp] = true
-							if imp != Unsafe {
+							if imp != Unsafe && !imp.fake {
 								pkg.imports = append(pkg.imports, imp)
 							}
 						}
@@ -204,7 +236,7 @@ func (check *Checker) collectObjects() {
 						if s.Name != nil {
 							name = s.Name.Name
 							if name == "init" {
-								check.errorf(s.Name.Pos(), "cannot declare init - must be func")
+								check.errorf(s.Name.Pos(), InvalidInitDecl, "cannot declare init - must be func")
 								continue
 							}
 						}
@@ -220,10 +252,18 @@ func (check *Checker) collectObjects() {
 						// add import to file scope
 						if name == "." {
 							// merge imported scope with file scope
-							for _, obj := range imp.scope.elems {
+							for name, obj := range imp.scope.elems {
 								// A package scope may contain non-exported objects,
 								// do not import them!
-								if obj.Exported() {
+								if ast.IsExported(name) {
+									// objects that are constructed on demand simply
+									// remain so (unless the name is already declared)
+									if lazy, _ := obj.(*lazyObject); lazy != nil {
+										if fileScope.InsertLazy(name, func() Object { return resolve(lazy) }) {
+											continue
+										}
+									}
+									obj = resolve(obj)
 									// TODO(gri) When we import a package, we create
 									// a new local package object. We should do the
 									// same for each dot-imported object. That way
@@ -232,8 +272,7 @@ func (check *Checker) collectObjects() {
 									// information because the same package - found
 									// via Config.Packages - may be dot-imported in
 									// another package!)
-									check.declare(fileScope, nil, obj)
-									check.recordImplicit(s, obj)
+									check.declare(fileScope, nil, obj, token.NoPos)
 								}
 							}
 							// add position to set of dot-import positions for this file
@@ -241,10 +280,11 @@ func (check *Checker) collectObjects() {
 							check.addUnusedDotImport(fileScope, imp, s.Pos())
 						} else {
 							// declare imported package object in file scope
-							check.declare(fileScope, nil, obj)
+							check.declare(fileScope, nil, obj, token.NoPos)
 						}
 
 					case *ast.ValueSpec:
+						doc := specDoc(d, s.Doc)
 						switch d.Tok {
 						case token.CONST:
 							// determine which initialization expressions to use
@@ -254,10 +294,12 @@ func (check *Checker) collectObjects() {
 							case last == nil:
 								last = new(ast.ValueSpec) // make sure last exists
 							}
+							check.recordIota(s, iota)
 
 							// declare all constants
 							for i, name := range s.Names {
-								obj := NewConst(name.Pos(), pkg, name.Name, nil, exact.MakeInt64(int64(iota)))
+								obj := check.recycle(NewConst(name.Pos(), pkg, name.Name, nil, exact.MakeInt64(int64(iota)))).(*Const)
+								check.recordDoc(obj, doc)
 
 								var init ast.Expr
 								if i < len(last.Values) {
@@ -286,7 +328,8 @@ func (check *Checker) collectObjects() {
 
 							// declare all variables
 							for i, name := range s.Names {
-								obj := NewVar(name.Pos(), pkg, name.Name, nil)
+								obj := check.recycle(NewVar(name.Pos(), pkg, name.Name, nil)).(*Var)
+								check.recordDoc(obj, doc)
 								lhs[i] = obj
 
 								d := d1
@@ -309,7 +352,8 @@ func (check *Checker) collectObjects() {
 						}
 
 					case *ast.TypeSpec:
-						obj := NewTypeName(s.Name.Pos(), pkg, s.Name.Name, nil)
+						obj := check.recycle(NewTypeName(s.Name.Pos(), pkg, s.Name.Name, nil)).(*TypeName)
+						check.recordDoc(obj, specDoc(d, s.Doc))
 						check.declarePkgObj(s.Name, obj, &declInfo{file: fileScope, typ: s.Type})
 
 					default:
@@ -319,7 +363,8 @@ func (check *Checker) collectObjects() {
 
 			case *ast.FuncDecl:
 				name := d.Name.Name
-				obj := NewFunc(d.Name.Pos(), pkg, name, nil)
+				obj := check.recycle(NewFunc(d.Name.Pos(), pkg, name, nil)).(*Func)
+				check.recordDoc(obj, d.Doc)
 				if d.Recv == nil {
 					// regular function
 					if name == "init" {
@@ -328,10 +373,10 @@ func (check *Checker) collectObjects() {
 						check.recordDef(d.Name, obj)
 						// init functions must have a body
 						if d.Body == nil {
-							check.softErrorf(obj.pos, "missing function body")
+							check.softErrorf(obj.pos, MissingFuncBody, "missing function body")
 						}
 					} else {
-						check.declare(pkg.scope, d.Name, obj)
+						check.declare(pkg.scope, d.Name, obj, token.NoPos)
 					}
 				} else {
 					// method
@@ -352,7 +397,9 @@ func (check *Checker) collectObjects() {
 				}
 				info := &declInfo{file: fileScope, fdecl: d}
 				check.objMap[obj] = info
-				obj.setOrder(uint32(len(check.objMap)))
+				if obj.order() == 0 { // recycled objects keep their order
+					obj.setOrder(uint32(len(check.objMap)))
+				}
 
 			default:
 				check.invalidAST(d.Pos(), "unknown ast.Decl node %T", d)
@@ -361,16 +408,18 @@ func (check *Checker) collectObjects() {
 	}
 
 	// verify that objects in package and file scopes have different names
+	// (in sorted order, for deterministic error reports)
 	for _, scope := range check.pkg.scope.children /* file scopes */ {
-		for _, obj := range scope.elems {
-			if alt := pkg.scope.Lookup(obj.Name()); alt != nil {
+		for _, name := range scope.Names() {
+			if alt := pkg.scope.Lookup(name); alt != nil {
+				obj := scope.Lookup(name)
 				if pkg, ok := obj.(*PkgName); ok {
-					check.errorf(alt.Pos(), "%s already declared through import of %s", alt.Name(), pkg.Imported())
-					check.reportAltDecl(pkg)
+					check.errorf(alt.Pos(), DuplicateDecl, "%s already declared through import of %s", alt.Name(), pkg.Imported())
+					check.reportAltDecl(pkg, DuplicateDecl)
 				} else {
-					check.errorf(alt.Pos(), "%s already declared through dot-import of %s", alt.Name(), obj.Pkg())
+					check.errorf(alt.Pos(), DuplicateDecl, "%s already declared through dot-import of %s", alt.Name(), obj.Pkg())
 					// TODO(gri) dot-imported objects don't have a position; reportAltDecl won't print anything
-					check.reportAltDecl(obj)
+					check.reportAltDecl(obj, DuplicateDecl)
 				}
 			}
 		}
@@ -390,6 +439,7 @@ func (check *Checker) packageObjects(objList []Object) {
 	typePath := make([]*TypeName, 0, 8)
 
 	for _, obj := range objList {
+		check.checkCanceled()
 		check.objDecl(obj, nil, typePath)
 	}
 
@@ -402,13 +452,18 @@ func (check *Checker) packageObjects(objList []Object) {
 
 // functionBodies typechecks all function bodies.
 func (check *Checker) functionBodies() {
+	if n := check.conf.FuncBodyWorkers; n > 1 && len(check.funcs) > 1 {
+		check.concurrentFunctionBodies(n)
+		return
+	}
 	for _, f := range check.funcs {
+		check.checkCanceled()
 		check.funcBody(f.decl, f.name, f.sig, f.body)
 	}
 }
 
-// unusedImports checks for unused imports.
-func (check *Checker) unusedImports() {
+// unusedImports checks for unused imports in the given file scopes.
+func (check *Checker) unusedImports(fileScopes []*Scope) {
 	// if function bodies are not checked, packages' uses are likely missing - don't check
 	if check.conf.IgnoreFuncBodies {
 		return
@@ -418,8 +473,11 @@ func (check *Checker) unusedImports() {
 	// any of its exported identifiers. To import a package solely for its side-effects
 	// (initialization), use the blank identifier as explicit package name."
 
+	// errors are reported in source order
+	var unused []diagnostic
+
 	// check use of regular imported packages
-	for _, scope := range check.pkg.scope.children /* file scopes */ {
+	for _, scope := range fileScopes {
 		for _, obj := range scope.elems {
 			if obj, ok := obj.(*PkgName); ok {
 				// Unused "blank imports" are automatically ignored
@@ -428,9 +486,9 @@ func (check *Checker) unusedImports() {
 					path := obj.imported.path
 					base := pathLib.Base(path)
 					if obj.name == base {
-						check.softErrorf(obj.pos, "%q imported but not used", path)
+						unused = append(unused, diagnostic{obj.pos, fmt.Sprintf("%q imported but not used", path)})
 					} else {
-						check.softErrorf(obj.pos, "%q imported but not used as %s", path, obj.name)
+						unused = append(unused, diagnostic{obj.pos, fmt.Sprintf("%q imported but not used as %s", path, obj.name)})
 					}
 				}
 			}
@@ -440,7 +498,22 @@ func (check *Checker) unusedImports() {
 	// check use of dot-imported packages
 	for _, unusedDotImports := range check.unusedDotImports {
 		for pkg, pos := range unusedDotImports {
-			check.softErrorf(pos, "%q imported but not used", pkg.path)
+			unused = append(unused, diagnostic{pos, fmt.Sprintf("%q imported but not used", pkg.path)})
 		}
 	}
+
+	sort.Sort(byPos(unused))
+	for _, d := range unused {
+		check.softErrorf(d.pos, UnusedImport, "%s", d.msg)
+	}
+}
+
+// specDoc returns the doc comment for a spec of the declaration d:
+// the spec's own doc comment if present, or else the doc comment of
+// the declaration (which may group several specs).
+func specDoc(d *ast.GenDecl, doc *ast.CommentGroup) *ast.CommentGroup {
+	if doc != nil {
+		return doc
+	}
+	return d.Doc
 }
@@ -9,9 +9,12 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
+	"golang.org/x/tools/go/exact"
 	_ "golang.org/x/tools/go/gcimporter"
 	. "golang.org/x/tools/go/types"
 )
@@ -185,3 +188,138 @@ func TestResolveIdents(t *testing.T) {
 
 	// TODO(gri) add tests to check ImplicitObj callbacks
 }
+
+func TestFakeImportC(t *testing.T) {
+	sources := []string{
+		`package p; import "C"; func f() int { x := 1; return int(C.g(x)) }`,
+		`package p; import "C"; var v C.int; var w = f()`,
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range sources {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("p%d.go", i), src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	conf := Config{FakeImportC: true}
+	info := Info{Uses: make(map[*ast.Ident]Object)}
+	pkg, err := conf.Check("p", fset, files, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// non-cgo identifiers are resolved, and all "C"s denote the same package
+	var pkgC *Package
+	uses := make(map[string]bool)
+	for id, obj := range info.Uses {
+		if obj, _ := obj.(*PkgName); obj != nil {
+			if pkgC != nil && obj.Imported() != pkgC {
+				t.Errorf("%s: imports of C denote different packages", fset.Position(id.Pos()))
+			}
+			pkgC = obj.Imported()
+		}
+		uses[id.Name] = true
+	}
+	for _, name := range []string{"C", "x", "f", "int"} {
+		if !uses[name] {
+			t.Errorf("no use of %s recorded", name)
+		}
+	}
+	if uses["g"] {
+		t.Errorf("use of C.g recorded")
+	}
+
+	if len(pkg.Imports()) != 0 {
+		t.Errorf("got imports %v; want none", pkg.Imports())
+	}
+}
+
+// dirImporter is an ImporterFrom that resolves import paths like the go
+// command resolves vendored packages, using packages indexed by path.
+type dirImporter struct {
+	pkgs  map[string]*Package
+	calls []string
+}
+
+func (imp *dirImporter) ImportFrom(path, dir string, mode ImportMode) (*Package, error) {
+	imp.calls = append(imp.calls, path+" from "+dir)
+	if mode != 0 {
+		return nil, fmt.Errorf("unexpected mode %d", mode)
+	}
+	for d := dir; d != "." && d != "/"; d = filepath.Dir(d) {
+		if pkg := imp.pkgs[filepath.Join(d, "vendor", path)]; pkg != nil {
+			return pkg, nil
+		}
+	}
+	if pkg := imp.pkgs[path]; pkg != nil {
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("package %s not found", path)
+}
+
+func TestImporterFrom(t *testing.T) {
+	newPkg := func(path string) *Package {
+		pkg := NewPackage(path, "x")
+		pkg.Scope().Insert(NewConst(token.NoPos, pkg, "Path", Typ[UntypedString], exact.MakeString(path)))
+		pkg.MarkComplete()
+		return pkg
+	}
+	imp := &dirImporter{pkgs: map[string]*Package{
+		"x":          newPkg("x"),
+		"a/vendor/x": newPkg("a/vendor/x"),
+	}}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range []string{"a/b/b.go", "c/c.go"} {
+		f, err := parser.ParseFile(fset, name, `package p; import "x"; const _ = x.Path`, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	// ImporterFrom takes precedence over Import
+	conf := Config{
+		Import: func(map[string]*Package, string) (*Package, error) {
+			return nil, fmt.Errorf("Import called")
+		},
+		ImporterFrom: imp,
+		Packages:     make(map[string]*Package),
+	}
+	info := &Info{Uses: make(map[*ast.Ident]Object)}
+	pkg, err := conf.Check("p", fset, files, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := strings.Join(imp.calls, ", "), "x from a/b, x from c"; got != want {
+		t.Errorf("got calls %s; want %s", got, want)
+	}
+	var got []string
+	for _, f := range files {
+		for id, obj := range info.Uses {
+			if id.Name == "Path" && id.Pos() >= f.Pos() && id.Pos() < f.End() {
+				got = append(got, exact.StringVal(obj.(*Const).Val()))
+			}
+		}
+	}
+	if want := []string{"a/vendor/x", "x"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got packages %v; want %v", got, want)
+	}
+	var imports []string
+	for _, imp := range pkg.Imports() {
+		imports = append(imports, imp.Path())
+	}
+	if got, want := strings.Join(imports, " "), "a/vendor/x x"; got != want {
+		t.Errorf("got imports %s; want %s", got, want)
+	}
+	for _, path := range []string{"a/vendor/x", "x"} {
+		if conf.Packages[path] != imp.pkgs[path] {
+			t.Errorf("package %s not recorded in Packages", path)
+		}
+	}
+}
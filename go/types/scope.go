@@ -9,9 +9,11 @@ package types
 import (
 	"bytes"
 	"fmt"
+	"go/token"
 	"io"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // TODO(gri) Provide scopes with a name or other mechanism so that
@@ -25,13 +27,16 @@ type Scope struct {
 	parent   *Scope
 	children []*Scope
 	comment  string            // for debugging only
-	elems    map[string]Object // lazily allocated
+	elems    map[string]Object // lazily allocated; entries may be *lazyObjects
+	pos, end token.Pos         // scope extent; may be invalid
 }
 
 // NewScope returns a new, empty scope contained in the given parent
-// scope, if any.  The comment is for debugging only.
-func NewScope(parent *Scope, comment string) *Scope {
-	s := &Scope{parent: parent, comment: comment}
+// scope, if any. The scope extends from pos to end; pos and end may
+// be invalid if the scope has no (contiguous) extent. The comment is
+// for debugging only.
+func NewScope(parent *Scope, pos, end token.Pos, comment string) *Scope {
+	s := &Scope{parent: parent, comment: comment, pos: pos, end: end}
 	// don't add children to Universe scope!
 	if parent != nil && parent != Universe {
 		parent.children = append(parent.children, s)
@@ -66,20 +71,22 @@ func (s *Scope) Child(i int) *Scope { return s.children[i] }
 // Lookup returns the object in scope s with the given name if such an
 // object exists; otherwise the result is nil.
 func (s *Scope) Lookup(name string) Object {
-	return s.elems[name]
+	return resolve(s.elems[name])
 }
 
 // LookupParent follows the parent chain of scopes starting with s until
 // it finds a scope where Lookup(name) returns a non-nil object, and then
-// returns that scope and object. If no such scope exists, the result is (nil, nil).
+// returns that scope and object. If a valid position pos is provided,
+// only objects that were declared at or before pos are considered.
+// If no such scope and object exists, the result is (nil, nil).
 //
 // Note that obj.Parent() may be different from the returned scope if the
 // object was inserted into the scope and already had a parent at that
 // time (see Insert, below). This can only happen for dot-imported objects
 // whose scope is the scope of the package that exported them.
-func (s *Scope) LookupParent(name string) (*Scope, Object) {
+func (s *Scope) LookupParent(name string, pos token.Pos) (*Scope, Object) {
 	for ; s != nil; s = s.parent {
-		if obj := s.elems[name]; obj != nil {
+		if obj := resolve(s.elems[name]); obj != nil && (!pos.IsValid() || obj.scopePos() <= pos) {
 			return s, obj
 		}
 	}
@@ -94,7 +101,7 @@ func (s *Scope) LookupParent(name string) (*Scope, Object) {
 func (s *Scope) Insert(obj Object) Object {
 	name := obj.Name()
 	if alt := s.elems[name]; alt != nil {
-		return alt
+		return resolve(alt)
 	}
 	if s.elems == nil {
 		s.elems = make(map[string]Object)
@@ -106,35 +113,95 @@ func (s *Scope) Insert(obj Object) Object {
 	return nil
 }
 
+// InsertLazy is like Insert, but it inserts an object with the given
+// name that is constructed on demand: resolve is called, at most once,
+// when the object is first looked up, and must return an object with
+// that name. InsertLazy permits importers to defer decoding of objects
+// of imported packages until they are actually used. Unlike Insert,
+// InsertLazy does not resolve an alternative object found in s; it
+// reports whether the object was inserted.
+//
+// The scope of a package that is imported by several concurrently
+// running Checkers may contain lazy objects; the resolve functions of
+// different objects of the package may thus be called concurrently.
+//
+func (s *Scope) InsertLazy(name string, resolve func() Object) bool {
+	if s.elems[name] != nil {
+		return false
+	}
+	if s.elems == nil {
+		s.elems = make(map[string]Object)
+	}
+	s.elems[name] = &lazyObject{parent: s, resolve: resolve}
+	return true
+}
+
+// Pos and End describe the scope's source code extent [pos, end).
+// The results are guaranteed to be valid only if the type-checked
+// AST has complete position information. The extent is undefined
+// for Universe and package scopes.
+func (s *Scope) Pos() token.Pos { return s.pos }
+func (s *Scope) End() token.Pos { return s.end }
+
+// Contains returns true if pos is within the scope's extent.
+// The result is guaranteed to be valid only if the type-checked
+// AST has complete position information.
+func (s *Scope) Contains(pos token.Pos) bool {
+	return s.pos <= pos && pos < s.end
+}
+
+// Innermost returns the innermost (child) scope containing
+// pos. If pos is not within any scope, the result is nil.
+// Package scopes have no extent; for them, Innermost
+// considers the scopes of the package files instead.
+// The result is guaranteed to be valid only if the
+// type-checked AST has complete position information.
+func (s *Scope) Innermost(pos token.Pos) *Scope {
+	// Package scopes do not have extents since they may be
+	// discontiguous, so iterate over the package's files.
+	if s.parent == Universe {
+		for _, s := range s.children {
+			if inner := s.Innermost(pos); inner != nil {
+				return inner
+			}
+		}
+	}
+
+	if s.Contains(pos) {
+		for _, s := range s.children {
+			if s.Contains(pos) {
+				return s.Innermost(pos)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
 // WriteTo writes a string representation of the scope to w,
 // with the scope elements sorted by name.
 // The level of indentation is controlled by n >= 0, with
 // n == 0 for no indentation.
-// If recurse is set, it also writes nested (children) scopes.
+// If recurse is set, it also writes nested (children) scopes,
+// including those of scopes without elements.
 func (s *Scope) WriteTo(w io.Writer, n int, recurse bool) {
 	const ind = ".  "
 	indn := strings.Repeat(ind, n)
 
-	fmt.Fprintf(w, "%s%s scope %p {", indn, s.comment, s)
-	if len(s.elems) == 0 {
-		fmt.Fprintf(w, "}\n")
-		return
-	}
+	fmt.Fprintf(w, "%s%s scope %p {\n", indn, s.comment, s)
 
-	fmt.Fprintln(w)
 	indn1 := indn + ind
 	for _, name := range s.Names() {
-		fmt.Fprintf(w, "%s%s\n", indn1, s.elems[name])
+		fmt.Fprintf(w, "%s%s\n", indn1, s.Lookup(name))
 	}
 
 	if recurse {
 		for _, s := range s.children {
-			fmt.Fprintln(w)
 			s.WriteTo(w, n+1, recurse)
 		}
 	}
 
-	fmt.Fprintf(w, "%s}", indn)
+	fmt.Fprintf(w, "%s}\n", indn)
 }
 
 // String returns a string representation of the scope, for debugging.
@@ -143,3 +210,47 @@ func (s *Scope) String() string {
 	s.WriteTo(&buf, 0, false)
 	return buf.String()
 }
+
+// A lazyObject represents an object of a scope that is constructed on
+// demand (see Scope.InsertLazy). It is never returned to clients.
+type lazyObject struct {
+	parent  *Scope
+	resolve func() Object
+	obj     Object
+	once    sync.Once
+}
+
+// resolve returns the object represented by obj, constructing it
+// if obj is a lazyObject that has not been resolved yet.
+func resolve(obj Object) Object {
+	lazy, _ := obj.(*lazyObject)
+	if lazy == nil {
+		return obj
+	}
+	lazy.once.Do(func() {
+		obj := lazy.resolve()
+		if obj.Parent() == nil {
+			obj.setParent(lazy.parent)
+		}
+		lazy.obj = obj
+		lazy.resolve = nil
+	})
+	return lazy.obj
+}
+
+// A lazyObject is only an Object so that it can be stored in a scope;
+// none of its methods are ever called.
+func (*lazyObject) Parent() *Scope                        { unreachable(); return nil }
+func (*lazyObject) Pos() token.Pos                        { unreachable(); return token.NoPos }
+func (*lazyObject) Pkg() *Package                         { unreachable(); return nil }
+func (*lazyObject) Name() string                          { unreachable(); return "" }
+func (*lazyObject) Type() Type                            { unreachable(); return nil }
+func (*lazyObject) Exported() bool                        { unreachable(); return false }
+func (*lazyObject) Id() string                            { unreachable(); return "" }
+func (*lazyObject) String() string                        { unreachable(); return "" }
+func (*lazyObject) order() uint32                         { unreachable(); return 0 }
+func (*lazyObject) setOrder(uint32)                       { unreachable() }
+func (*lazyObject) setParent(*Scope)                      { unreachable() }
+func (*lazyObject) scopePos() token.Pos                   { unreachable(); return token.NoPos }
+func (*lazyObject) setScopePos(pos token.Pos)             { unreachable() }
+func (*lazyObject) sameId(pkg *Package, name string) bool { unreachable(); return false }
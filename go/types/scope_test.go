@@ -0,0 +1,208 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sync"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestScopeLookupParent(t *testing.T) {
+	const src = `package p
+var x int
+func f(x int) int {
+	_ = x
+	{
+		_ = x // x is declared below
+		x := x
+		_ = x
+	}
+	type T int
+	var _ T
+	for i := range []T{} {
+		_ = i
+	}
+	switch y := interface{}(x).(type) {
+	case int:
+		_ = y
+	}
+	return x
+}
+var _ = func(z int) int { return z + x }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{
+		Defs: make(map[*ast.Ident]Object),
+		Uses: make(map[*ast.Ident]Object),
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each use of an identifier must resolve, via the innermost
+	// scope containing it, to the object it denotes.
+	for id, want := range info.Uses {
+		inner := pkg.Scope().Innermost(id.Pos())
+		if inner == nil {
+			t.Errorf("%s: no scope for %s", fset.Position(id.Pos()), id.Name)
+			continue
+		}
+		if _, got := inner.LookupParent(id.Name, id.Pos()); got != want {
+			t.Errorf("%s: LookupParent(%s) = %v; want %v", fset.Position(id.Pos()), id.Name, declPos(fset, got), declPos(fset, want))
+		}
+	}
+
+	// Without a valid position, all objects in scope are found.
+	for id, obj := range info.Defs {
+		if obj == nil || obj.Name() != "x" || obj.Parent() == pkg.Scope() {
+			continue
+		}
+		// obj is the parameter x or the local x
+		if _, got := obj.Parent().LookupParent("x", token.NoPos); got != obj {
+			t.Errorf("%s: LookupParent(x, NoPos) = %v; want %v", fset.Position(id.Pos()), declPos(fset, got), declPos(fset, obj))
+		}
+	}
+
+	// Positions outside the package files are not in any scope.
+	if s := pkg.Scope().Innermost(token.Pos(fset.Base() + 1)); s != nil {
+		t.Errorf("Innermost(end of file set) = %v; want nil", s)
+	}
+}
+
+// declPos returns a description of obj including its declaration position.
+func declPos(fset *token.FileSet, obj Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%s (declared at %s)", obj, fset.Position(obj.Pos()))
+}
+
+func TestScopeWriteTo(t *testing.T) {
+	const src = `package p
+func f() {
+	{
+		var x int
+		_ = x
+	}
+}`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the function scope of f has no elements, but a child scope
+	s := pkg.Scope()
+	if s.NumChildren() != 1 || s.Child(0).NumChildren() != 1 {
+		t.Fatal("unexpected scope tree")
+	}
+	fscope := s.Child(0).Child(0)
+	if fscope.Len() != 0 || fscope.NumChildren() != 1 {
+		t.Fatalf("unexpected function scope %s", fscope)
+	}
+
+	var buf bytes.Buffer
+	s.WriteTo(&buf, 0, true)
+	got := regexp.MustCompile(`scope 0x[0-9a-f]+`).ReplaceAllString(buf.String(), "scope")
+	const want = `package "p" scope {
+.  func p.f()
+.  p scope {
+.  .  function scope {
+.  .  .  block scope {
+.  .  .  .  var x int
+.  .  .  }
+.  .  }
+.  }
+}
+`
+	if got != want {
+		t.Errorf("got:\n%swant:\n%s", got, want)
+	}
+}
+
+func TestInsertLazy(t *testing.T) {
+	lib := NewPackage("lib", "lib")
+	var mu sync.Mutex
+	resolved := make(map[string]int)
+	for _, name := range []string{"A", "B", "C", "d"} {
+		name := name
+		if !lib.Scope().InsertLazy(name, func() Object {
+			mu.Lock()
+			resolved[name]++
+			mu.Unlock()
+			return NewVar(token.NoPos, lib, name, Typ[Int])
+		}) {
+			t.Fatalf("InsertLazy(%s) failed", name)
+		}
+	}
+	lib.MarkComplete()
+	if lib.Scope().InsertLazy("A", nil) {
+		t.Errorf("InsertLazy(A) succeeded twice")
+	}
+	if got := lib.Scope().Len(); got != 4 {
+		t.Errorf("got %d objects; want 4", got)
+	}
+	if len(resolved) != 0 {
+		t.Errorf("objects resolved before use: %v", resolved)
+	}
+
+	importer := func(_ map[string]*Package, path string) (*Package, error) {
+		return lib, nil
+	}
+	fset := token.NewFileSet()
+	check := func(src string) {
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conf := Config{Import: importer}
+		if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// only the objects used are resolved
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check(`package p; import "lib"; var _ = lib.A + lib.B`)
+		}()
+	}
+	wg.Wait()
+	if got, want := fmt.Sprint(resolved), "map[A:1 B:1]"; got != want {
+		t.Errorf("got resolved objects %s; want %s", got, want)
+	}
+
+	// so does a dot-import
+	check(`package p; import . "lib"; var _ = A`)
+	if got, want := fmt.Sprint(resolved), "map[A:1 B:1]"; got != want {
+		t.Errorf("got resolved objects %s; want %s", got, want)
+	}
+	check(`package p; import . "lib"; var _ = C`)
+	if got, want := fmt.Sprint(resolved), "map[A:1 B:1 C:1]"; got != want {
+		t.Errorf("got resolved objects %s; want %s", got, want)
+	}
+
+	obj := lib.Scope().Lookup("d")
+	if obj == nil || obj.Name() != "d" || obj.Parent() != lib.Scope() {
+		t.Errorf("got %v; want var d in package lib", obj)
+	}
+}
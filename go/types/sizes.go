@@ -132,19 +132,77 @@ func (s *StdSizes) Sizeof(T Type) int64 {
 		if n == 0 {
 			return 0
 		}
-		offsets := t.offsets
-		if t.offsets == nil {
-			// compute offsets on demand
-			offsets = s.Offsetsof(t.fields)
-			t.offsets = offsets
+		// Compute the offsets inline rather than via Offsetsof so
+		// that the size of each field is computed only once; the
+		// size of deeply nested structs is otherwise exponential.
+		var o int64
+		for _, f := range t.fields {
+			o = align(o, s.Alignof(f.typ))
+			o += s.Sizeof(f.typ)
 		}
-		return offsets[n-1] + s.Sizeof(t.fields[n-1].typ)
+		return o
 	case *Interface:
 		return s.WordSize * 2
 	}
 	return s.WordSize // catch-all
 }
 
+// gcArchSizes and gccgoArchSizes record the word size and maximum
+// alignment used by the respective compiler for each architecture.
+var gcArchSizes = map[string]StdSizes{
+	"386":      {4, 4},
+	"arm":      {4, 4},
+	"arm64":    {8, 8},
+	"amd64":    {8, 8},
+	"amd64p32": {4, 8},
+	"mips":     {4, 4},
+	"mipsle":   {4, 4},
+	"mips64":   {8, 8},
+	"mips64le": {8, 8},
+	"ppc64":    {8, 8},
+	"ppc64le":  {8, 8},
+	"s390x":    {8, 8},
+}
+
+var gccgoArchSizes = map[string]StdSizes{
+	"386":      {4, 4},
+	"arm":      {4, 8},
+	"arm64":    {8, 8},
+	"amd64":    {8, 8},
+	"amd64p32": {4, 8},
+	"mips":     {4, 8},
+	"mipsle":   {4, 8},
+	"mips64":   {8, 8},
+	"mips64le": {8, 8},
+	"ppc":      {4, 8},
+	"ppc64":    {8, 8},
+	"ppc64le":  {8, 8},
+	"s390":     {4, 8},
+	"s390x":    {8, 8},
+	"sparc":    {4, 8},
+	"sparc64":  {8, 8},
+}
+
+// SizesFor returns the Sizes used by a compiler ("gc" or "gccgo") for
+// an architecture (a GOARCH value such as "arm64"). The result is nil
+// if the compiler/architecture pair is not known.
+func SizesFor(compiler, arch string) Sizes {
+	var m map[string]StdSizes
+	switch compiler {
+	case "gc":
+		m = gcArchSizes
+	case "gccgo":
+		m = gccgoArchSizes
+	default:
+		return nil
+	}
+	s, ok := m[arch]
+	if !ok {
+		return nil
+	}
+	return &s
+}
+
 // stdSizes is used if Config.Sizes == nil.
 var stdSizes = StdSizes{8, 8}
 
@@ -158,10 +216,11 @@ func (conf *Config) alignof(T Type) int64 {
 	return stdSizes.Alignof(T)
 }
 
+// Offsets are not cached with the struct: the struct may be shared by
+// several packages, checked concurrently with different Sizes.
 func (conf *Config) offsetsof(T *Struct) []int64 {
-	offsets := T.offsets
-	if offsets == nil && T.NumFields() > 0 {
-		// compute offsets on demand
+	var offsets []int64
+	if T.NumFields() > 0 {
 		if s := conf.Sizes; s != nil {
 			offsets = s.Offsetsof(T.fields)
 			// sanity checks
@@ -176,7 +235,6 @@ func (conf *Config) offsetsof(T *Struct) []int64 {
 		} else {
 			offsets = stdSizes.Offsetsof(T.fields)
 		}
-		T.offsets = offsets
 	}
 	return offsets
 }
@@ -204,6 +262,68 @@ func (conf *Config) sizeof(T Type) int64 {
 	return stdSizes.Sizeof(T)
 }
 
+// A FieldLayout describes the position of a single struct field.
+type FieldLayout struct {
+	Field   *Var  // the field; embedded fields are not flattened
+	Offset  int64 // offset of the field from the start of the struct
+	Size    int64 // size of the field's type
+	Align   int64 // alignment of the field's type
+	Padding int64 // number of padding bytes preceding the field
+}
+
+// A StructLayout describes the memory layout of a struct type
+// as computed by a particular Sizes implementation.
+type StructLayout struct {
+	Fields  []FieldLayout // one entry per field, in source order
+	Size    int64         // size of the struct
+	Align   int64         // alignment of the struct
+	Padding int64         // total number of padding bytes, including trailing padding
+}
+
+// Layout returns the layout of struct s as computed by sizes.
+// Offsets, sizes, and alignments are those reported by the
+// Alignof, Offsetsof, and Sizeof methods of sizes; the struct's
+// trailing padding is the difference between its size and the
+// end of its last field. If sizes is nil, the default sizes
+// used by the type-checker (64bit StdSizes) are assumed.
+// For the layout chosen by a particular compiler and architecture,
+// use the sizes returned by SizesFor.
+func Layout(s *Struct, sizes Sizes) *StructLayout {
+	if sizes == nil {
+		sizes = &stdSizes
+	}
+
+	var offsets []int64
+	if len(s.fields) > 0 {
+		offsets = sizes.Offsetsof(s.fields)
+		if len(offsets) != len(s.fields) {
+			panic("Sizes.Offsetsof returned the wrong number of offsets")
+		}
+	}
+
+	l := &StructLayout{
+		Fields: make([]FieldLayout, len(s.fields)),
+		Size:   sizes.Sizeof(s),
+		Align:  sizes.Alignof(s),
+	}
+	var end int64 // end of the previous field
+	for i, f := range s.fields {
+		fl := FieldLayout{
+			Field:  f,
+			Offset: offsets[i],
+			Size:   sizes.Sizeof(f.typ),
+			Align:  sizes.Alignof(f.typ),
+		}
+		fl.Padding = fl.Offset - end
+		l.Padding += fl.Padding
+		end = fl.Offset + fl.Size
+		l.Fields[i] = fl
+	}
+	l.Padding += l.Size - end // trailing padding
+
+	return l
+}
+
 // align returns the smallest y >= x such that y % a == 0.
 func align(x, a int64) int64 {
 	y := x + a - 1
@@ -0,0 +1,340 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+// gcSizes mimics the layout chosen by gc on amd64: unlike StdSizes,
+// the size of a struct is rounded up to its alignment, and a trailing
+// zero-size field is padded so that its address doesn't point past
+// the end of the struct.
+type gcSizes struct{}
+
+var std = &StdSizes{WordSize: 8, MaxAlign: 8}
+
+func (s gcSizes) Alignof(T Type) int64 {
+	switch t := T.Underlying().(type) {
+	case *Array:
+		return s.Alignof(t.Elem())
+	case *Struct:
+		max := int64(1)
+		for i := 0; i < t.NumFields(); i++ {
+			if a := s.Alignof(t.Field(i).Type()); a > max {
+				max = a
+			}
+		}
+		return max
+	}
+	a := s.Sizeof(T)
+	if a < 1 {
+		return 1
+	}
+	if a > 8 {
+		return 8
+	}
+	return a
+}
+
+func (s gcSizes) Offsetsof(fields []*Var) []int64 {
+	offsets := make([]int64, len(fields))
+	var o int64
+	for i, f := range fields {
+		a := s.Alignof(f.Type())
+		o = (o + a - 1) / a * a
+		offsets[i] = o
+		o += s.Sizeof(f.Type())
+	}
+	return offsets
+}
+
+func (s gcSizes) Sizeof(T Type) int64 {
+	switch t := T.Underlying().(type) {
+	case *Array:
+		return t.Len() * s.Sizeof(t.Elem())
+	case *Struct:
+		n := t.NumFields()
+		if n == 0 {
+			return 0
+		}
+		var fields []*Var
+		for i := 0; i < n; i++ {
+			fields = append(fields, t.Field(i))
+		}
+		last := fields[n-1].Type()
+		end := s.Offsetsof(fields)[n-1] + s.Sizeof(last)
+		if s.Sizeof(last) == 0 {
+			end++
+		}
+		a := s.Alignof(t)
+		return (end + a - 1) / a * a
+	}
+	return std.Sizeof(T)
+}
+
+func TestLayout(t *testing.T) {
+	var tests = []struct {
+		src    string // declaration of type T
+		fields string // field@offset(size,align)+padding
+		size   int64
+		align  int64
+		pad    int64
+	}{
+		{`type T struct{}`, ``, 0, 1, 0},
+		{`type T struct{ b bool; i int64 }`, `b@0(1,1) i@8(8,8)+7`, 16, 8, 7},
+		{`type T struct{ i int64; b bool }`, `i@0(8,8) b@8(1,1)`, 16, 8, 7},
+		{`type T struct{ a, b bool; i int32; c bool }`, `a@0(1,1) b@1(1,1) i@4(4,4)+2 c@8(1,1)`, 12, 4, 5},
+		{`type T struct{ i int64; a [0]int64 }`, `i@0(8,8) a@8(0,8)`, 16, 8, 8},
+		{`type T struct{ a [0]int64; b byte }`, `a@0(0,8) b@0(1,1)`, 8, 8, 7},
+		{`type T struct{ e struct{}; i int32 }`, `e@0(0,1) i@0(4,4)`, 4, 4, 0},
+		{`type T struct{ s string; p *int; x []int }`, `s@0(16,8) p@16(8,8) x@24(24,8)`, 48, 8, 0},
+
+		// embedded fields are not flattened
+		{`type E struct{ a bool; b int32 }; type T struct{ x bool; E; y [2]E }`, `x@0(1,1) E@4(8,4)+3 y@12(16,4)`, 28, 4, 3},
+		{`type E struct{ a int64; b bool }; type T struct{ x bool; *E; y E }`, `x@0(1,1) E@8(8,8)+7 y@16(16,8)`, 32, 8, 7},
+
+		// arrays of structs
+		{`type T struct{ a [3]struct{ i int16; b bool }; b bool }`, `a@0(12,2) b@12(1,1)`, 14, 2, 1},
+	}
+
+	for _, test := range tests {
+		src := "package p; " + test.src
+		pkg, err := pkgFor("layout.go", src, nil)
+		if err != nil {
+			t.Errorf("%s: %s", src, err)
+			continue
+		}
+		s := pkg.Scope().Lookup("T").Type().Underlying().(*Struct)
+		l := Layout(s, gcSizes{})
+
+		var fields []string
+		for _, f := range l.Fields {
+			str := fmt.Sprintf("%s@%d(%d,%d)", f.Field.Name(), f.Offset, f.Size, f.Align)
+			if f.Padding != 0 {
+				str += fmt.Sprintf("+%d", f.Padding)
+			}
+			fields = append(fields, str)
+		}
+		if got := strings.Join(fields, " "); got != test.fields {
+			t.Errorf("%s: got fields %s; want %s", src, got, test.fields)
+		}
+		if l.Size != test.size || l.Align != test.align || l.Padding != test.pad {
+			t.Errorf("%s: got size %d, align %d, padding %d; want %d, %d, %d",
+				src, l.Size, l.Align, l.Padding, test.size, test.align, test.pad)
+		}
+	}
+}
+
+// Layout must agree with StdSizes, and with the default sizes if none are given.
+func TestLayoutStdSizes(t *testing.T) {
+	const src = `package p; type T struct{ a bool; b int64; c bool; d [0]int32 }`
+	pkg, err := pkgFor("layout.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := pkg.Scope().Lookup("T").Type().Underlying().(*Struct)
+
+	for _, sizes := range []Sizes{nil, std} {
+		l := Layout(s, sizes)
+		var offsets []int64
+		for _, f := range l.Fields {
+			offsets = append(offsets, f.Offset)
+		}
+		if got, want := fmt.Sprint(offsets), "[0 8 16 20]"; got != want {
+			t.Errorf("got offsets %s; want %s", got, want)
+		}
+		// StdSizes doesn't round struct sizes up to their alignment
+		if l.Size != 20 || l.Align != 8 || l.Padding != 10 {
+			t.Errorf("got size %d, align %d, padding %d; want 20, 8, 10", l.Size, l.Align, l.Padding)
+		}
+	}
+}
+
+// The unsafe built-ins evaluate to constants for the configured sizes.
+func TestUnsafeConstantsSizes(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+type T struct {
+	a bool
+	b int
+	c string
+}
+
+var x T
+
+const (
+	size   = unsafe.Sizeof(x)
+	align  = unsafe.Alignof(x.b)
+	offset = unsafe.Offsetof(x.c)
+)`
+	for _, test := range []struct {
+		sizes *StdSizes
+		want  string
+	}{
+		{nil, "32 8 16"}, // default sizes
+		{&StdSizes{WordSize: 8, MaxAlign: 8}, "32 8 16"},
+		{&StdSizes{WordSize: 4, MaxAlign: 4}, "16 4 8"},
+		{&StdSizes{WordSize: 8, MaxAlign: 4}, "28 4 12"},
+	} {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var conf Config
+		if test.sizes != nil {
+			conf.Sizes = test.sizes
+		}
+		pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var vals []string
+		for _, name := range []string{"size", "align", "offset"} {
+			vals = append(vals, pkg.Scope().Lookup(name).(*Const).Val().String())
+		}
+		if got := strings.Join(vals, " "); got != test.want {
+			t.Errorf("%+v: got %s; want %s", test.sizes, got, test.want)
+		}
+	}
+}
+
+// The size of deeply nested structs is computed in reasonable time.
+func TestDeeplyNestedStructSizes(t *testing.T) {
+	const depth = 100
+	var buf bytes.Buffer
+	buf.WriteString("package p\n\nimport \"unsafe\"\n\ntype T0 struct{ a int }\n")
+	for i := 1; i <= depth; i++ {
+		fmt.Fprintf(&buf, "type T%d struct{ a T%d }\n", i, i-1)
+	}
+	fmt.Fprintf(&buf, "const size = unsafe.Sizeof(T%d{})\n", depth)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", buf.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pkg.Scope().Lookup("size").(*Const).Val().String(); got != "8" {
+		t.Errorf("got size %s; want 8", got)
+	}
+}
+
+func TestSizesFor(t *testing.T) {
+	for _, test := range []struct {
+		compiler, arch     string
+		wordSize, maxAlign int64 // 0 if unknown
+	}{
+		{"gc", "amd64", 8, 8},
+		{"gc", "386", 4, 4},
+		{"gc", "arm", 4, 4},
+		{"gc", "arm64", 8, 8},
+		{"gc", "amd64p32", 4, 8},
+		{"gccgo", "arm", 4, 8},
+		{"gc", "vax", 0, 0},
+		{"tcc", "amd64", 0, 0},
+	} {
+		sizes := SizesFor(test.compiler, test.arch)
+		if test.wordSize == 0 {
+			if sizes != nil {
+				t.Errorf("%s/%s: got %v; want nil", test.compiler, test.arch, sizes)
+			}
+			continue
+		}
+		s, ok := sizes.(*StdSizes)
+		if !ok || s.WordSize != test.wordSize || s.MaxAlign != test.maxAlign {
+			t.Errorf("%s/%s: got %v; want word size %d, max align %d", test.compiler, test.arch, sizes, test.wordSize, test.maxAlign)
+		}
+	}
+}
+
+// Layout reflects the sizes of the target architecture.
+func TestLayoutSizesFor(t *testing.T) {
+	const src = `package p; type T struct{ a byte; b uintptr; c complex128; d []int }`
+	pkg, err := pkgFor("layout.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := pkg.Scope().Lookup("T").Type().Underlying().(*Struct)
+
+	for _, test := range []struct {
+		arch    string
+		offsets string
+		size    int64
+		padding int64
+	}{
+		{"amd64", "[0 8 16 32]", 56, 7},
+		{"386", "[0 4 8 24]", 36, 3},
+		{"amd64p32", "[0 4 8 24]", 36, 3},
+	} {
+		l := Layout(s, SizesFor("gc", test.arch))
+		var offsets []int64
+		for _, f := range l.Fields {
+			offsets = append(offsets, f.Offset)
+		}
+		if got := fmt.Sprint(offsets); got != test.offsets || l.Size != test.size || l.Padding != test.padding {
+			t.Errorf("%s: got offsets %s, size %d, padding %d; want %s, %d, %d",
+				test.arch, got, l.Size, l.Padding, test.offsets, test.size, test.padding)
+		}
+	}
+}
+
+// Constant overflow of int and uint depends on the configured sizes.
+func TestIntOverflowSizes(t *testing.T) {
+	const src = `package p
+
+var (
+	a = 1 << 40
+	b int = -1 << 31
+	c uint = 1<<32 - 1
+	d uint = 1 << 32
+	e = len("x") + 1<<31
+)`
+	for _, test := range []struct {
+		arch string
+		want string // names of overflowing variables
+	}{
+		{"amd64", ""},
+		{"386", "a d e"},
+		{"arm64", ""},
+		{"arm", "a d e"},
+		{"amd64p32", "a d e"},
+	} {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		conf := Config{
+			Sizes: SizesFor("gc", test.arch),
+			Error: func(err error) {
+				e := err.(Error)
+				if e.Code == NumericOverflow {
+					names = append(names, string("abcde"[fset.Position(e.Pos).Line-4]))
+				}
+			},
+		}
+		conf.Check("p", fset, []*ast.File{f}, nil)
+		if got := strings.Join(names, " "); got != test.want {
+			t.Errorf("%s: got overflows in %q; want %q", test.arch, got, test.want)
+		}
+	}
+}
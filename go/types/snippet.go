@@ -0,0 +1,115 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements CheckFile and CheckSnippet.
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+)
+
+// CheckFile is like Check but for a package consisting of a single file.
+// If path is empty, the file's package name is used as package path.
+// A package main is not required to declare a main function; imports
+// are resolved with Config.Import as usual.
+func (conf *Config) CheckFile(path string, fset *token.FileSet, file *ast.File, info *Info) (*Package, error) {
+	if path == "" {
+		path = file.Name.Name
+	}
+	return conf.Check(path, fset, []*ast.File{file}, info)
+}
+
+// CheckSnippet type-checks src, which must be a list of statements or
+// a single expression, as if it were the body of a function in package
+// main. The packages with the given import paths are imported under
+// their package names and may be used in src; unused imports are not
+// reported. If src is an expression, its type and value are recorded
+// in info (if provided) as for any other expression.
+//
+// The source is added to fset as file filename, together with the
+// declarations needed to complete it. The lines and columns of positions
+// reported for src (e.g., in errors) are relative to src, but their byte
+// offsets (token.Position.Offset) are offsets in the completed file.
+// The result is the package and the function body containing the
+// statements of src; for an expression, the body consists of the
+// assignment _ = src, unless src is a call without result, in which
+// case it consists of the call statement.
+//
+func (conf *Config) CheckSnippet(fset *token.FileSet, filename, src string, imports []string, info *Info) (*Package, *ast.BlockStmt, error) {
+	// the imports are made available for convenience only
+	c := *conf
+	c.DisableUnusedImportCheck = true
+
+	file, err := parser.ParseFile(fset, filename, snippetFile(filename, src, imports), 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := file.Decls[len(file.Decls)-1].(*ast.FuncDecl).Body
+
+	// An expression is parsed as an expression statement. Whether
+	// it is a call without result is only known when it is checked;
+	// otherwise it is checked as an assignment (see snippetValue).
+	var e *snippetExpr
+	if len(body.List) == 1 {
+		if s, _ := body.List[0].(*ast.ExprStmt); s != nil {
+			e = &snippetExpr{stmt: s}
+		}
+	}
+
+	pkg := NewPackage("main", "")
+	check := NewChecker(&c, fset, pkg, info)
+	check.snippet = e
+	err = check.Files([]*ast.File{file})
+	if e != nil && e.assign != nil {
+		body.List[0] = e.assign
+	}
+	return pkg, body, err
+}
+
+// A snippetExpr is the statement of an expression checked by CheckSnippet.
+type snippetExpr struct {
+	stmt   *ast.ExprStmt
+	assign *ast.AssignStmt // the assignment _ = stmt.X, if stmt.X has a value
+}
+
+// snippetValue reports whether s is the statement of an expression
+// checked by CheckSnippet that is not a call without result. If so, the
+// value x of the expression is checked as if it were assigned to the
+// blank identifier, and the respective assignment is recorded.
+func (check *Checker) snippetValue(s *ast.ExprStmt, x *operand) bool {
+	e := check.snippet
+	if e == nil || e.stmt != s {
+		return false
+	}
+	switch x.mode {
+	case invalid:
+		return true // error reported before
+	case constant, variable, mapindex, value, commaok:
+		// ok
+	default:
+		return false
+	}
+	blank := &ast.Ident{NamePos: s.Pos(), Name: "_"}
+	check.assignVar(blank, x)
+	e.assign = &ast.AssignStmt{Lhs: []ast.Expr{blank}, TokPos: s.Pos(), Tok: token.ASSIGN, Rhs: []ast.Expr{s.X}}
+	return true
+}
+
+// snippetFile returns the source of the file completing the snippet src.
+func snippetFile(filename, src string, imports []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("package main\n")
+	for _, path := range imports {
+		fmt.Fprintf(&buf, "import %s\n", strconv.Quote(path))
+	}
+	// Use a line directive so that lines and columns are relative to src.
+	fmt.Fprintf(&buf, "func _() {\n//line %s:1:1\n%s\n}\n", filename, src)
+	return buf.Bytes()
+}
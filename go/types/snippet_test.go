@@ -0,0 +1,161 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestCheckFile(t *testing.T) {
+	const src = `package script
+
+import "unsafe"
+
+var size = unsafe.Sizeof(x)
+var x int32
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "script.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	var conf Config
+	pkg, err := conf.CheckFile("", fset, f, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Path() != "script" || pkg.Name() != "script" {
+		t.Errorf("got package %s (path %q); want script", pkg.Name(), pkg.Path())
+	}
+	if len(info.Types) == 0 {
+		t.Errorf("no types recorded")
+	}
+}
+
+func TestCheckSnippet(t *testing.T) {
+	var conf Config
+
+	// expression
+	fset := token.NewFileSet()
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	_, body, err := conf.CheckSnippet(fset, "expr", `unsafe.Sizeof(0) * 2`, []string{"unsafe"}, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr := body.List[0].(*ast.AssignStmt).Rhs[0]
+	if tv := info.Types[expr]; tv.Type != Typ[Uintptr] || tv.Value == nil || tv.Value.String() != "16" {
+		t.Errorf("got %s = %s (type %s); want 16 (type uintptr)", ExprString(expr), tv.Value, tv.Type)
+	}
+	if pos := fset.Position(expr.Pos()); pos.Filename != "expr" || pos.Line != 1 {
+		t.Errorf("got position %s; want expr:1", pos)
+	}
+
+	// call without result
+	info = Info{Types: make(map[ast.Expr]TypeAndValue)}
+	_, body, err = conf.CheckSnippet(fset, "call", `println(1)`, nil, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := body.List[0].(*ast.ExprStmt).X
+	if tv := info.Types[call]; !tv.IsVoid() {
+		t.Errorf("got %s (type %s); want no value", ExprString(call), tv.Type)
+	}
+
+	// call with result
+	info = Info{Types: make(map[ast.Expr]TypeAndValue)}
+	_, body, err = conf.CheckSnippet(fset, "len", `len("foo")`, nil, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr = body.List[0].(*ast.AssignStmt).Rhs[0]
+	if tv := info.Types[expr]; tv.Type != Typ[Int] || tv.Value == nil || tv.Value.String() != "3" {
+		t.Errorf("got %s = %s (type %s); want 3 (type int)", ExprString(expr), tv.Value, tv.Type)
+	}
+
+	// statement list of calls
+	_, body, err = conf.CheckSnippet(fset, "calls", "println(1)\nprintln(2)", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(body.List); n != 2 {
+		t.Errorf("got %d statements; want 2", n)
+	}
+
+	// statements
+	info = Info{Defs: make(map[*ast.Ident]Object)}
+	_, body, err = conf.CheckSnippet(fset, "stmts", "x := len(\"foo\")\ny := x * 2\n_ = y", nil, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(body.List); n != 3 {
+		t.Errorf("got %d statements; want 3", n)
+	}
+	for id, obj := range info.Defs {
+		if id.Name == "y" {
+			if obj.Type() != Typ[Int] {
+				t.Errorf("got type %s for y; want int", obj.Type())
+			}
+			if pos := fset.Position(id.Pos()); pos.Filename != "stmts" || pos.Line != 2 {
+				t.Errorf("got position %s for y; want stmts:2", pos)
+			}
+		}
+	}
+
+	// error positions are relative to the snippet
+	_, _, err = conf.CheckSnippet(fset, "errs", "x := 1\n_ = x + undeclared", nil, nil)
+	e, ok := err.(Error)
+	if !ok {
+		t.Fatalf("got error %v; want undeclared name error", err)
+	}
+	if pos := fset.Position(e.Pos); pos.Filename != "errs" || pos.Line != 2 || pos.Column != 9 || e.Msg != "undeclared name: undeclared" {
+		t.Errorf("got error %v; want undeclared name error at errs:2:9", err)
+	}
+
+	// so are columns on the first line, but not byte offsets
+	for _, src := range []string{"1 + undeclared", "len(undeclared)"} {
+		_, _, err = conf.CheckSnippet(fset, "col", src, nil, nil)
+		e, ok = err.(Error)
+		if !ok {
+			t.Fatalf("%s: got error %v; want undeclared name error", src, err)
+		}
+		pos := fset.Position(e.Pos)
+		if want := strings.Index(src, "undeclared"); pos.Line != 1 || pos.Column != 1+want || pos.Offset == want {
+			t.Errorf("%s: got error %v (offset %d); want error at col:1:%d", src, err, pos.Offset, 1+want)
+		}
+	}
+}
+
+func TestCheckSnippetOnce(t *testing.T) {
+	q, err := pkgFor("q", `package q; func F() int { return 0 }; func G() {}`, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The snippet is checked once: the hooks of the
+	// configuration, such as Import, are called once.
+	imports := 0
+	conf := Config{
+		Import: func(map[string]*Package, string) (*Package, error) {
+			imports++
+			return q, nil
+		},
+	}
+	for _, src := range []string{`q.F()`, `q.G()`, `q.F() + 1`, "x := q.F()\nq.G()\n_ = x"} {
+		imports = 0
+		if _, _, err := conf.CheckSnippet(token.NewFileSet(), "once", src, []string{"q"}, nil); err != nil {
+			t.Errorf("%s: %s", src, err)
+		}
+		if imports != 1 {
+			t.Errorf("%s: Import called %d times; want once", src, imports)
+		}
+	}
+}
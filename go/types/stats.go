@@ -0,0 +1,44 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Stats.
+
+package types
+
+import "time"
+
+// Stats records statistics about type-checking, for clients tuning
+// large analyses (see Config.Stats). The checker adds to the values
+// already present; thus, a Stats may be used to accumulate statistics
+// for several packages, but not for packages checked concurrently.
+type Stats struct {
+	Objects int // objects declared, including implicitly declared objects
+	Types   int // types constructed for type literals
+	Shared  int // types replaced by an identical shared type (see Context)
+	Exprs   int // expressions checked, including subexpressions
+	Funcs   int // function bodies checked, including those of function literals
+
+	// time spent in the phases of type-checking
+	Collect   time.Duration // collecting objects and importing packages
+	Decls     time.Duration // checking package-level declarations
+	Bodies    time.Duration // checking function bodies
+	Other     time.Duration // computing the initialization order and other checks
+	Analyzers time.Duration // running Config.Analyzers
+}
+
+// add adds the counters of t to s.
+func (s *Stats) add(t *Stats) {
+	s.Objects += t.Objects
+	s.Types += t.Types
+	s.Shared += t.Shared
+	s.Exprs += t.Exprs
+	s.Funcs += t.Funcs
+}
+
+// phase adds the time elapsed since start to *d and returns the current time.
+func (s *Stats) phase(d *time.Duration, start time.Time) time.Time {
+	now := time.Now()
+	*d += now.Sub(start)
+	return now
+}
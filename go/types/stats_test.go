@@ -0,0 +1,51 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestStats(t *testing.T) {
+	const src = `package p
+
+type T struct{ f []int }
+
+func f(x int) *T {
+	g := func() {}
+	g()
+	return &T{[]int{x}}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats Stats
+	conf := Config{Stats: &stats}
+	for i := 1; i <= 2; i++ {
+		if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// statistics accumulate
+		// objects: T, f (field), f (func), x, result, g
+		// types: struct{...}, []int (twice), *T, func() (signature of f is not a literal)
+		// shared: the second []int, and the type *T of &T{...}
+		if stats.Objects != 6*i || stats.Types != 5*i || stats.Shared != 2*i || stats.Funcs != 2*i {
+			t.Errorf("check %d: got %d objects, %d types, %d shared types, %d functions; want %d, %d, %d, %d",
+				i, stats.Objects, stats.Types, stats.Shared, stats.Funcs, 6*i, 5*i, 2*i, 2*i)
+		}
+		if stats.Exprs == 0 || stats.Collect+stats.Decls+stats.Bodies+stats.Other <= 0 {
+			t.Errorf("check %d: missing statistics: %+v", i, stats)
+		}
+	}
+}
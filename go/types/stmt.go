@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"sort"
 
 	"golang.org/x/tools/go/exact"
 )
@@ -23,6 +24,8 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 		defer fmt.Println("--- <end>")
 	}
 
+	check.stats.Funcs++
+
 	// save/restore current context and setup function context
 	// (and use 0 indentation at function start)
 	defer func(ctxt context, indent int) {
@@ -36,6 +39,9 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 	}
 	check.indent = 0
 
+	// the function scope extends to the end of the function body
+	sig.scope.end = body.End()
+
 	check.stmtList(0, body.List)
 
 	if check.hasLabel {
@@ -43,7 +49,7 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 	}
 
 	if sig.results.Len() > 0 && !check.isTerminating(body, "") {
-		check.error(body.Rbrace, "missing return")
+		check.error(body.Rbrace, MissingReturn, "missing return")
 	}
 
 	// spec: "Implementation restriction: A compiler may make it illegal to
@@ -53,14 +59,26 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 	check.usage(sig.scope)
 }
 
+// usage reports the unused variables of scope and its nested scopes,
+// in source order.
 func (check *Checker) usage(scope *Scope) {
-	for _, obj := range scope.elems {
-		if v, _ := obj.(*Var); v != nil && !v.used {
-			check.softErrorf(v.pos, "%s declared but not used", v.name)
+	var unused []diagnostic
+	var collect func(*Scope)
+	collect = func(scope *Scope) {
+		for _, obj := range scope.elems {
+			if v, _ := obj.(*Var); v != nil && !v.used {
+				unused = append(unused, diagnostic{v.pos, v.name})
+			}
+		}
+		for _, scope := range scope.children {
+			collect(scope)
 		}
 	}
-	for _, scope := range scope.children {
-		check.usage(scope)
+	collect(scope)
+
+	sort.Sort(byPos(unused))
+	for _, d := range unused {
+		check.softErrorf(d.pos, UnusedVar, "%s declared but not used", d.msg)
 	}
 }
 
@@ -110,7 +128,7 @@ func (check *Checker) multipleDefaults(list []ast.Stmt) {
 		}
 		if d != nil {
 			if first != nil {
-				check.errorf(d.Pos(), "multiple defaults (first at %s)", first.Pos())
+				check.errorf(d.Pos(), DuplicateDefault, "multiple defaults (first at %s)", first.Pos())
 			} else {
 				first = d
 			}
@@ -119,7 +137,7 @@ func (check *Checker) multipleDefaults(list []ast.Stmt) {
 }
 
 func (check *Checker) openScope(s ast.Stmt, comment string) {
-	scope := NewScope(check.scope, comment)
+	scope := NewScope(check.scope, s.Pos(), s.End(), comment)
 	check.recordScope(s, scope)
 	check.scope = scope
 }
@@ -149,7 +167,7 @@ func (check *Checker) suspendedCall(keyword string, call *ast.CallExpr) {
 	default:
 		unreachable()
 	}
-	check.errorf(x.pos(), "%s %s %s", keyword, msg, &x)
+	check.errorf(x.pos(), UnusedResult, "%s %s %s", keyword, msg, &x)
 }
 
 func (check *Checker) caseValues(x operand /* copy argument (not *operand!) */, values []ast.Expr) {
@@ -158,13 +176,13 @@ func (check *Checker) caseValues(x operand /* copy argument (not *operand!) */,
 		var y operand
 		check.expr(&y, e)
 		if y.mode == invalid {
-			return
+			continue // error reported before
 		}
 		// TODO(gri) The convertUntyped call pair below appears in other places. Factor!
 		// Order matters: By comparing y against x, error positions are at the case values.
 		check.convertUntyped(&y, x.typ)
 		if y.mode == invalid {
-			return
+			continue // error reported before
 		}
 		check.convertUntyped(&x, y.typ)
 		if x.mode == invalid {
@@ -186,13 +204,13 @@ L:
 		for t, pos := range seen {
 			if T == nil && t == nil || T != nil && t != nil && Identical(T, t) {
 				// talk about "case" rather than "type" because of nil case
-				check.error(e.Pos(), "duplicate case in type switch")
-				check.errorf(pos, "\tprevious case %s", T) // secondary error, \t indented
+				check.error(e.Pos(), DuplicateCase, "duplicate case in type switch")
+				check.errorf(pos, DuplicateCase, "\tprevious case %s", T) // secondary error, \t indented
 				continue L
 			}
 		}
 		seen[T] = e.Pos()
-		if T != nil {
+		if T != nil && xtyp != nil {
 			check.typeAssertion(e.Pos(), x, xtyp, T)
 		}
 	}
@@ -234,6 +252,9 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		// in statement context. Such statements may be parenthesized."
 		var x operand
 		kind := check.rawExpr(&x, s.X, nil)
+		if check.snippetValue(s, &x) {
+			return
+		}
 		var msg string
 		switch x.mode {
 		default:
@@ -246,7 +267,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		case typexpr:
 			msg = "is not an expression"
 		}
-		check.errorf(x.pos(), "%s %s", &x, msg)
+		check.errorf(x.pos(), UnusedResult, "%s %s", &x, msg)
 
 	case *ast.SendStmt:
 		var ch, x operand
@@ -257,7 +278,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		}
 		if tch, ok := ch.typ.Underlying().(*Chan); !ok || tch.dir == RecvOnly || !check.assignment(&x, tch.elem) {
 			if x.mode != invalid {
-				check.invalidOp(ch.pos(), "cannot send %s to channel %s", &x, &ch)
+				check.invalidOp(ch.pos(), InvalidSend, "cannot send %s to channel %s", &x, &ch)
 			}
 		}
 
@@ -297,7 +318,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		default:
 			// assignment operations
 			if len(s.Lhs) != 1 || len(s.Rhs) != 1 {
-				check.errorf(s.TokPos, "assignment operation %s requires single-valued expressions", s.Tok)
+				check.errorf(s.TokPos, TooManyValues, "assignment operation %s requires single-valued expressions", s.Tok)
 				return
 			}
 			op := assignOp(s.Tok)
@@ -329,9 +350,9 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				// list in a "return" statement if a different entity (constant, type, or variable)
 				// with the same name as a result parameter is in scope at the place of the return."
 				for _, obj := range res.vars {
-					if _, alt := check.scope.LookupParent(obj.name); alt != nil && alt != obj {
-						check.errorf(s.Pos(), "result parameter %s not in scope at return", obj.name)
-						check.errorf(alt.Pos(), "\tinner declaration of %s", obj)
+					if _, alt := check.scope.LookupParent(obj.name, check.pos); alt != nil && alt != obj {
+						check.errorf(s.Pos(), OutOfScopeResult, "result parameter %s not in scope at return", obj.name)
+						check.errorf(alt.Pos(), OutOfScopeResult, "\tinner declaration of %s", obj)
 						// ok to continue
 					}
 				}
@@ -340,7 +361,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				check.initVars(res.vars, s.Results, s.Return)
 			}
 		} else if len(s.Results) > 0 {
-			check.error(s.Results[0].Pos(), "no result values expected")
+			check.error(s.Results[0].Pos(), WrongResultCount, "no result values expected")
 			check.use(s.Results...)
 		}
 
@@ -352,15 +373,15 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		switch s.Tok {
 		case token.BREAK:
 			if ctxt&breakOk == 0 {
-				check.error(s.Pos(), "break not in for, switch, or select statement")
+				check.error(s.Pos(), MisplacedBranch, "break not in for, switch, or select statement")
 			}
 		case token.CONTINUE:
 			if ctxt&continueOk == 0 {
-				check.error(s.Pos(), "continue not in for statement")
+				check.error(s.Pos(), MisplacedBranch, "continue not in for statement")
 			}
 		case token.FALLTHROUGH:
 			if ctxt&fallthroughOk == 0 {
-				check.error(s.Pos(), "fallthrough statement out of place")
+				check.error(s.Pos(), MisplacedBranch, "fallthrough statement out of place")
 			}
 		default:
 			check.invalidAST(s.Pos(), "branch statement: %s", s.Tok)
@@ -380,7 +401,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		var x operand
 		check.expr(&x, s.Cond)
 		if x.mode != invalid && !isBoolean(x.typ) {
-			check.error(s.Cond.Pos(), "non-boolean condition in if statement")
+			check.error(s.Cond.Pos(), InvalidCond, "non-boolean condition in if statement")
 		}
 		check.stmt(inner, s.Body)
 		if s.Else != nil {
@@ -415,6 +436,8 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 			if x.mode != invalid {
 				check.caseValues(x, clause.List)
+			} else {
+				check.use(clause.List...)
 			}
 			check.openScope(clause, "case")
 			inner := inner
@@ -471,15 +494,15 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			check.invalidAST(s.Pos(), "incorrect form of type switch guard")
 			return
 		}
+		// If x is invalid or not an interface, the case types cannot be
+		// checked against x, but we still check the case clauses so that
+		// the information recorded for their bodies is complete.
 		var x operand
 		check.expr(&x, expr.X)
-		if x.mode == invalid {
-			return
-		}
 		xtyp, _ := x.typ.Underlying().(*Interface)
-		if xtyp == nil {
-			check.errorf(x.pos(), "%s is not an interface", &x)
-			return
+		if x.mode != invalid && xtyp == nil {
+			check.errorf(x.pos(), InvalidAssert, "%s is not an interface", &x)
+			// ok to continue
 		}
 
 		check.multipleDefaults(s.Body.List)
@@ -506,7 +529,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 					T = x.typ
 				}
 				obj := NewVar(lhs.Pos(), check.pkg, lhs.Name, T)
-				check.declare(check.scope, nil, obj)
+				check.declare(check.scope, nil, obj, clause.Colon)
 				check.recordImplicit(clause, obj)
 				// For the "declared but not used" error, all lhs variables act as
 				// one; i.e., if any one of them is 'used', all of them are 'used'.
@@ -517,8 +540,10 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			check.closeScope()
 		}
 
-		// If lhs exists, we must have at least one lhs variable that was used.
-		if lhs != nil {
+		// If lhs exists, we must have at least one lhs variable that was used
+		// (unless x is not a valid interface, in which case an error was
+		// reported before).
+		if lhs != nil && xtyp != nil {
 			var used bool
 			for _, v := range lhsVars {
 				if v.used {
@@ -527,7 +552,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				v.used = true // avoid usage error when checking entire function
 			}
 			if !used {
-				check.softErrorf(lhs.Pos(), "%s declared but not used", lhs.Name)
+				check.softErrorf(lhs.Pos(), UnusedVar, "%s declared but not used", lhs.Name)
 			}
 		}
 
@@ -564,7 +589,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			}
 
 			if !valid {
-				check.error(clause.Comm.Pos(), "select case must be send or receive (possibly with assignment)")
+				check.error(clause.Comm.Pos(), InvalidSelectCase, "select case must be send or receive (possibly with assignment)")
 				continue
 			}
 
@@ -586,14 +611,14 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			var x operand
 			check.expr(&x, s.Cond)
 			if x.mode != invalid && !isBoolean(x.typ) {
-				check.error(s.Cond.Pos(), "non-boolean condition in for statement")
+				check.error(s.Cond.Pos(), InvalidCond, "non-boolean condition in for statement")
 			}
 		}
 		check.simpleStmt(s.Post)
 		// spec: "The init statement may be a short variable
 		// declaration, but the post statement must not."
 		if s, _ := s.Post.(*ast.AssignStmt); s != nil && s.Tok == token.DEFINE {
-			check.softErrorf(s.Pos(), "cannot declare in post statement")
+			check.softErrorf(s.Pos(), InvalidPostDecl, "cannot declare in post statement")
 			check.use(s.Lhs...) // avoid follow-up errors
 		}
 		check.stmt(inner, s.Body)
@@ -607,16 +632,11 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		decl := s.Tok == token.DEFINE
 		var x operand
 		check.expr(&x, s.X)
-		if x.mode == invalid {
-			// if we don't have a declaration, we can still check the loop's body
-			// (otherwise we can't because we are missing the declared variables)
-			if !decl {
-				check.stmt(inner, s.Body)
-			}
-			return
-		}
 
 		// determine key/value types
+		// (if x is invalid or cannot be ranged over, key and val remain nil
+		// and the iteration variables are declared with invalid types so
+		// that the loop's body can still be checked)
 		var key, val Type
 		switch typ := x.typ.Underlying().(type) {
 		case *Basic:
@@ -642,22 +662,18 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 			key = typ.elem
 			val = Typ[Invalid]
 			if typ.dir == SendOnly {
-				check.errorf(x.pos(), "cannot range over send-only channel %s", &x)
+				check.errorf(x.pos(), InvalidRangeExpr, "cannot range over send-only channel %s", &x)
 				// ok to continue
 			}
 			if s.Value != nil {
-				check.errorf(s.Value.Pos(), "iteration over %s permits only one iteration variable", &x)
+				check.errorf(s.Value.Pos(), InvalidIterVar, "iteration over %s permits only one iteration variable", &x)
 				// ok to continue
 			}
 		}
 
-		if key == nil {
-			check.errorf(x.pos(), "cannot range over %s", &x)
-			// if we don't have a declaration, we can still check the loop's body
-			if !decl {
-				check.stmt(inner, s.Body)
-			}
-			return
+		if key == nil && x.mode != invalid {
+			check.errorf(x.pos(), InvalidRangeExpr, "cannot range over %s", &x)
+			// ok to continue
 		}
 
 		// check assignment to/declaration of iteration variables
@@ -689,24 +705,30 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 						vars = append(vars, obj)
 					}
 				} else {
-					check.errorf(lhs.Pos(), "cannot declare %s", lhs)
+					check.errorf(lhs.Pos(), InvalidSyntaxTree, "cannot declare %s", lhs)
 					obj = NewVar(lhs.Pos(), check.pkg, "_", nil) // dummy variable
 				}
 
 				// initialize lhs variable
-				x.mode = value
-				x.expr = lhs // we don't have a better rhs expression to use here
-				x.typ = rhs[i]
-				check.initVar(obj, &x, false)
+				if typ := rhs[i]; typ != nil {
+					x.mode = value
+					x.expr = lhs // we don't have a better rhs expression to use here
+					x.typ = typ
+					check.initVar(obj, &x, false)
+				} else {
+					obj.typ = Typ[Invalid]
+					obj.used = true // don't complain about unused variable
+				}
 			}
 
 			// declare variables
 			if len(vars) > 0 {
+				scopePos := s.X.End()
 				for _, obj := range vars {
-					check.declare(check.scope, nil /* recordDef already called */, obj)
+					check.declare(check.scope, nil /* recordDef already called */, obj, scopePos)
 				}
 			} else {
-				check.error(s.TokPos, "no new variables on left side of :=")
+				check.error(s.TokPos, NoNewVar, "no new variables on left side of :=")
 			}
 		} else {
 			// ordinary assignment
@@ -714,6 +736,9 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				if lhs == nil {
 					continue
 				}
+				if rhs[i] == nil {
+					continue // error reported before
+				}
 				x.mode = value
 				x.expr = lhs // we don't have a better rhs expression to use here
 				x.typ = rhs[i]
@@ -724,6 +749,6 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		check.stmt(inner, s.Body)
 
 	default:
-		check.error(s.Pos(), "invalid statement")
+		check.unsupported(s.Pos(), s)
 	}
 }
@@ -0,0 +1,96 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements struct tag validation (Config.CheckStructTags).
+
+package types
+
+import (
+	"errors"
+	"go/ast"
+	"strconv"
+)
+
+// A TagPair is a key:"value" pair of a struct field tag;
+// the value is unquoted.
+type TagPair struct {
+	Key, Value string
+}
+
+var (
+	errTagSyntax      = errors.New("bad syntax for struct tag pair")
+	errTagKeySyntax   = errors.New("bad syntax for struct tag key")
+	errTagValueSyntax = errors.New("bad syntax for struct tag value")
+)
+
+// parseStructTag parses tag following the reflect.StructTag convention,
+// which is a space-separated list of key:"value" pairs, and returns the
+// pairs in source order.
+func parseStructTag(tag string) ([]TagPair, error) {
+	var pairs []TagPair
+	for tag != "" {
+		// skip leading space
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// scan to colon; a space, a quote, or a control character
+		// is a syntax error
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 {
+			return nil, errTagKeySyntax
+		}
+		if i+1 >= len(tag) || tag[i] != ':' {
+			return nil, errTagSyntax
+		}
+		if tag[i+1] != '"' {
+			return nil, errTagValueSyntax
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// scan quoted string to find value
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, errTagValueSyntax
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, errTagValueSyntax
+		}
+		tag = tag[i+1:]
+
+		pairs = append(pairs, TagPair{key, value})
+	}
+	return pairs, nil
+}
+
+// structTag validates the (unquoted) tag of the struct field tag literal t
+// if Config.CheckStructTags is set, and returns its key:"value" pairs. The
+// result is nil if the tag is not checked, empty, or malformed.
+func (check *Checker) structTag(t *ast.BasicLit, tag string) []TagPair {
+	if !check.conf.CheckStructTags || tag == "" {
+		return nil
+	}
+	pairs, err := parseStructTag(tag)
+	if err != nil {
+		check.softErrorf(t.Pos(), InvalidStructTag, "struct field tag %s not compatible with reflect.StructTag.Get: %s", t.Value, err)
+		return nil
+	}
+	return pairs
+}
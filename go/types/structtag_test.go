@@ -0,0 +1,86 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestStructTags(t *testing.T) {
+	const src = `package p
+
+type T struct {
+	A    int    ` + "`json:\"a\" xml:\"a,attr\"`" + `
+	B, C string ` + "`json:\"-\"`" + `
+	D    int    ` + "`json:a`" + `
+	E    int    ` + "`json:\"a\"xml`" + `
+	F    int    ` + "`:\"a\"`" + `
+	G    int    ` + "`\"x\"`" + `
+	H    int    ` + "`j\\\"son:\"a\"`" + `
+	I    int
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errors []string
+	conf := Config{
+		CheckStructTags: true,
+		Error: func(err error) {
+			if !err.(Error).Soft {
+				t.Errorf("unexpected hard error: %s", err)
+			}
+			errors = append(errors, err.Error())
+		},
+	}
+	info := Info{StructTags: make(map[*Var][]TagPair)}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, &info)
+
+	wantErrors := []string{
+		"p.go:6:14: struct field tag `json:a` not compatible with reflect.StructTag.Get: bad syntax for struct tag value",
+		"p.go:7:14: struct field tag `json:\"a\"xml` not compatible with reflect.StructTag.Get: bad syntax for struct tag pair",
+		"p.go:8:14: struct field tag `:\"a\"` not compatible with reflect.StructTag.Get: bad syntax for struct tag key",
+		"p.go:9:14: struct field tag `\"x\"` not compatible with reflect.StructTag.Get: bad syntax for struct tag key",
+		"p.go:10:14: struct field tag `j\\\"son:\"a\"` not compatible with reflect.StructTag.Get: bad syntax for struct tag pair",
+	}
+	if got := strings.Join(errors, "\n"); got != strings.Join(wantErrors, "\n") {
+		t.Errorf("got errors:\n%s\nwant:\n%s", got, strings.Join(wantErrors, "\n"))
+	}
+
+	styp := pkg.Scope().Lookup("T").Type().Underlying().(*Struct)
+	wantTags := map[string]string{
+		"A": `[{json a} {xml a,attr}]`,
+		"B": `[{json -}]`,
+		"C": `[{json -}]`,
+	}
+	for i := 0; i < styp.NumFields(); i++ {
+		fld := styp.Field(i)
+		got, ok := info.StructTags[fld]
+		want, wantOk := wantTags[fld.Name()]
+		if ok != wantOk || ok && fmt.Sprint(got) != want {
+			t.Errorf("%s: got tag %v (recorded = %v); want %s", fld.Name(), got, ok, want)
+		}
+	}
+
+	// Without CheckStructTags, tags are neither checked nor recorded.
+	conf = Config{}
+	info = Info{StructTags: make(map[*Var][]TagPair)}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(info.StructTags) != 0 {
+		t.Errorf("got %d struct tags recorded; want none", len(info.StructTags))
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestBasicInfo(t *testing.T) {
+	for _, test := range []struct {
+		typ        Type
+		info       BasicInfo
+		isConstTyp bool
+	}{
+		{Typ[Bool], IsBoolean, true},
+		{Typ[Int], IsInteger, true},
+		{Typ[Uint8], IsInteger | IsUnsigned, true},
+		{Typ[Float32], IsFloat, true},
+		{Typ[Complex64], IsComplex, true},
+		{Typ[String], IsString, true},
+		{Typ[UnsafePointer], 0, false},
+		{Typ[UntypedInt], IsInteger | IsUntyped, true},
+		{Typ[UntypedNil], IsUntyped, false},
+	} {
+		b := test.typ.(*Basic)
+		if got := b.Info(); got != test.info {
+			t.Errorf("%s.Info() = %#x; want %#x", b, got, test.info)
+		}
+		if got := b.Info()&IsConstType != 0; got != test.isConstTyp {
+			t.Errorf("%s: got IsConstType = %v; want %v", b, got, test.isConstTyp)
+		}
+		if got := IsInterface(b); got {
+			t.Errorf("IsInterface(%s) = true", b)
+		}
+	}
+
+	iface := NewInterface(nil, nil).Complete()
+	obj := NewTypeName(token.NoPos, nil, "I", nil)
+	for _, typ := range []Type{iface, NewNamed(obj, iface, nil)} {
+		if !IsInterface(typ) {
+			t.Errorf("IsInterface(%s) = false", typ)
+		}
+	}
+}
+
+func TestNamedMethods(t *testing.T) {
+	sources := []string{
+		`package p
+type T struct{}
+func (T) c() {}
+func (*T) a() {}
+func (T) _() {}
+type I interface{ m() }`,
+		`package p
+func (T) b() {}
+func (*T) A() {}`,
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for i, src := range sources {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("p%d.go", i), src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	T := pkg.Scope().Lookup("T").Type().(*Named)
+	var got []string
+	for i := 0; i < T.NumMethods(); i++ {
+		got = append(got, T.Method(i).Name())
+	}
+	if want := "c a b A"; strings.Join(got, " ") != want {
+		t.Errorf("got methods %v; want %s", got, want)
+	}
+
+	// interface methods belong to the underlying type
+	if n := pkg.Scope().Lookup("I").Type().(*Named).NumMethods(); n != 0 {
+		t.Errorf("got %d methods for I; want 0", n)
+	}
+}
+
+func TestNewInterface(t *testing.T) {
+	pkg := NewPackage("p", "p")
+	sig := NewSignature(nil, nil, nil, nil, false)
+	newMethod := func(name string) *Func {
+		return NewFunc(token.NoPos, pkg, name, sig)
+	}
+	newNamedIface := func(name string, methods ...*Func) *Named {
+		obj := NewTypeName(token.NoPos, pkg, name, nil)
+		return NewNamed(obj, NewInterface(methods, nil), nil)
+	}
+
+	// B and A are passed out of order; they are complete by the
+	// time the outer interface is completed.
+	B := newNamedIface("B", newMethod("b"))
+	A := newNamedIface("A", newMethod("a"))
+	iface := NewInterface([]*Func{newMethod("d"), newMethod("c")}, []*Named{B, A}).Complete()
+
+	var explicit, embedded, all []string
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		explicit = append(explicit, iface.ExplicitMethod(i).Name())
+	}
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		embedded = append(embedded, iface.Embedded(i).Obj().Name())
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		all = append(all, iface.Method(i).Name())
+	}
+	check := func(what string, got []string, want string) {
+		if s := strings.Join(got, " "); s != want {
+			t.Errorf("%s: got %q; want %q", what, s, want)
+		}
+	}
+	check("explicit methods", explicit, "c d")
+	check("embedded types", embedded, "A B")
+	check("all methods", all, "a b c d")
+
+	if got, want := iface.String(), "interface{c(); d(); p.A; p.B}"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+
+	// a named type with all four methods implements iface, one with
+	// only three methods doesn't
+	newNamedStruct := func(name string, methods ...string) *Named {
+		obj := NewTypeName(token.NoPos, pkg, name, nil)
+		var list []*Func
+		for _, m := range methods {
+			list = append(list, newMethod(m))
+		}
+		return NewNamed(obj, NewStruct(nil, nil), list)
+	}
+	if T := newNamedStruct("T", "a", "b", "c", "d"); !Implements(T, iface) {
+		t.Errorf("%s does not implement %s", T, iface)
+	}
+	if T := newNamedStruct("T", "a", "c", "d"); Implements(T, iface) {
+		t.Errorf("%s implements %s", T, iface)
+	}
+}
+
+func TestTypeConstructors(t *testing.T) {
+	const src = `package p
+var V struct {
+	a [4]byte
+	b []*int
+	c map[string]chan<- bool
+	d func(x int, y ...string) (error, bool)
+	e interface{ m() }
+	_ int "tag"
+}`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := pkg.Scope().Lookup("V").Type()
+
+	field := func(name string, typ Type) *Var {
+		return NewField(token.NoPos, pkg, name, typ, false)
+	}
+	param := func(name string, typ Type) *Var {
+		return NewParam(token.NoPos, pkg, name, typ)
+	}
+	sig := NewSignature(nil, nil,
+		NewTuple(param("x", Typ[Int]), param("y", NewSlice(Typ[String]))),
+		NewTuple(param("", Universe.Lookup("error").Type()), param("", Typ[Bool])),
+		true)
+	m := NewFunc(token.NoPos, pkg, "m", NewSignature(nil, nil, nil, nil, false))
+	got := NewStruct([]*Var{
+		field("a", NewArray(Universe.Lookup("byte").Type(), 4)),
+		field("b", NewSlice(NewPointer(Typ[Int]))),
+		field("c", NewMap(Typ[String], NewChan(SendOnly, Typ[Bool]))),
+		field("d", sig),
+		field("e", NewInterface([]*Func{m}, nil).Complete()),
+		field("_", Typ[Int]),
+	}, []string{5: "tag"})
+
+	if !Identical(got, want) {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	if got.String() != want.String() {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
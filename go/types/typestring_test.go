@@ -150,9 +150,44 @@ func TestQualifiedTypeString(t *testing.T) {
 		{NewPointer(pT), p, "*T"},
 		{NewPointer(pT), q, "*p.T"},
 	} {
-		if got := TypeString(test.this, test.typ); got != test.want {
-			t.Errorf("TypeString(%s, %s) = %s, want %s",
-				test.this, test.typ, got, test.want)
+		if got := TypeString(test.typ, RelativeTo(test.this)); got != test.want {
+			t.Errorf("TypeString(%s, RelativeTo(%s)) = %s, want %s",
+				test.typ, test.this, got, test.want)
+		}
+	}
+}
+
+func TestQualifier(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", "package p; type T int; func F(T) *T { return nil }", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf Config
+	p, err := conf.Check("vendor/example.com/p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pT := p.Scope().Lookup("T").Type()
+	pF := p.Scope().Lookup("F")
+
+	byName := func(pkg *Package) string { return pkg.Name() }
+	omit := func(*Package) string { return "" }
+
+	for _, test := range []struct {
+		qf       Qualifier
+		typ, obj string
+	}{
+		{nil, "[]vendor/example.com/p.T", "func vendor/example.com/p.F(vendor/example.com/p.T) *vendor/example.com/p.T"},
+		{byName, "[]p.T", "func p.F(p.T) *p.T"},
+		{omit, "[]T", "func F(T) *T"},
+		{RelativeTo(p), "[]T", "func F(T) *T"},
+	} {
+		if got := TypeString(NewSlice(pT), test.qf); got != test.typ {
+			t.Errorf("TypeString: got %s, want %s", got, test.typ)
+		}
+		if got := ObjectString(pF, test.qf); got != test.obj {
+			t.Errorf("ObjectString: got %s, want %s", got, test.obj)
 		}
 	}
 }
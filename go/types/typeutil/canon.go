@@ -0,0 +1,47 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines Canonicalizer, which interns types.
+
+import "golang.org/x/tools/go/types"
+
+// A Canonicalizer maps each type to a canonical representative of
+// all the types identical to it (per types.Identical), so that
+// canonicalized types may be compared using ==, and used as keys
+// of ordinary Go maps.
+//
+// The zero value for a Canonicalizer is ready to use.
+// Not thread-safe.
+//
+type Canonicalizer struct {
+	m Map // maps each canonical type to itself
+}
+
+// SetHasher sets the hasher used by the Canonicalizer.
+// See Map.SetHasher.
+func (c *Canonicalizer) SetHasher(hasher Hasher) {
+	c.m.SetHasher(hasher)
+}
+
+// Type returns the canonical representative of T: the first type
+// identical to T that was passed to c.Type.
+//
+// Only T itself is canonicalized; its components, such as the
+// element type of a slice, are not, but may be canonicalized
+// separately by the client.
+//
+func (c *Canonicalizer) Type(T types.Type) types.Type {
+	if t := c.m.At(T); t != nil {
+		return t.(types.Type)
+	}
+	c.m.Set(T, T)
+	return T
+}
+
+// Len returns the number of canonical types.
+func (c *Canonicalizer) Len() int {
+	return c.m.Len()
+}
@@ -0,0 +1,48 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestCanonicalizer(t *testing.T) {
+	var c typeutil.Canonicalizer
+
+	sig := func(param types.Type) types.Type {
+		params := types.NewTuple(types.NewVar(0, nil, "x", param))
+		return types.NewSignature(nil, nil, params, nil, false)
+	}
+
+	tests := []struct {
+		x, y types.Type
+	}{
+		{tPStr1, tPStr2},
+		{tChanInt1, tChanInt2},
+		{types.NewSlice(tInt), types.NewSlice(tInt)},
+		{sig(tPStr1), sig(tPStr2)},
+		{tStr, tStr},
+	}
+	for _, test := range tests {
+		x := c.Type(test.x)
+		if x != test.x {
+			t.Errorf("first Type(%s) = %p; want argument %p", test.x, x, test.x)
+		}
+		if y := c.Type(test.y); y != x {
+			t.Errorf("Type(%s) = %p; want canonical %p", test.y, y, x)
+		}
+	}
+	if got, want := c.Len(), len(tests); got != want {
+		t.Errorf("Len() = %d; want %d", got, want)
+	}
+
+	// Non-identical types are not merged.
+	if c.Type(types.NewChan(types.SendOnly, tInt)) == c.Type(tChanInt1) {
+		t.Errorf("chan<- int and <-chan int canonicalized to the same type")
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines Core, which resolves a type to its structure.
+
+import "golang.org/x/tools/go/types"
+
+// Core returns the core type of T, that is the unnamed type obtained
+// by following the underlying types of named types starting with T,
+// and the chain of named types followed on the way; the chain is
+// empty if T is unnamed.
+//
+// For a completely type-checked T, the chain has at most one element
+// and core is T.Underlying(). For incompletely set up types, Core
+// returns nil as core type if it encounters a missing underlying type,
+// and types.Typ[types.Invalid] if the chain is cyclic, rather than
+// looping forever.
+//
+func Core(T types.Type) (core types.Type, chain []*types.Named) {
+	for T != nil {
+		t, ok := T.(*types.Named)
+		if !ok {
+			return T, chain
+		}
+		for _, n := range chain {
+			if n == t {
+				return types.Typ[types.Invalid], chain
+			}
+		}
+		chain = append(chain, t)
+		T = t.Underlying()
+	}
+	return nil, chain
+}
@@ -0,0 +1,54 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestCore(t *testing.T) {
+	const src = `package p
+type C chan int
+type D C
+type S struct{ c C }
+type A B // invalid recursive type
+type B A
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Error: func(error) {}} // ignore the error for A and B
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, nil)
+	lookup := func(name string) types.Type { return pkg.Scope().Lookup(name).Type() }
+
+	for _, test := range []struct {
+		T     types.Type
+		core  string
+		chain int
+	}{
+		{lookup("C"), "chan int", 1},
+		{lookup("D"), "chan int", 1},
+		{lookup("S"), "struct{c p.C}", 1},
+		{lookup("A"), "invalid type", 1},
+		{types.NewSlice(lookup("C")), "[]p.C", 0},
+		{tInt, "int", 0},
+	} {
+		core, chain := typeutil.Core(test.T)
+		if got := core.String(); got != test.core {
+			t.Errorf("Core(%s) = %s; want %s", test.T, got, test.core)
+		}
+		if len(chain) != test.chain || len(chain) > 0 && chain[0] != test.T {
+			t.Errorf("Core(%s): got chain %v; want %d element(s) starting with %s", test.T, chain, test.chain, test.T)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines Diff, which describes how two types differ.
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A ChangeKind describes the kind of a Change.
+type ChangeKind int
+
+const (
+	Added           ChangeKind = iota // component present in the new type only
+	Removed                           // component present in the old type only
+	TypeChanged                       // component has a different type
+	VariadicChanged                   // signature variadic flag differs
+	FieldMoved                        // struct field has a different position
+	EmbeddedChanged                   // struct field embedded flag differs
+	TagChanged                        // struct field has a different tag
+)
+
+// A Change describes a single difference between two types.
+//
+// Path identifies the changed component relative to the compared
+// types as a dot-separated sequence of field and method names and
+// of the selectors params[i], results[i], key, and elem. It is
+// empty if the compared types themselves differ.
+//
+// Old and New are the types of the component in the old and new
+// type, respectively; Old is nil for Added, and New is nil for
+// Removed changes. For VariadicChanged, they are the signatures, and
+// for FieldMoved, EmbeddedChanged, and TagChanged, the structs.
+//
+type Change struct {
+	Kind     ChangeKind
+	Path     string
+	Old, New types.Type
+}
+
+// String returns a description of c with package-qualified types.
+func (c Change) String() string {
+	return c.Format(nil)
+}
+
+// Format returns a description of c; types are formatted with the
+// qualifier qf as for types.TypeString.
+func (c Change) Format(qf types.Qualifier) string {
+	var msg string
+	switch c.Kind {
+	case Added:
+		msg = "added " + types.TypeString(c.New, qf)
+	case Removed:
+		msg = "removed " + types.TypeString(c.Old, qf)
+	case TypeChanged:
+		msg = fmt.Sprintf("changed from %s to %s", types.TypeString(c.Old, qf), types.TypeString(c.New, qf))
+	case VariadicChanged:
+		if c.New.(*types.Signature).Variadic() {
+			msg = "became variadic"
+		} else {
+			msg = "is no longer variadic"
+		}
+	case FieldMoved, EmbeddedChanged, TagChanged:
+		name := c.Path[strings.LastIndex(c.Path, ".")+1:]
+		x, y := c.Old.(*types.Struct), c.New.(*types.Struct)
+		i, j := fieldIndex(x, name), fieldIndex(y, name)
+		switch c.Kind {
+		case FieldMoved:
+			msg = fmt.Sprintf("moved from position %d to %d", i, j)
+		case EmbeddedChanged:
+			if y.Field(j).Anonymous() {
+				msg = "became embedded"
+			} else {
+				msg = "is no longer embedded"
+			}
+		case TagChanged:
+			msg = fmt.Sprintf("tag changed from %q to %q", x.Tag(i), y.Tag(j))
+		}
+	default:
+		msg = fmt.Sprintf("unknown change %d", c.Kind)
+	}
+	if c.Path == "" {
+		return msg
+	}
+	return c.Path + ": " + msg
+}
+
+// Diff returns the list of changes that turn type x into type y;
+// the result is empty if the types are structurally the same.
+//
+// Unlike types.Identical, Diff is intended for comparing different
+// versions of a type, for instance from two versions of a package:
+// named types are the same if they have the same package path and
+// name. If x and y are named types themselves, their underlying
+// types and their declared methods are compared. Struct fields and
+// methods are matched by name; for struct fields, changes of their
+// order (relative to the other fields present in both structs), tag,
+// and embedded flag are reported in addition to changes of their type.
+// For interfaces, the method sets are compared, regardless of whether
+// a method is declared explicitly or through an embedded interface.
+//
+// The changes are ordered by their position in x, followed by
+// additions in the order of their position in y.
+//
+func Diff(x, y types.Type) []Change {
+	var d differ
+	if x, ok := x.(*types.Named); ok {
+		if y, ok := y.(*types.Named); ok {
+			d.diff("", x.Underlying(), y.Underlying())
+			d.objects("", namedMethods(x), namedMethods(y))
+			return d.changes
+		}
+	}
+	d.diff("", x, y)
+	return d.changes
+}
+
+// A differ collects the changes found while comparing types.
+type differ struct {
+	changes []Change
+	ifaces  []ifacePair // interfaces being compared
+}
+
+// An ifacePair is a pair of interfaces being compared.
+type ifacePair struct {
+	x, y *types.Interface
+}
+
+func (d *differ) report(kind ChangeKind, path string, x, y types.Type) {
+	d.changes = append(d.changes, Change{kind, path, x, y})
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func (d *differ) diff(path string, x, y types.Type) {
+	switch x := x.(type) {
+	case *types.Basic:
+		if y, ok := y.(*types.Basic); ok && x.Kind() == y.Kind() {
+			return
+		}
+
+	case *types.Array:
+		if y, ok := y.(*types.Array); ok && x.Len() == y.Len() {
+			d.diff(join(path, "elem"), x.Elem(), y.Elem())
+			return
+		}
+
+	case *types.Slice:
+		if y, ok := y.(*types.Slice); ok {
+			d.diff(join(path, "elem"), x.Elem(), y.Elem())
+			return
+		}
+
+	case *types.Pointer:
+		if y, ok := y.(*types.Pointer); ok {
+			d.diff(join(path, "elem"), x.Elem(), y.Elem())
+			return
+		}
+
+	case *types.Map:
+		if y, ok := y.(*types.Map); ok {
+			d.diff(join(path, "key"), x.Key(), y.Key())
+			d.diff(join(path, "elem"), x.Elem(), y.Elem())
+			return
+		}
+
+	case *types.Chan:
+		if y, ok := y.(*types.Chan); ok && x.Dir() == y.Dir() {
+			d.diff(join(path, "elem"), x.Elem(), y.Elem())
+			return
+		}
+
+	case *types.Struct:
+		if y, ok := y.(*types.Struct); ok {
+			d.fields(path, x, y)
+			return
+		}
+
+	case *types.Signature:
+		if y, ok := y.(*types.Signature); ok {
+			d.tuple(join(path, "params"), x.Params(), y.Params())
+			d.tuple(join(path, "results"), x.Results(), y.Results())
+			if x.Variadic() != y.Variadic() {
+				d.report(VariadicChanged, path, x, y)
+			}
+			return
+		}
+
+	case *types.Interface:
+		if y, ok := y.(*types.Interface); ok {
+			// Method sets include the methods of embedded
+			// interfaces, which may refer back to x and y;
+			// don't compare the same interfaces twice.
+			p := ifacePair{x, y}
+			for _, q := range d.ifaces {
+				if q == p {
+					return
+				}
+			}
+			d.ifaces = append(d.ifaces, p)
+			d.objects(path, ifaceMethods(x), ifaceMethods(y))
+			d.ifaces = d.ifaces[:len(d.ifaces)-1]
+			return
+		}
+
+	case *types.Named:
+		// Don't recurse into named types: they are compared by name.
+		// Together with the interfaces being compared, this
+		// terminates cycles.
+		if y, ok := y.(*types.Named); ok && sameName(x.Obj(), y.Obj()) {
+			return
+		}
+	}
+
+	d.report(TypeChanged, path, x, y)
+}
+
+// tuple compares the elements of x and y by position.
+func (d *differ) tuple(path string, x, y *types.Tuple) {
+	n := x.Len()
+	if m := y.Len(); m > n {
+		n = m
+	}
+	for i := 0; i < n; i++ {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= y.Len():
+			d.report(Removed, p, x.At(i).Type(), nil)
+		case i >= x.Len():
+			d.report(Added, p, nil, y.At(i).Type())
+		default:
+			d.diff(p, x.At(i).Type(), y.At(i).Type())
+		}
+	}
+}
+
+// objects compares the objects (fields or methods) in x and y by name.
+func (d *differ) objects(path string, x, y []types.Object) {
+	index := make(map[string]types.Object)
+	for _, obj := range y {
+		index[obj.Name()] = obj
+	}
+	seen := make(map[string]bool)
+	for _, obj := range x {
+		name := obj.Name()
+		if name == "_" {
+			continue
+		}
+		seen[name] = true
+		if alt := index[name]; alt != nil {
+			d.diff(join(path, name), obj.Type(), alt.Type())
+		} else {
+			d.report(Removed, join(path, name), obj.Type(), nil)
+		}
+	}
+	for _, obj := range y {
+		if name := obj.Name(); name != "_" && !seen[name] {
+			d.report(Added, join(path, name), nil, obj.Type())
+		}
+	}
+}
+
+// fields compares the fields of structs x and y by name, as objects does,
+// but also their order, tags, and embedded flags.
+func (d *differ) fields(path string, x, y *types.Struct) {
+	index := make(map[string]int)
+	for j := 0; j < y.NumFields(); j++ {
+		if name := y.Field(j).Name(); name != "_" {
+			index[name] = j
+		}
+	}
+
+	// The position of a field present in both structs among all
+	// such fields; their order may differ in x and y.
+	xrank := make(map[string]int)
+	for i := 0; i < x.NumFields(); i++ {
+		name := x.Field(i).Name()
+		if _, ok := index[name]; ok {
+			xrank[name] = len(xrank)
+		}
+	}
+	yrank := make(map[string]int)
+	for j := 0; j < y.NumFields(); j++ {
+		name := y.Field(j).Name()
+		if _, ok := xrank[name]; ok {
+			yrank[name] = len(yrank)
+		}
+	}
+
+	for i := 0; i < x.NumFields(); i++ {
+		f := x.Field(i)
+		name := f.Name()
+		if name == "_" {
+			continue
+		}
+		p := join(path, name)
+		j, ok := index[name]
+		if !ok {
+			d.report(Removed, p, f.Type(), nil)
+			continue
+		}
+		g := y.Field(j)
+		d.diff(p, f.Type(), g.Type())
+		if xrank[name] != yrank[name] {
+			d.report(FieldMoved, p, x, y)
+		}
+		if f.Anonymous() != g.Anonymous() {
+			d.report(EmbeddedChanged, p, x, y)
+		}
+		if x.Tag(i) != y.Tag(j) {
+			d.report(TagChanged, p, x, y)
+		}
+	}
+	for j := 0; j < y.NumFields(); j++ {
+		g := y.Field(j)
+		if name := g.Name(); name != "_" {
+			if _, ok := xrank[name]; !ok {
+				d.report(Added, join(path, name), nil, g.Type())
+			}
+		}
+	}
+}
+
+// fieldIndex returns the index of the field with the given name in t.
+func fieldIndex(t *types.Struct, name string) int {
+	for i := 0; i < t.NumFields(); i++ {
+		if t.Field(i).Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameName reports whether x and y have the same name and package path.
+func sameName(x, y types.Object) bool {
+	if x.Name() != y.Name() {
+		return false
+	}
+	xpkg, ypkg := x.Pkg(), y.Pkg()
+	if xpkg == nil || ypkg == nil {
+		return xpkg == ypkg
+	}
+	return xpkg.Path() == ypkg.Path()
+}
+
+func namedMethods(t *types.Named) []types.Object {
+	list := make([]types.Object, t.NumMethods())
+	for i := range list {
+		list[i] = t.Method(i)
+	}
+	return list
+}
+
+func ifaceMethods(t *types.Interface) []types.Object {
+	list := make([]types.Object, t.NumMethods())
+	for i := range list {
+		list[i] = t.Method(i)
+	}
+	return list
+}
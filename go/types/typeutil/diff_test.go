@@ -0,0 +1,169 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const diffOld = `package p
+
+type Reader interface {
+	Read(p []byte) (n int, err error)
+	Close() error
+	Printf(format string, args ...interface{})
+	Next() interface{ Reader }
+}
+
+type Config struct {
+	Name    string
+	Size    int
+	Opts    map[string]bool
+	Handler func(int) error
+	_       int
+}
+
+func (c *Config) Validate() error { return nil }
+func (c *Config) Reset()          {}
+
+type Base struct{}
+
+type Closer interface{ Close() error }
+
+type ReadCloser interface {
+	Closer
+	Read(p []byte) (n int, err error)
+}
+
+type Record struct {
+	ID    int    "id"
+	Label string "label"
+	Base
+	Extra int
+}
+`
+
+const diffNew = `package p
+
+type Reader interface {
+	Read(p []byte, off int64) (n int, err error)
+	Printf(format string, args []interface{})
+	Next() interface{ Reader }
+	Seek(off int64) int64
+}
+
+type Config struct {
+	Name    string
+	Size    int64
+	Opts    map[string]*Config
+	Handler func(int) (bool, error)
+	Verbose bool
+}
+
+func (c *Config) Validate() error { return nil }
+func (c *Config) Apply(*Config)   {}
+
+type Base struct{}
+
+type Closer interface{ Close() error }
+
+type ReadCloser interface {
+	Close() error
+	Read(p []byte) (n int, err error)
+}
+
+type Record struct {
+	Label string "name"
+	ID    int    "id"
+	Base  Base
+	Config
+}
+`
+
+func diffPkg(t *testing.T, src string) *types.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var conf types.Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestDiff(t *testing.T) {
+	old := diffPkg(t, diffOld)
+	new := diffPkg(t, diffNew)
+
+	for _, test := range []struct {
+		name string
+		want []string
+	}{
+		{"Reader", []string{
+			"Close: removed func() error",
+			// the method set of interface{ Reader } changes with Reader
+			"Next.results[0].Close: removed func() error",
+			"Next.results[0].Printf: is no longer variadic",
+			"Next.results[0].Read.params[1]: added int64",
+			"Next.results[0].Seek: added func(off int64) int64",
+			"Printf: is no longer variadic",
+			"Read.params[1]: added int64",
+			"Seek: added func(off int64) int64",
+		}},
+		{"Config", []string{
+			"Size: changed from int to int64",
+			"Opts.elem: changed from bool to *Config",
+			"Handler.results[0]: changed from error to bool",
+			"Handler.results[1]: added error",
+			"Verbose: added bool",
+			"Reset: removed func()",
+			"Apply: added func(*Config)",
+		}},
+		{"Record", []string{
+			"ID: moved from position 0 to 1",
+			"Label: moved from position 1 to 0",
+			`Label: tag changed from "label" to "name"`,
+			"Base: is no longer embedded",
+			"Extra: removed int",
+			"Config: added Config",
+		}},
+		{"ReadCloser", nil}, // same method set
+	} {
+		x := old.Scope().Lookup(test.name).Type()
+		y := new.Scope().Lookup(test.name).Type()
+		var got []string
+		for _, c := range typeutil.Diff(x, y) {
+			got = append(got, c.Format(types.RelativeTo(new)))
+		}
+		if g, w := strings.Join(got, "\n"), strings.Join(test.want, "\n"); g != w {
+			t.Errorf("%s: got changes:\n%s\nwant:\n%s", test.name, g, w)
+		}
+
+		// a type doesn't differ from itself
+		if changes := typeutil.Diff(x, x); len(changes) != 0 {
+			t.Errorf("%s: got %d changes comparing type with itself", test.name, len(changes))
+		}
+	}
+
+	// changes are formatted with the given qualifier
+	changes := typeutil.Diff(old.Scope().Lookup("Record").Type(), new.Scope().Lookup("Record").Type())
+	c := changes[len(changes)-1]
+	if got, want := c.Format(func(pkg *types.Package) string { return "v2/" + pkg.Name() }), "Config: added v2/p.Config"; got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+	if got, want := c.String(), "Config: added p.Config"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines EncodeObjectPath and DecodeObjectPath, which map
+// objects to stable, package-relative string paths and back.
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/types"
+)
+
+// An object path is a sequence of names separated by dots, e.g. "T.M.x".
+// The first name denotes a package-level object. Each subsequent name
+// denotes an object reachable from the object denoted by the preceding
+// names, as follows:
+//
+//	- for a type name, a method declared for the named type, a method
+//	  declared explicitly in its underlying interface, or a field of its
+//	  underlying struct;
+//	- for a function or method, a named parameter or result;
+//	- for a variable, struct field, parameter or result of unnamed
+//	  struct or interface type, a field or an explicitly declared method
+//	  of that type.
+//
+// Thus, given
+//
+//	type T struct{ f struct{ g int } }
+//	func (T) M(x int) (err error)
+//
+// the path "T.f.g" denotes field g and "T.M.x" denotes parameter x.
+//
+// Objects that are not reachable this way, such as local variables,
+// unnamed or blank parameters, and the members of named types declared
+// inside functions, have no path.
+
+// EncodeObjectPath returns the path of obj relative to its package.
+// The path can be decoded into the same object with DecodeObjectPath,
+// given the same package or a package type-checked from the same source
+// by another process. An error is returned if obj has no path.
+//
+func EncodeObjectPath(obj types.Object) (string, error) {
+	pkg := obj.Pkg()
+	if pkg == nil {
+		return "", fmt.Errorf("predeclared %s has no path", obj)
+	}
+	if obj.Name() == "_" {
+		return "", fmt.Errorf("blank %s has no path", obj)
+	}
+
+	// common case: package-level object
+	scope := pkg.Scope()
+	if scope.Lookup(obj.Name()) == obj {
+		return obj.Name(), nil
+	}
+
+	// search the objects reachable from package-level objects
+	// (in sorted order, for deterministic results)
+	for _, name := range scope.Names() {
+		if path := findPath(scope.Lookup(name), obj, name); path != "" {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no path in package %s", obj, pkg.Path())
+}
+
+// findPath returns the path of target among the objects reachable
+// from obj, where path is the path of obj, or "" if not found.
+func findPath(obj, target types.Object, path string) string {
+	if obj == target {
+		return path
+	}
+	for _, child := range children(obj) {
+		if p := findPath(child, target, path+"."+child.Name()); p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+// DecodeObjectPath returns the object denoted by path in package pkg.
+// It is the inverse of EncodeObjectPath.
+func DecodeObjectPath(pkg *types.Package, path string) (types.Object, error) {
+	names := strings.Split(path, ".")
+	obj := pkg.Scope().Lookup(names[0])
+	if obj == nil {
+		return nil, fmt.Errorf("invalid path %q: no object %s in package %s", path, names[0], pkg.Path())
+	}
+	for _, name := range names[1:] {
+		var found types.Object
+		for _, child := range children(obj) {
+			if child.Name() == name {
+				found = child
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("invalid path %q: %s has no member %s", path, obj, name)
+		}
+		obj = found
+	}
+	return obj, nil
+}
+
+// children returns the named, non-blank objects directly reachable
+// from obj as described in the comment on object paths. Their names
+// are unique within the result.
+func children(obj types.Object) []types.Object {
+	var list []types.Object
+	add := func(obj types.Object) {
+		if name := obj.Name(); name != "" && name != "_" {
+			list = append(list, obj)
+		}
+	}
+	addMembers := func(T types.Type) {
+		switch T := T.(type) {
+		case *types.Struct:
+			for i := 0; i < T.NumFields(); i++ {
+				add(T.Field(i))
+			}
+		case *types.Interface:
+			for i := 0; i < T.NumExplicitMethods(); i++ {
+				add(T.ExplicitMethod(i))
+			}
+		}
+	}
+	addVars := func(tup *types.Tuple) {
+		for i := 0; i < tup.Len(); i++ {
+			add(tup.At(i))
+		}
+	}
+
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		if named, _ := obj.Type().(*types.Named); named != nil {
+			for i := 0; i < named.NumMethods(); i++ {
+				add(named.Method(i))
+			}
+			addMembers(named.Underlying())
+		}
+	case *types.Func:
+		sig := obj.Type().(*types.Signature)
+		addVars(sig.Params())
+		addVars(sig.Results())
+	case *types.Var:
+		addMembers(obj.Type()) // unnamed types only
+	}
+	return list
+}
@@ -0,0 +1,129 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const objPathSrc = `package p
+
+type T struct {
+	f struct{ g int }
+	U
+}
+
+type U int
+
+func (T) M(x int) (err error) {
+	var local int
+	_ = local
+	return
+}
+
+type I interface {
+	N(y string)
+}
+
+var V interface{ O() }
+
+func F(int, _ string) (r struct{ h bool }) { return }
+
+const C = 0
+`
+
+// checkObjPathSrc type-checks objPathSrc in a new file set, as a
+// separate process would.
+func checkObjPathSrc(t *testing.T) (*token.FileSet, *types.Package, *types.Info) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", objPathSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	var conf types.Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fset, pkg, info
+}
+
+func TestObjectPath(t *testing.T) {
+	fset1, pkg1, info1 := checkObjPathSrc(t)
+	fset2, pkg2, _ := checkObjPathSrc(t)
+
+	want := map[string]string{
+		"T":       "p.go:3:6",
+		"T.f":     "p.go:4:2",
+		"T.f.g":   "p.go:4:12",
+		"T.U":     "p.go:5:2",
+		"T.M":     "p.go:10:10",
+		"T.M.x":   "p.go:10:12",
+		"T.M.err": "p.go:10:20",
+		"I.N":     "p.go:17:2",
+		"I.N.y":   "p.go:17:4",
+		"V.O":     "p.go:20:18",
+		"F.r":     "p.go:22:24",
+		"F.r.h":   "p.go:22:34",
+		"C":       "p.go:24:7",
+	}
+
+	// Every object with a path decodes to the corresponding
+	// object of the package checked by the "other process".
+	got := make(map[string]bool)
+	for id, obj := range info1.Defs {
+		if obj == nil {
+			continue
+		}
+		path, err := typeutil.EncodeObjectPath(obj)
+		if err != nil {
+			switch id.Name {
+			case "p", "local", "_":
+				// ok: no path
+			default:
+				t.Errorf("%s: %s", id.Name, err)
+			}
+			continue
+		}
+		got[path] = true
+		obj2, err := typeutil.DecodeObjectPath(pkg2, path)
+		if err != nil {
+			t.Errorf("%s: %s", path, err)
+			continue
+		}
+		pos1 := fset1.Position(obj.Pos()).String()
+		pos2 := fset2.Position(obj2.Pos()).String()
+		if pos1 != pos2 || obj.Name() != obj2.Name() {
+			t.Errorf("%s: got %s at %s; want %s at %s", path, obj2, pos2, obj, pos1)
+		}
+		if w, ok := want[path]; ok && pos1 != w {
+			t.Errorf("%s: denotes object at %s; want %s", path, pos1, w)
+		}
+	}
+	for path := range want {
+		if !got[path] {
+			t.Errorf("no object with path %s", path)
+		}
+	}
+
+	// The decoder rejects paths that denote no object.
+	for _, path := range []string{"", "X", "T.X", "T.f.X", "T.M.local", "C.X"} {
+		if obj, err := typeutil.DecodeObjectPath(pkg1, path); err == nil {
+			t.Errorf("%q: got %s; want error", path, obj)
+		}
+	}
+
+	// Predeclared objects have no path.
+	if path, err := typeutil.EncodeObjectPath(types.Universe.Lookup("int")); err == nil {
+		t.Errorf("int: got path %q; want error", path)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines OptimalOrder, which suggests a field order
+// that minimizes the size of a struct.
+
+import (
+	"sort"
+
+	"golang.org/x/tools/go/types"
+)
+
+// A FieldOrder describes the size of a struct type and the size
+// achievable by reordering its fields.
+type FieldOrder struct {
+	Size    int64 // size of the struct with its fields in source order
+	MinSize int64 // minimal size achievable by reordering the fields
+	Order   []int // suggested order, as indices of the struct's fields
+}
+
+// OptimalOrder returns the size of struct s as computed by sizes,
+// and a field order for which s has the minimal size. Sizes are
+// computed by types.Layout; if sizes is nil, the default sizes
+// used by the type-checker are assumed.
+//
+// The suggested order places zero-sized fields first, followed by
+// the other fields by decreasing alignment and then decreasing size;
+// fields that compare equal keep their relative order. If no order
+// is smaller than the source order, Order is the source order and
+// MinSize equals Size.
+//
+// Reordering fields may change the behavior of programs, for example
+// if they depend on the layout of the struct through package unsafe
+// or share it with C code. Clients such as linters should treat the
+// result as a suggestion.
+//
+func OptimalOrder(s *types.Struct, sizes types.Sizes) *FieldOrder {
+	l := types.Layout(s, sizes)
+
+	n := s.NumFields()
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	res := &FieldOrder{Size: l.Size, MinSize: l.Size, Order: order}
+
+	sorted := make([]int, n)
+	copy(sorted, order)
+	sort.Stable(byPacking{sorted, l.Fields})
+
+	fields := make([]*types.Var, n)
+	for i, k := range sorted {
+		fields[i] = s.Field(k)
+	}
+	if size := types.Layout(types.NewStruct(fields, nil), sizes).Size; size < l.Size {
+		res.MinSize = size
+		res.Order = sorted
+	}
+
+	return res
+}
+
+// byPacking sorts field indices into the order suggested by OptimalOrder.
+type byPacking struct {
+	order  []int
+	fields []types.FieldLayout
+}
+
+func (p byPacking) Len() int      { return len(p.order) }
+func (p byPacking) Swap(i, j int) { p.order[i], p.order[j] = p.order[j], p.order[i] }
+func (p byPacking) Less(i, j int) bool {
+	x, y := &p.fields[p.order[i]], &p.fields[p.order[j]]
+	if zx, zy := x.Size == 0, y.Size == 0; zx != zy {
+		return zx
+	}
+	if x.Align != y.Align {
+		return x.Align > y.Align
+	}
+	return x.Size > y.Size
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestOptimalOrder(t *testing.T) {
+	const src = `package p
+
+type T0 struct{}
+
+type T1 struct {
+	a int64
+	b int32
+}
+
+type T2 struct {
+	a bool
+	b int64
+	c bool
+}
+
+type T3 struct {
+	a bool
+	b int32
+	c bool
+	d int16
+	e struct{}
+	f int64
+}
+
+type T4 struct {
+	a [3]byte
+	b int16
+	c *int
+	d [0]int64
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name          string
+		sizes         types.Sizes
+		size, minSize int64
+		order         string
+	}{
+		{"T0", nil, 0, 0, "[]"},
+		{"T1", nil, 12, 12, "[0 1]"},
+		{"T2", nil, 17, 10, "[1 0 2]"},
+		{"T2", &types.StdSizes{WordSize: 4, MaxAlign: 4}, 13, 10, "[1 0 2]"},
+		{"T3", nil, 24, 16, "[4 5 1 3 0 2]"},
+		{"T4", nil, 16, 13, "[3 2 1 0]"},
+		{"T4", &types.StdSizes{WordSize: 4, MaxAlign: 4}, 12, 9, "[3 2 1 0]"},
+	} {
+		s := pkg.Scope().Lookup(test.name).Type().Underlying().(*types.Struct)
+		res := typeutil.OptimalOrder(s, test.sizes)
+		if res.Size != test.size || res.MinSize != test.minSize || fmt.Sprint(res.Order) != test.order {
+			t.Errorf("%s (sizes %v): got size %d, min size %d, order %v; want %d, %d, %s",
+				test.name, test.sizes, res.Size, res.MinSize, res.Order, test.size, test.minSize, test.order)
+		}
+	}
+}
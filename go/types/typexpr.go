@@ -23,12 +23,14 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 	x.mode = invalid
 	x.expr = e
 
-	scope, obj := check.scope.LookupParent(e.Name)
+	scope, obj := check.scope.LookupParent(e.Name, check.pos)
 	if obj == nil {
 		if e.Name == "_" {
-			check.errorf(e.Pos(), "cannot use _ as value or type")
+			check.errorf(e.Pos(), InvalidBlank, "cannot use _ as value or type")
 		} else {
-			check.errorf(e.Pos(), "undeclared name: %s", e.Name)
+			// If some package files are missing or have syntax errors,
+			// the name may be declared by them.
+			check.err(e.Pos(), UndeclaredName, "undeclared name: "+e.Name, check.incomplete)
 		}
 		return
 	}
@@ -43,12 +45,16 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 	// (This code is only needed for dot-imports. Without them,
 	// we only have to mark variables, see *Var case below).
 	if pkg := obj.Pkg(); pkg != check.pkg && pkg != nil {
-		delete(check.unusedDotImports[scope], pkg)
+		if m := check.unusedDotImports[scope]; m != nil {
+			check.lock()
+			delete(m, pkg)
+			check.unlock()
+		}
 	}
 
 	switch obj := obj.(type) {
 	case *PkgName:
-		check.errorf(e.Pos(), "use of package %s not in selector", obj.name)
+		check.errorf(e.Pos(), InvalidPkgUse, "use of package %s not in selector", obj.name)
 		return
 
 	case *Const:
@@ -58,7 +64,7 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 		}
 		if obj == universeIota {
 			if check.iota == nil {
-				check.errorf(e.Pos(), "cannot use iota outside constant declaration")
+				check.errorf(e.Pos(), InvalidIota, "cannot use iota outside constant declaration")
 				return
 			}
 			x.val = check.iota
@@ -74,12 +80,12 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 		// (it's ok to iterate forward because each named type appears at most once in path)
 		for i, prev := range path {
 			if prev == obj {
-				check.errorf(obj.pos, "illegal cycle in declaration of %s", obj.name)
+				check.errorf(obj.pos, InvalidDeclCycle, "illegal cycle in declaration of %s", obj.name)
 				// print cycle
 				for _, obj := range path[i:] {
-					check.errorf(obj.Pos(), "\t%s refers to", obj.Name()) // secondary error, \t indented
+					check.errorf(obj.Pos(), InvalidDeclCycle, "\t%s refers to", obj.Name()) // secondary error, \t indented
 				}
-				check.errorf(obj.Pos(), "\t%s", obj.Name())
+				check.errorf(obj.Pos(), InvalidDeclCycle, "\t%s", obj.Name())
 				// maintain x.mode == typexpr despite error
 				typ = Typ[Invalid]
 				break
@@ -87,7 +93,9 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 		}
 
 	case *Var:
-		if obj.pkg == check.pkg {
+		// Only local variables are marked as used; for package-level
+		// variables, the flag is not needed.
+		if obj.pkg == check.pkg && obj.parent != check.pkg.scope {
 			obj.used = true
 		}
 		check.addDeclDep(obj)
@@ -130,25 +138,49 @@ func (check *Checker) typExpr(e ast.Expr, def *Named, path []*TypeName) (T Type)
 		}()
 	}
 
+	switch e.(type) {
+	case *ast.ArrayType, *ast.StructType, *ast.StarExpr, *ast.FuncType, *ast.InterfaceType, *ast.MapType, *ast.ChanType:
+		check.stats.Types++
+	}
+
+	check.enter(e)
 	T = check.typExprInternal(e, def, path)
+	check.leave()
+	if def == nil {
+		T = check.canonical(T)
+	}
 	assert(isTyped(T))
 	check.recordTypeAndValue(e, typexpr, T, nil)
 
 	return
 }
 
+// funcLitType type-checks the type e of a function literal. Unlike the
+// types of other type expressions, the signature is not shared with
+// identical types: it provides the scope of the function literal.
+func (check *Checker) funcLitType(e *ast.FuncType) *Signature {
+	check.stats.Types++
+	check.enter(e)
+	sig := new(Signature)
+	check.funcType(sig, nil, e)
+	check.leave()
+	check.recordTypeAndValue(e, typexpr, sig, nil)
+	return sig
+}
+
 func (check *Checker) typ(e ast.Expr) Type {
 	return check.typExpr(e, nil, nil)
 }
 
 // funcType type-checks a function or method type and returns its signature.
 func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast.FuncType) *Signature {
-	scope := NewScope(check.scope, "function")
+	scope := NewScope(check.scope, ftyp.Pos(), ftyp.End(), "function")
 	check.recordScope(ftyp, scope)
 
-	recvList, _ := check.collectParams(scope, recvPar, false)
-	params, variadic := check.collectParams(scope, ftyp.Params, true)
-	results, _ := check.collectParams(scope, ftyp.Results, false)
+	scopePos := ftyp.End() // all parameters' scopes start after the signature
+	recvList, _ := check.collectParams(scope, recvPar, false, scopePos)
+	params, variadic := check.collectParams(scope, ftyp.Params, true, scopePos)
+	results, _ := check.collectParams(scope, ftyp.Results, false, scopePos)
 
 	if recvPar != nil {
 		// recv parameter list present (may be empty)
@@ -157,11 +189,11 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 		var recv *Var
 		switch len(recvList) {
 		case 0:
-			check.error(recvPar.Pos(), "method is missing receiver")
+			check.error(recvPar.Pos(), InvalidMethodDecl, "method is missing receiver")
 			recv = NewParam(0, nil, "", Typ[Invalid]) // ignore recv below
 		default:
 			// more than one receiver
-			check.error(recvList[len(recvList)-1].Pos(), "method must have exactly one receiver")
+			check.error(recvList[len(recvList)-1].Pos(), InvalidMethodDecl, "method must have exactly one receiver")
 			fallthrough // continue with first receiver
 		case 1:
 			recv = recvList[0]
@@ -192,7 +224,7 @@ func (check *Checker) funcType(sig *Signature, recvPar *ast.FieldList, ftyp *ast
 				err = "basic or unnamed type"
 			}
 			if err != "" {
-				check.errorf(recv.pos, "invalid receiver %s (%s)", recv.typ, err)
+				check.errorf(recv.pos, InvalidRecv, "invalid receiver %s (%s)", recv.typ, err)
 				// ok to continue
 			}
 		}
@@ -227,9 +259,9 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 		case invalid:
 			// ignore - error reported before
 		case novalue:
-			check.errorf(x.pos(), "%s used as type", &x)
+			check.errorf(x.pos(), NotAType, "%s used as type", &x)
 		default:
-			check.errorf(x.pos(), "%s is not a type", &x)
+			check.errorf(x.pos(), NotAType, "%s is not a type", &x)
 		}
 
 	case *ast.SelectorExpr:
@@ -244,9 +276,9 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 		case invalid:
 			// ignore - error reported before
 		case novalue:
-			check.errorf(x.pos(), "%s used as type", &x)
+			check.errorf(x.pos(), NotAType, "%s used as type", &x)
 		default:
-			check.errorf(x.pos(), "%s is not a type", &x)
+			check.errorf(x.pos(), NotAType, "%s is not a type", &x)
 		}
 
 	case *ast.ParenExpr:
@@ -306,7 +338,7 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 		// it is safe to continue in any case (was issue 6667).
 		check.delay(func() {
 			if !Comparable(typ.key) {
-				check.errorf(e.Key.Pos(), "invalid map key type %s", typ.key)
+				check.errorf(e.Key.Pos(), InvalidMapKey, "invalid map key type %s", typ.key)
 			}
 		})
 
@@ -334,7 +366,7 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 		return typ
 
 	default:
-		check.errorf(e.Pos(), "%s is not a type", e)
+		check.errorf(e.Pos(), NotAType, "%s is not a type", e)
 	}
 
 	typ := Typ[Invalid]
@@ -353,7 +385,7 @@ func (check *Checker) typOrNil(e ast.Expr) Type {
 	case invalid:
 		// ignore - error reported before
 	case novalue:
-		check.errorf(x.pos(), "%s used as type", &x)
+		check.errorf(x.pos(), NotAType, "%s used as type", &x)
 	case typexpr:
 		return x.typ
 	case value:
@@ -362,7 +394,7 @@ func (check *Checker) typOrNil(e ast.Expr) Type {
 		}
 		fallthrough
 	default:
-		check.errorf(x.pos(), "%s is not a type", &x)
+		check.errorf(x.pos(), NotAType, "%s is not a type", &x)
 	}
 	return Typ[Invalid]
 }
@@ -372,23 +404,23 @@ func (check *Checker) arrayLength(e ast.Expr) int64 {
 	check.expr(&x, e)
 	if x.mode != constant {
 		if x.mode != invalid {
-			check.errorf(x.pos(), "array length %s must be constant", &x)
+			check.errorf(x.pos(), InvalidArrayLen, "array length %s must be constant", &x)
 		}
 		return 0
 	}
 	if !x.isInteger() {
-		check.errorf(x.pos(), "array length %s must be integer", &x)
+		check.errorf(x.pos(), InvalidArrayLen, "array length %s must be integer", &x)
 		return 0
 	}
 	n, ok := exact.Int64Val(x.val)
 	if !ok || n < 0 {
-		check.errorf(x.pos(), "invalid array length %s", &x)
+		check.errorf(x.pos(), InvalidArrayLen, "invalid array length %s", &x)
 		return 0
 	}
 	return n
 }
 
-func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicOk bool) (params []*Var, variadic bool) {
+func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicOk bool, scopePos token.Pos) (params []*Var, variadic bool) {
 	if list == nil {
 		return
 	}
@@ -416,7 +448,7 @@ func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicO
 					// ok to continue
 				}
 				par := NewParam(name.Pos(), check.pkg, name.Name, typ)
-				check.declare(scope, name, par)
+				check.declare(scope, name, par, scopePos)
 				params = append(params, par)
 			}
 			named = true
@@ -437,7 +469,7 @@ func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicO
 	// For a variadic function, change the last parameter's type from T to []T.
 	if variadic && len(params) > 0 {
 		last := params[len(params)-1]
-		last.typ = &Slice{elem: last.typ}
+		last.typ = check.canonical(&Slice{elem: last.typ})
 	}
 
 	return
@@ -445,8 +477,8 @@ func (check *Checker) collectParams(scope *Scope, list *ast.FieldList, variadicO
 
 func (check *Checker) declareInSet(oset *objset, pos token.Pos, obj Object) bool {
 	if alt := oset.insert(obj); alt != nil {
-		check.errorf(pos, "%s redeclared", obj.Name())
-		check.reportAltDecl(alt)
+		check.errorf(pos, DuplicateDecl, "%s redeclared", obj.Name())
+		check.reportAltDecl(alt, DuplicateDecl)
 		return false
 	}
 	return true
@@ -487,7 +519,7 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 			// spec: "As with all method sets, in an interface type,
 			// each method must have a unique non-blank name."
 			if name.Name == "_" {
-				check.errorf(pos, "invalid method name _")
+				check.errorf(pos, InvalidMethodDecl, "invalid method name _")
 				continue
 			}
 			// Don't type-check signature yet - use an
@@ -506,6 +538,7 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 				iface.allMethods = append(iface.allMethods, m)
 				signatures = append(signatures, f.Type)
 				check.recordDef(name, m)
+				check.recordDoc(m, f.Doc)
 			}
 		} else {
 			// embedded type
@@ -539,7 +572,7 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 		embed, _ := u.(*Interface)
 		if embed == nil {
 			if u != Typ[Invalid] {
-				check.errorf(pos, "%s is not an interface", named)
+				check.errorf(pos, InvalidEmbedded, "%s is not an interface", named)
 			}
 			continue
 		}
@@ -626,9 +659,10 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType, path []*TypeNa
 	// for double-declaration checks
 	var fset objset
 
-	// current field typ and tag
+	// current field typ, tag, and parsed tag (if checked)
 	var typ Type
 	var tag string
+	var pairs []TagPair
 	// anonymous != nil indicates an anonymous field.
 	add := func(field *ast.Field, ident *ast.Ident, anonymous *TypeName, pos token.Pos) {
 		if tag != "" && tags == nil {
@@ -644,6 +678,10 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType, path []*TypeNa
 		if name == "_" || check.declareInSet(&fset, pos, fld) {
 			fields = append(fields, fld)
 			check.recordDef(ident, fld)
+			check.recordDoc(fld, field.Doc)
+			if pairs != nil {
+				check.recordStructTag(fld, pairs)
+			}
 		}
 		if anonymous != nil {
 			check.recordUse(ident, anonymous)
@@ -653,6 +691,7 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType, path []*TypeNa
 	for _, f := range list.List {
 		typ = check.typExpr(f.Type, nil, path)
 		tag = check.tag(f.Tag)
+		pairs = check.structTag(f.Tag, tag)
 		if len(f.Names) > 0 {
 			// named fields
 			for _, name := range f.Names {
@@ -671,7 +710,7 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType, path []*TypeNa
 				}
 				// unsafe.Pointer is treated like a regular pointer
 				if t.kind == UnsafePointer {
-					check.errorf(pos, "anonymous field type cannot be unsafe.Pointer")
+					check.errorf(pos, InvalidEmbedded, "anonymous field type cannot be unsafe.Pointer")
 					continue
 				}
 				add(f, name, Universe.Lookup(t.name).(*TypeName), pos)
@@ -684,20 +723,25 @@ func (check *Checker) structType(styp *Struct, e *ast.StructType, path []*TypeNa
 				case *Basic:
 					// unsafe.Pointer is treated like a regular pointer
 					if u.kind == UnsafePointer {
-						check.errorf(pos, "anonymous field type cannot be unsafe.Pointer")
+						check.errorf(pos, InvalidEmbedded, "anonymous field type cannot be unsafe.Pointer")
 						continue
 					}
 				case *Pointer:
-					check.errorf(pos, "anonymous field type cannot be a pointer")
+					check.errorf(pos, InvalidEmbedded, "anonymous field type cannot be a pointer")
 					continue
 				case *Interface:
 					if isPtr {
-						check.errorf(pos, "anonymous field type cannot be a pointer to an interface")
+						check.errorf(pos, InvalidEmbedded, "anonymous field type cannot be a pointer to an interface")
 						continue
 					}
 				}
 				add(f, name, t.obj, pos)
 
+			case *Pointer:
+				// **T (or *P for an unnamed pointer type P) can only be
+				// constructed with an AST not produced by the parser
+				check.errorf(pos, InvalidEmbedded, "anonymous field type cannot be a pointer")
+
 			default:
 				check.invalidAST(pos, "anonymous field type %s must be named", typ)
 			}
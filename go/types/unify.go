@@ -0,0 +1,142 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Unify.
+
+package types
+
+// Unify attempts to unify the types x and y. The (named) types whose
+// type names are listed in vars are type variables: they may appear in
+// x or y, and they stand for arbitrary types. Unify reports whether
+// there is a substitution of type variables that makes x and y identical;
+// if so, it returns the respective bindings. A type variable that is not
+// constrained by x and y is not bound; a type variable may be bound to
+// another type variable.
+//
+// For instance, given the type variable T, unifying []T with []int binds
+// T to int, and unifying func(T) T with func(int) string fails.
+//
+func Unify(x, y Type, vars []*TypeName) (map[*TypeName]Type, bool) {
+	u := unifier{
+		vars:     make(map[*TypeName]bool),
+		bindings: make(map[*TypeName]Type),
+	}
+	for _, v := range vars {
+		u.vars[v] = true
+	}
+	if !u.unify(x, y, nil) {
+		return nil, false
+	}
+	return u.bindings, true
+}
+
+// A unifier maintains the state of a unification.
+type unifier struct {
+	vars     map[*TypeName]bool // set of type variables
+	bindings map[*TypeName]Type // type variable bindings
+}
+
+// typeVar returns the type variable denoted by typ, or nil.
+func (u *unifier) typeVar(typ Type) *TypeName {
+	if t, _ := typ.(*Named); t != nil && u.vars[t.obj] {
+		return t.obj
+	}
+	return nil
+}
+
+// unify is like identical but binds type variables as needed.
+func (u *unifier) unify(x, y Type, p *typePair) bool {
+	c := comparer{vars: u.bindVars}
+	return c.identical(x, y, p)
+}
+
+// bindVars unifies x and y if one of them is a type variable.
+func (u *unifier) bindVars(x, y Type, p *typePair) (identical, handled bool) {
+	if v := u.typeVar(x); v != nil {
+		return u.bind(v, y, p), true
+	}
+	if v := u.typeVar(y); v != nil {
+		return u.bind(v, x, p), true
+	}
+	return false, false
+}
+
+// bind unifies the type variable v with typ.
+func (u *unifier) bind(v *TypeName, typ Type, p *typePair) bool {
+	if t, found := u.bindings[v]; found {
+		return u.unify(t, typ, p)
+	}
+	if w := u.typeVar(typ); w != nil {
+		if w == v {
+			return true
+		}
+		if t, found := u.bindings[w]; found {
+			return u.bind(v, t, p)
+		}
+	}
+	if u.occurs(v, typ, nil) {
+		return false // v cannot be bound to a type containing v
+	}
+	u.bindings[v] = typ
+	return true
+}
+
+// occurs reports whether the type variable v occurs in typ,
+// taking the current bindings into account. seen records the
+// interfaces visited so far, since interfaces may recur.
+func (u *unifier) occurs(v *TypeName, typ Type, seen map[*Interface]bool) bool {
+	switch t := typ.(type) {
+	case *Basic:
+		return false
+	case *Array:
+		return u.occurs(v, t.elem, seen)
+	case *Slice:
+		return u.occurs(v, t.elem, seen)
+	case *Struct:
+		for _, f := range t.fields {
+			if u.occurs(v, f.typ, seen) {
+				return true
+			}
+		}
+	case *Pointer:
+		return u.occurs(v, t.base, seen)
+	case *Tuple:
+		if t != nil {
+			for _, x := range t.vars {
+				if u.occurs(v, x.typ, seen) {
+					return true
+				}
+			}
+		}
+	case *Signature:
+		return u.occurs(v, t.params, seen) || u.occurs(v, t.results, seen)
+	case *Interface:
+		if seen[t] {
+			return false
+		}
+		if seen == nil {
+			seen = make(map[*Interface]bool)
+		}
+		seen[t] = true
+		for _, m := range t.allMethods {
+			if u.occurs(v, m.typ, seen) {
+				return true
+			}
+		}
+	case *Map:
+		return u.occurs(v, t.key, seen) || u.occurs(v, t.elem, seen)
+	case *Chan:
+		return u.occurs(v, t.elem, seen)
+	case *Named:
+		if w := u.typeVar(t); w != nil {
+			if w == v {
+				return true
+			}
+			if b, found := u.bindings[w]; found {
+				return u.occurs(v, b, seen)
+			}
+		}
+	}
+	return false
+}
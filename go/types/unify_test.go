@@ -0,0 +1,110 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/go/types"
+)
+
+func TestUnify(t *testing.T) {
+	// T, U, and V are the type variables.
+	const src = `package p
+type T int
+type U int
+type V int
+type N struct{ x T }
+type I interface{ m() I }
+type J interface{ m() J }
+var (
+	x0 []T
+	y0 []int
+
+	x1 func(T, []U) map[T]U
+	y1 func(int, []string) map[int]string
+
+	x2 func(T) T
+	y2 func(int) string
+
+	x3 struct{ a T; b U }
+	y3 struct{ a U; b *int }
+
+	x4 T
+	y4 []T
+
+	x5 map[T]U
+	y5 map[U]T
+
+	x6 func(T, ...U)
+	y6 func(int, []string)
+
+	x7 chan<- T
+	y7 <-chan int
+
+	x8 [2]T
+	y8 [3]int
+
+	x9 N
+	y9 T
+
+	x10 struct{ a T; b U; c V }
+	y10 struct{ a U; b V; c []T }
+
+	x11 I
+	y11 J
+)
+`
+	pkg, err := pkgFor("unify.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := pkg.Scope()
+	var vars []*TypeName
+	for _, name := range []string{"T", "U", "V"} {
+		vars = append(vars, scope.Lookup(name).(*TypeName))
+	}
+
+	var tests = []struct {
+		x, y     string
+		bindings string // sorted list of bindings, or "fail"
+	}{
+		{"x0", "y0", "T=int"},
+		{"x1", "y1", "T=int U=string"},
+		{"x2", "y2", "fail"}, // conflicting bindings for T
+		{"x3", "y3", "T=p.U U=*int"},
+		{"x4", "y4", "fail"}, // T occurs in []T
+		{"x5", "y5", "T=p.U"},
+		{"x6", "y6", "fail"}, // variadic mismatch
+		{"x7", "y7", "fail"}, // channel direction mismatch
+		{"x8", "y8", "fail"}, // array length mismatch
+		{"x9", "y9", "T=p.N"},
+		{"x10", "y10", "fail"}, // T = U = V = []T
+		{"x11", "y11", "fail"}, // distinct named types
+		{"x11", "x11", ""},
+		{"y1", "y1", ""},
+	}
+
+	for _, test := range tests {
+		x := scope.Lookup(test.x).Type()
+		y := scope.Lookup(test.y).Type()
+		bindings, ok := Unify(x, y, vars)
+
+		got := "fail"
+		if ok {
+			var list []string
+			for v, typ := range bindings {
+				list = append(list, v.Name()+"="+typ.String())
+			}
+			sort.Strings(list)
+			got = strings.Join(list, " ")
+		}
+		if got != test.bindings {
+			t.Errorf("Unify(%s, %s): got %q; want %q", x, y, got, test.bindings)
+		}
+	}
+}
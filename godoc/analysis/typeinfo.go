@@ -92,7 +92,7 @@ func (a *analysis) doTypeInfo(info *loader.PackageInfo, implements map[*types.Na
 		fi.addLink(aLink{
 			start: offset,
 			end:   offset + len(id.Name),
-			title: types.ObjectString(info.Pkg, obj),
+			title: types.ObjectString(obj, types.RelativeTo(info.Pkg)),
 			href:  a.posURL(pos, Len),
 		})
 	}
@@ -130,7 +130,7 @@ func (a *analysis) namedType(obj *types.TypeName, implements map[*types.Named]im
 			ByKind: byKind,
 			Other: anchorJSON{
 				Href: a.posURL(Tobj.Pos(), len(Tobj.Name())),
-				Text: types.TypeString(this, T),
+				Text: types.TypeString(T, types.RelativeTo(this)),
 			},
 		})
 	}
@@ -142,7 +142,7 @@ func (a *analysis) namedType(obj *types.TypeName, implements map[*types.Named]im
 			// "T is implemented by <iface>"...
 			// "T implements        <iface>"...
 			group := implGroupJSON{
-				Descr: types.TypeString(this, T),
+				Descr: types.TypeString(T, types.RelativeTo(this)),
 			}
 			// Show concrete types first; use two passes.
 			for _, sub := range r.to {
@@ -164,7 +164,7 @@ func (a *analysis) namedType(obj *types.TypeName, implements map[*types.Named]im
 			if r.from != nil {
 				// "T implements <iface>"...
 				group := implGroupJSON{
-					Descr: types.TypeString(this, T),
+					Descr: types.TypeString(T, types.RelativeTo(this)),
 				}
 				for _, super := range r.from {
 					addFact(&group, super, false)
@@ -174,7 +174,7 @@ func (a *analysis) namedType(obj *types.TypeName, implements map[*types.Named]im
 			if r.fromPtr != nil {
 				// "*C implements <iface>"...
 				group := implGroupJSON{
-					Descr: "*" + types.TypeString(this, T),
+					Descr: "*" + types.TypeString(T, types.RelativeTo(this)),
 				}
 				for _, psuper := range r.fromPtr {
 					addFact(&group, psuper, false)
@@ -190,7 +190,7 @@ func (a *analysis) namedType(obj *types.TypeName, implements map[*types.Named]im
 		pos := meth.Pos() // may be 0 for error.Error
 		v.Methods = append(v.Methods, anchorJSON{
 			Href: a.posURL(pos, len(meth.Name())),
-			Text: types.SelectionString(this, sel),
+			Text: types.SelectionString(sel, types.RelativeTo(this)),
 		})
 	}
 
@@ -579,7 +579,7 @@ func (r *describePackageResult) display(printf printfFunc) {
 	for _, mem := range r.members {
 		printf(mem.obj, "\t%s", formatMember(mem.obj, maxname))
 		for _, meth := range mem.methods {
-			printf(meth.Obj(), "\t\t%s", types.SelectionString(r.pkg, meth))
+			printf(meth.Obj(), "\t\t%s", types.SelectionString(meth, types.RelativeTo(r.pkg)))
 		}
 	}
 }
@@ -589,10 +589,10 @@ func formatMember(obj types.Object, maxname int) string {
 	fmt.Fprintf(&buf, "%-5s %-*s", tokenOf(obj), maxname, obj.Name())
 	switch obj := obj.(type) {
 	case *types.Const:
-		fmt.Fprintf(&buf, " %s = %s", types.TypeString(obj.Pkg(), obj.Type()), obj.Val().String())
+		fmt.Fprintf(&buf, " %s = %s", types.TypeString(obj.Type(), types.RelativeTo(obj.Pkg())), obj.Val().String())
 
 	case *types.Func:
-		fmt.Fprintf(&buf, " %s", types.TypeString(obj.Pkg(), obj.Type()))
+		fmt.Fprintf(&buf, " %s", types.TypeString(obj.Type(), types.RelativeTo(obj.Pkg())))
 
 	case *types.TypeName:
 		// Abbreviate long aggregate type names.
@@ -608,13 +608,13 @@ func formatMember(obj types.Object, maxname int) string {
 			}
 		}
 		if abbrev == "" {
-			fmt.Fprintf(&buf, " %s", types.TypeString(obj.Pkg(), obj.Type().Underlying()))
+			fmt.Fprintf(&buf, " %s", types.TypeString(obj.Type().Underlying(), types.RelativeTo(obj.Pkg())))
 		} else {
 			fmt.Fprintf(&buf, " %s", abbrev)
 		}
 
 	case *types.Var:
-		fmt.Fprintf(&buf, " %s", types.TypeString(obj.Pkg(), obj.Type()))
+		fmt.Fprintf(&buf, " %s", types.TypeString(obj.Type(), types.RelativeTo(obj.Pkg())))
 	}
 	return buf.String()
 }
@@ -738,7 +738,7 @@ func methodsToSerial(this *types.Package, methods []*types.Selection, fset *toke
 	var jmethods []serial.DescribeMethod
 	for _, meth := range methods {
 		jmethods = append(jmethods, serial.DescribeMethod{
-			Name: types.SelectionString(this, meth),
+			Name: types.SelectionString(meth, types.RelativeTo(this)),
 			Pos:  fset.Position(meth.Obj().Pos()).String(),
 		})
 	}
@@ -163,7 +163,7 @@ func (r *freevarsResult) display(printf printfFunc) {
 			// Avoid printing "type T T".
 			var typstr string
 			if ref.kind != "type" {
-				typstr = " " + types.TypeString(r.qpos.info.Pkg, ref.typ)
+				typstr = " " + types.TypeString(ref.typ, types.RelativeTo(r.qpos.info.Pkg))
 			}
 			printf(ref.obj, "%s %s%s", ref.kind, ref.ref, typstr)
 		}
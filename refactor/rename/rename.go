@@ -79,6 +79,9 @@ Flags:
 
 -v         enables verbose logging.
 
+-tags      a space-separated list of build tags to consider satisfied
+           when locating packages, as for 'go build -tags'.
+
 gorename automatically computes the set of packages that might be
 affected.  For a local renaming, this is just the package specified by
 -from or -offset, but for a potentially exported name, gorename scans